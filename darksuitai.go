@@ -1,7 +1,12 @@
 package darksuitai
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,18 +14,28 @@ import (
 
 	"github.com/darksuit-ai/darksuitai/internal"
 	anthropicllm "github.com/darksuit-ai/darksuitai/internal/llms/anthropic"
+	geminillm "github.com/darksuit-ai/darksuitai/internal/llms/gemini"
+	groqllm "github.com/darksuit-ai/darksuitai/internal/llms/groq"
+	openaillm "github.com/darksuit-ai/darksuitai/internal/llms/openai"
 	"github.com/darksuit-ai/darksuitai/internal/memory"
 	"github.com/darksuit-ai/darksuitai/internal/memory/embed"
+	"github.com/darksuit-ai/darksuitai/internal/memory/ingest"
 	"github.com/darksuit-ai/darksuitai/internal/memory/mongodb"
+	"github.com/darksuit-ai/darksuitai/internal/memory/redisdb"
 	"github.com/darksuit-ai/darksuitai/internal/observability"
 	"github.com/darksuit-ai/darksuitai/pkg/agent"
 	"github.com/darksuit-ai/darksuitai/pkg/agent/_chat"
 	"github.com/darksuit-ai/darksuitai/pkg/agent/_stream"
 	ai "github.com/darksuit-ai/darksuitai/pkg/chat"
 	convai "github.com/darksuit-ai/darksuitai/pkg/convchat"
+	"github.com/darksuit-ai/darksuitai/pkg/mcp"
 	"github.com/darksuit-ai/darksuitai/pkg/tools"
+	"github.com/darksuit-ai/darksuitai/pkg/tools/fetch"
+	"github.com/darksuit-ai/darksuitai/pkg/tools/openapi"
+	"github.com/darksuit-ai/darksuitai/pkg/tools/search"
 	"github.com/darksuit-ai/darksuitai/types"
 	"github.com/joho/godotenv"
+	goredis "github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -92,6 +107,10 @@ type (
 	ObserverRunHandle = observability.RunHandle
 	ObserverLLMCall   = observability.LLMCall
 	ObserverToolCall  = observability.ToolCall
+	// ObserverToolProgress is a progress event reported by a running tool;
+	// RunHandles that implement ObserverProgressRecorder receive them.
+	ObserverToolProgress     = observability.ToolProgress
+	ObserverProgressRecorder = observability.ProgressRecorder
 )
 
 // NewStdoutObserver returns an Observer that prints one JSON event per line to
@@ -114,14 +133,105 @@ type (
 	Summarizer = memory.Summarizer
 	// Embedder converts text to vector embeddings for semantic recall.
 	Embedder = memory.Embedder
+	// BatchEmbedder is an Embedder that embeds many texts per call.
+	BatchEmbedder = memory.BatchEmbedder
+	// EmbedBatchConfig bounds the batch size and requests in flight of an
+	// embedder's EmbedBatch.
+	EmbedBatchConfig = memory.BatchConfig
+	// CachingEmbedder is an Embedder with an LRU cache keyed by text hash.
+	CachingEmbedder = memory.CachingEmbedder
 	// VectorStore persists and retrieves text embeddings.
 	VectorStore = memory.VectorStore
+	// VectorLookup is implemented by vector stores that report which IDs
+	// they already hold.
+	VectorLookup = memory.VectorLookup
 	// SummaryStore persists a session's rolling summary.
 	SummaryStore = memory.SummaryStore
-	// MemoryTurn is a single Human/AI exchange.
+	// MemoryTurn is a single Human/AI exchange, with the tool calls made
+	// while answering.
 	MemoryTurn = memory.Turn
+	// TurnToolCall records one tool execution within a MemoryTurn (name,
+	// input, truncated output, error, duration).
+	TurnToolCall = memory.ToolCall
 	// MemoryHit is a semantic-search result.
 	MemoryHit = memory.Hit
+	// MemoryFilter restricts vector search and deletion to entries whose
+	// metadata matches every condition.
+	MemoryFilter = memory.Filter
+	// MemoryCondition is one metadata test in a MemoryFilter.
+	MemoryCondition = memory.Condition
+	// VectorSnapshotter is implemented by the in-memory vector stores, which
+	// can save their contents to a stream and load them back.
+	VectorSnapshotter = memory.Snapshotter
+	// KeywordIndex is a lexical index searched next to a VectorStore.
+	KeywordIndex = memory.KeywordIndex
+	// BM25Config tunes the BM25 keyword index (K1, B, Tokenizer).
+	BM25Config = memory.BM25Config
+	// HybridRetriever fuses vector and keyword search, optionally reranked.
+	HybridRetriever = memory.HybridRetriever
+	// HybridConfig tunes fusion (RRF or weighted), candidates and reranking.
+	HybridConfig = memory.HybridConfig
+	// Reranker reorders search hits by relevance to the query.
+	Reranker = memory.Reranker
+	// IngestDocument is a loaded document (source, format, text, metadata).
+	IngestDocument = ingest.Document
+	// Ingester chunks, embeds and upserts documents into a VectorStore.
+	Ingester = ingest.Ingester
+	// IngestConfig tunes ingestion (chunker, batch size, concurrency).
+	IngestConfig = ingest.Config
+	// IngestResult reports how many documents and chunks were written.
+	IngestResult = ingest.Result
+	// DocumentLoader extracts the text of one document format; set one in
+	// IngestConfig.Loaders to replace a built-in loader.
+	DocumentLoader = ingest.Loader
+	// DocumentLoaderFunc adapts a function to a DocumentLoader.
+	DocumentLoaderFunc = ingest.LoaderFunc
+	// DocumentChunker splits document text into chunks.
+	DocumentChunker = ingest.Chunker
+	// FixedChunker cuts fixed-size windows with overlap.
+	FixedChunker = ingest.FixedChunker
+	// SentenceChunker packs whole sentences into chunks.
+	SentenceChunker = ingest.SentenceChunker
+	// MarkdownChunker splits Markdown by heading and records the heading path.
+	MarkdownChunker = ingest.MarkdownChunker
+	// RetrieverToolConfig tunes a tool built by NewRetrieverTool.
+	RetrieverToolConfig = tools.RetrieverConfig
+	// HNSWConfig tunes the HNSW vector index (M, EfConstruction, EfSearch).
+	HNSWConfig = memory.HNSWConfig
+	// Recaller embeds completed turns and recalls relevant older ones.
+	Recaller = memory.Recaller
+	// RecallConfig tunes semantic recall (top-K, session or user scope).
+	RecallConfig = memory.RecallConfig
+	// ChatMemory persists a session's conversation transcript.
+	ChatMemory = memory.ChatMemory
+	// UserMemory keeps durable, model-extracted facts about each user.
+	UserMemory = memory.UserMemory
+	// UserMemoryConfig tunes user memory (deduplication, profile size).
+	UserMemoryConfig = memory.UserMemoryConfig
+	// UserFact is a durable fact known about a user.
+	UserFact = memory.Fact
+	// FactStore persists user facts.
+	FactStore = memory.FactStore
+	// FactExtractor decides how a turn changes a user's facts.
+	FactExtractor = memory.FactExtractor
+	// Session is the record of one conversation (user, title, tags, turn
+	// count, timestamps).
+	Session = memory.Session
+	// SessionStore persists session records.
+	SessionStore = memory.SessionStore
+	// SessionManager lists, renames, tags, exports and deletes sessions.
+	SessionManager = memory.SessionManager
+	// SessionConfig wires a SessionManager to the chat memory, summary store
+	// and recaller holding each session's data.
+	SessionConfig = memory.SessionConfig
+	// SessionExport is the JSON document written by SessionManager.Export.
+	SessionExport = memory.SessionExport
+	// CompletionFunc sends one system + user prompt to a model and returns
+	// its reply.
+	CompletionFunc = memory.CompletionFunc
+	// RedisMemoryConfig tunes the Redis chat memory and summary store (key
+	// prefix, session TTL and maximum retained turns).
+	RedisMemoryConfig = redisdb.Config
 )
 
 // NewCompactor builds a conversation compactor from a summary store and summarizer.
@@ -137,9 +247,164 @@ func NewMongoSummaryStore(collection *mongo.Collection) SummaryStore {
 	return mongodb.NewMongoSummaryStore(collection)
 }
 
+// NewRecaller builds semantic recall over a vector store and embedder; pass it
+// to SetRecaller.
+func NewRecaller(store VectorStore, embedder Embedder, cfg RecallConfig) *Recaller {
+	return memory.NewRecaller(store, embedder, cfg)
+}
+
+// Recall scopes for RecallConfig.Scope.
+const (
+	RecallScopeSession = memory.RecallScopeSession
+	RecallScopeUser    = memory.RecallScopeUser
+)
+
+// Hybrid search fusion methods and the score sources in MemoryHit.Scores.
+const (
+	FusionRRF      = memory.FusionRRF
+	FusionWeighted = memory.FusionWeighted
+
+	ScoreVector  = memory.ScoreVector
+	ScoreKeyword = memory.ScoreKeyword
+	ScoreFused   = memory.ScoreFused
+	ScoreRerank  = memory.ScoreRerank
+)
+
+// NewUserMemory builds long-term user memory over a fact store and extractor;
+// pass it to SetUserMemory.
+func NewUserMemory(store FactStore, extractor FactExtractor, cfg UserMemoryConfig) *UserMemory {
+	return memory.NewUserMemory(store, extractor, cfg)
+}
+
+// NewInMemoryFactStore returns a process-local fact store (tests/single-node).
+func NewInMemoryFactStore() FactStore { return memory.NewInMemoryFactStore() }
+
+// NewMongoFactStore returns a MongoDB-backed fact store. Create an index on
+// {userId: 1, createdAt: 1} so listing a user's facts stays cheap.
+func NewMongoFactStore(collection *mongo.Collection) FactStore {
+	return mongodb.NewMongoFactStore(collection)
+}
+
+// NewFactExtractor returns a FactExtractor that uses llm (any configured
+// provider) to decide which user facts a turn adds, updates or deletes.
+func NewFactExtractor(llm *LLM) FactExtractor {
+	return memory.NewFactExtractor(llm.completion())
+}
+
+// ErrSessionNotFound is returned for an unknown session ID.
+var ErrSessionNotFound = memory.ErrSessionNotFound
+
+// NewSessionManager keeps session records in store; pass it to
+// SetSessionManager so the agent records every saved turn.
+/*
+Example:
+	sessions := darksuitai.NewSessionManager(darksuitai.NewMongoSessionStore(sessionCollection), darksuitai.SessionConfig{
+		ChatMemory: darksuitai.NewMongoConversationMemory(chatCollection),
+		Summaries:  summaryStore,
+		Titler:     darksuitai.NewSessionTitler(titleLLM),
+	})
+	args.SetSessionManager(sessions)
+
+	list, _ := sessions.List(ctx, "user-42")
+	_ = sessions.Rename(ctx, list[0].ID, "Refund for order 1234")
+	_ = sessions.Export(ctx, list[0].ID, w)
+	_ = sessions.Delete(ctx, list[0].ID) // transcript, summary and recalled turns too
+*/
+func NewSessionManager(store SessionStore, cfg SessionConfig) *SessionManager {
+	return memory.NewSessionManager(store, cfg)
+}
+
+// NewInMemorySessionStore returns a process-local session store (tests/single-node).
+func NewInMemorySessionStore() SessionStore { return memory.NewInMemorySessionStore() }
+
+// NewMongoSessionStore returns a MongoDB-backed session store. Create an
+// index on {userId: 1, updatedAt: -1} so listing a user's sessions stays
+// cheap.
+func NewMongoSessionStore(collection *mongo.Collection) SessionStore {
+	return mongodb.NewMongoSessionStore(collection)
+}
+
+// NewSessionTitler returns a SessionConfig.Titler that asks llm (any
+// configured provider) to title new sessions.
+func NewSessionTitler(llm *LLM) CompletionFunc { return llm.completion() }
+
+// NewMongoConversationMemory returns the MongoDB chat memory the agent uses
+// for a SetMongoDBChatMemory collection, e.g. for SessionConfig.ChatMemory.
+func NewMongoConversationMemory(collection *mongo.Collection) ChatMemory {
+	return mongodb.NewMongoCollection(collection)
+}
+
+// NewRedisChatMemory returns a Redis-backed chat memory that keeps one list per
+// session, expiring idle sessions after cfg.TTL and retaining at most
+// cfg.MaxLength turns. Pass it to SetChatMemory.
+func NewRedisChatMemory(client goredis.UniversalClient, cfg RedisMemoryConfig) ChatMemory {
+	return redisdb.NewRedisChatMemory(client, cfg)
+}
+
+// NewRedisSummaryStore returns a Redis-backed summary store for the Compactor.
+// Use the same cfg as the chat memory so summaries expire with their session.
+func NewRedisSummaryStore(client goredis.UniversalClient, cfg RedisMemoryConfig) SummaryStore {
+	return redisdb.NewRedisSummaryStore(client, cfg)
+}
+
+// Metadata conditions for a MemoryFilter, e.g.
+// MemoryFilter{MetaEq("user_id", id), MetaGte("created_at", since.Unix())}.
+func MetaEq(field string, value any) MemoryCondition { return memory.Eq(field, value) }
+
+// MetaIn matches entries whose field equals any of values.
+func MetaIn(field string, values ...any) MemoryCondition { return memory.In(field, values...) }
+
+// MetaNin matches entries whose field is missing or equals none of values.
+func MetaNin(field string, values ...any) MemoryCondition { return memory.Nin(field, values...) }
+
+// MetaGt matches entries whose field is greater than value.
+func MetaGt(field string, value any) MemoryCondition { return memory.Gt(field, value) }
+
+// MetaGte matches entries whose field is greater than or equal to value.
+func MetaGte(field string, value any) MemoryCondition { return memory.Gte(field, value) }
+
+// MetaLt matches entries whose field is less than value.
+func MetaLt(field string, value any) MemoryCondition { return memory.Lt(field, value) }
+
+// MetaLte matches entries whose field is less than or equal to value.
+func MetaLte(field string, value any) MemoryCondition { return memory.Lte(field, value) }
+
 // NewInMemoryVectorStore returns a cosine-ranked in-memory vector store.
 func NewInMemoryVectorStore() VectorStore { return memory.NewInMemoryVectorStore() }
 
+// NewHNSWVectorStore returns an in-memory vector store indexed with HNSW for
+// approximate nearest-neighbour search over large local corpora.
+func NewHNSWVectorStore(cfg HNSWConfig) VectorStore { return memory.NewHNSWVectorStore(cfg) }
+
+// ErrCorruptSnapshot is returned (wrapped) when a vector store snapshot is
+// truncated, from an unknown version, or fails its checksum.
+var ErrCorruptSnapshot = memory.ErrCorruptSnapshot
+
+// SaveVectorStore writes a snapshot of an in-memory vector store to w.
+/*
+Example:
+	f, _ := os.Create("memory.dsvs")
+	defer f.Close()
+	err := darksuitai.SaveVectorStore(store, f)
+*/
+func SaveVectorStore(store VectorStore, w io.Writer) error {
+	s, ok := store.(VectorSnapshotter)
+	if !ok {
+		return fmt.Errorf("darksuitai: %T does not support snapshots", store)
+	}
+	return s.Save(w)
+}
+
+// LoadVectorStore replaces the contents of an in-memory vector store with a
+// snapshot read from r. The store is unchanged if the snapshot is invalid.
+func LoadVectorStore(store VectorStore, r io.Reader) error {
+	s, ok := store.(VectorSnapshotter)
+	if !ok {
+		return fmt.Errorf("darksuitai: %T does not support snapshots", store)
+	}
+	return s.Load(r)
+}
+
 // NewMongoVectorStore returns a MongoDB Atlas Vector Search-backed vector store.
 func NewMongoVectorStore(collection *mongo.Collection, indexName string) VectorStore {
 	return mongodb.NewMongoVectorStore(collection, indexName)
@@ -150,9 +415,129 @@ func NewAnthropicSummarizer(apiKey, model string) Summarizer {
 	return anthropicllm.NewSummarizer(apiKey, model)
 }
 
-// NewHTTPEmbedder returns an OpenAI-compatible HTTP embedder.
+// NewOpenAISummarizer returns a Summarizer backed by OpenAI Chat Completions.
+func NewOpenAISummarizer(apiKey, model string) Summarizer {
+	return openaillm.NewSummarizer(apiKey, model)
+}
+
+// NewGroqSummarizer returns a Summarizer backed by Groq.
+func NewGroqSummarizer(apiKey, model string) Summarizer {
+	return groqllm.NewSummarizer(apiKey, model)
+}
+
+// NewGeminiSummarizer returns a Summarizer backed by the Google Gen AI SDK.
+// If model is empty it defaults to gemini-2.5-flash.
+func NewGeminiSummarizer(apiKey, model string) Summarizer {
+	return geminillm.NewSummarizer(apiKey, model)
+}
+
+// NewLLMSummarizer returns a Summarizer that uses llm (any configured
+// provider, with its model kwargs) to fold turns into the running summary.
+func NewLLMSummarizer(llm *LLM) Summarizer {
+	return memory.NewSummarizer(llm.completion())
+}
+
+// NewHTTPEmbedder returns an OpenAI-compatible HTTP embedder. It is also a
+// BatchEmbedder, sending 128 texts per request with 4 requests in flight.
 func NewHTTPEmbedder(apiKey, model string) Embedder { return embed.NewHTTPEmbedder(apiKey, model) }
 
+// NewHTTPEmbedderWithBatching is NewHTTPEmbedder with custom batching; zero
+// fields keep the defaults.
+func NewHTTPEmbedderWithBatching(apiKey, model string, cfg EmbedBatchConfig) Embedder {
+	return embed.NewHTTPEmbedder(apiKey, model).WithBatching(cfg)
+}
+
+// NewGeminiEmbedder returns a BatchEmbedder backed by the Google Gen AI SDK.
+// If model is empty it defaults to gemini-embedding-001.
+func NewGeminiEmbedder(apiKey, model string) Embedder {
+	return geminillm.NewEmbedder(apiKey, model)
+}
+
+// NewOllamaEmbedder returns a BatchEmbedder for an Ollama server. Empty host
+// and model default to http://localhost:11434 and "nomic-embed-text".
+func NewOllamaEmbedder(host, model string) Embedder { return embed.NewOllamaEmbedder(host, model) }
+
+// NewCachingEmbedder wraps embedder with an LRU cache of up to size vectors
+// (10000 when size <= 0), keyed by a hash of the text.
+/*
+Example:
+	embedder := darksuitai.NewCachingEmbedder(darksuitai.NewOllamaEmbedder("", ""), 50000)
+	ingester := darksuitai.NewIngester(store, embedder, darksuitai.IngestConfig{})
+*/
+func NewCachingEmbedder(embedder Embedder, size int) *CachingEmbedder {
+	return memory.NewCachingEmbedder(embedder, size)
+}
+
+// EmbedBatch embeds texts in one batched call when embedder is a
+// BatchEmbedder, else one text at a time.
+func EmbedBatch(ctx context.Context, embedder Embedder, texts []string) ([][]float32, error) {
+	return memory.EmbedBatch(ctx, embedder, texts)
+}
+
+// Document formats understood by the ingestion loaders.
+const (
+	FormatText     = ingest.FormatText
+	FormatMarkdown = ingest.FormatMarkdown
+	FormatHTML     = ingest.FormatHTML
+	FormatPDF      = ingest.FormatPDF
+)
+
+// NewIngester returns an ingestion pipeline that embeds document chunks with
+// embedder and upserts them into store under content-hash IDs.
+/*
+Example:
+	ingester := darksuitai.NewIngester(store, embedder, darksuitai.IngestConfig{})
+	res, err := ingester.IngestFiles(ctx, "docs/faq.md", "docs/manual.pdf", "docs/pricing.html")
+*/
+func NewIngester(store VectorStore, embedder Embedder, cfg IngestConfig) *Ingester {
+	return ingest.New(store, embedder, cfg)
+}
+
+// LoadDocument loads a text, Markdown, HTML or PDF file, picking the loader
+// from its extension.
+func LoadDocument(path string) (IngestDocument, error) { return ingest.LoadFile(path) }
+
+// ReadDocument loads a document of the given format (guessed from source when
+// empty) from r, e.g. an HTTP response body.
+func ReadDocument(r io.Reader, source, format string) (IngestDocument, error) {
+	return ingest.Load(r, source, format)
+}
+
+// NewBM25Index returns an in-memory BM25 keyword index.
+func NewBM25Index(cfg BM25Config) KeywordIndex { return memory.NewBM25Index(cfg) }
+
+// NewHybridRetriever searches vectors (embedded with embedder) and keywords
+// together and fuses the rankings. Either store may be nil.
+/*
+Example:
+	retriever := darksuitai.NewHybridRetriever(
+		darksuitai.NewInMemoryVectorStore(),
+		darksuitai.NewHTTPEmbedder(os.Getenv("OPENAI_API_KEY"), ""),
+		darksuitai.NewBM25Index(darksuitai.BM25Config{}),
+		darksuitai.HybridConfig{Reranker: darksuitai.NewHTTPReranker(os.Getenv("COHERE_API_KEY"), "")},
+	)
+	_ = retriever.Add(ctx, "sku-1234", "SKU-1234-B: 20V cordless drill", nil)
+	hits, err := retriever.Search(ctx, "SKU-1234-B battery", 5, nil)
+*/
+func NewHybridRetriever(vectors VectorStore, embedder Embedder, keywords KeywordIndex, cfg HybridConfig) *HybridRetriever {
+	return memory.NewHybridRetriever(vectors, embedder, keywords, cfg)
+}
+
+// NewLLMReranker returns a Reranker that asks llm to score each hit.
+func NewLLMReranker(llm *LLM) Reranker {
+	return memory.NewLLMReranker(llm.completion())
+}
+
+// NewHTTPReranker returns a cross-encoder reranker for Cohere-compatible
+// /rerank endpoints (Cohere, Jina, Voyage, TEI). Empty model defaults to
+// "rerank-v3.5"; use NewHTTPRerankerWithEndpoint for other providers.
+func NewHTTPReranker(apiKey, model string) Reranker { return embed.NewHTTPReranker(apiKey, model) }
+
+// NewHTTPRerankerWithEndpoint is NewHTTPReranker against a custom endpoint.
+func NewHTTPRerankerWithEndpoint(apiKey, model, endpoint string) Reranker {
+	return embed.NewHTTPReranker(apiKey, model).WithEndpoint(endpoint)
+}
+
 // Create an instance of the DarkSuitAgent interface
 var darkSuitAgent internal.DarkSuitAgent = internal.NewDarkSuitAgent()
 
@@ -168,9 +553,9 @@ Example usage:
 				return input, data, nil
 			})
 
-	darksuitAI.ToolNodes = append(darksuitAI.ToolNodes,myTool)
+	agent.Tools().Add(myTool)
 
-	fmt.Printf("all tools created: %v",darksuitAI.ToolNodes) // to see all your tools
+	fmt.Printf("all tools created: %v", agent.Tools().List()) // to see all your tools
 */
 func NewTool(name string, description string, toolFunc func(string, string, map[string]interface{}) (string, []interface{}, error)) tools.BaseTool {
 	return tools.BaseTool{
@@ -180,6 +565,115 @@ func NewTool(name string, description string, toolFunc func(string, string, map[
 	}
 }
 
+// Context-aware tool re-exports.
+type (
+	// ToolContext is what a ToolHandler receives: the run's context, the
+	// session, user and run IDs, tool metadata, a logger and the observer's
+	// run handle.
+	ToolContext = tools.ToolContext
+	// ToolHandler is a context-aware tool implementation.
+	ToolHandler = tools.ToolHandler
+)
+
+/*
+NewContextTool creates a tool from a context-aware handler. The handler gets a
+ToolContext, which is a context.Context cancelled with the agent's run (see
+ChatContext), identifies the session, user and run, carries the agent's tool
+metadata and a logger, and reports progress with Progress. Tools made with
+NewTool keep working; they are called without a context.
+
+Example:
+
+	export := darksuitai.NewContextTool("export_orders", "Exports the user's orders as CSV. Input: the month.",
+		func(tc *darksuitai.ToolContext, month string) (string, []interface{}, error) {
+			tc.Logger.Info("exporting orders", "month", month)
+			url, err := exportOrders(tc, tc.UserID, month, func(done int) {
+				tc.Progress("exporting", map[string]any{"rows": done})
+			})
+			if err != nil {
+				return "", nil, err
+			}
+			return "The export is ready at " + url, nil, nil
+		})
+	agent.Tools().Add(export)
+*/
+func NewContextTool(name string, description string, handler ToolHandler) tools.BaseTool {
+	return tools.NewContextTool(name, description, handler)
+}
+
+/*
+ToolPolicy sets a tool's timeout, retries with backoff, circuit breaker, and
+whether its errors go back to the model instead of failing the run. Attach it
+with the tool's WithPolicy method; each tool needs its own policy, which holds
+the breaker's state.
+
+Example:
+
+	search := darksuitai.NewTool("search", "Searches the web.", searchFunc).WithPolicy(darksuitai.ToolPolicy{
+		Timeout:          10 * time.Second,
+		Retries:          2,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+		ReportErrors:     true,
+	})
+*/
+type ToolPolicy = tools.Policy
+
+// ErrToolCircuitOpen is returned, wrapped, when a tool's circuit breaker is
+// open.
+var ErrToolCircuitOpen = tools.ErrCircuitOpen
+
+// RetryableToolError marks a tool error as worth retrying under a
+// ToolPolicy, e.g. a rate limit or a temporary upstream failure.
+func RetryableToolError(err error) error { return tools.Retryable(err) }
+
+/*
+ToolCacheConfig turns on result caching for a tool: repeated calls with the
+same input (after normalizing JSON key order and whitespace) are answered from
+Store until TTL passes, so repeated searches don't hit a paid API again. Only
+successful results are cached. Attach it with the tool's WithCache method;
+cache hits are flagged in the observer's ToolCall.
+
+Example:
+
+	cache := darksuitai.NewMongoResultCache(client.Database("app").Collection("tool_cache"))
+	search := darksuitai.NewTool("search", "Searches the web.", searchFunc).WithCache(darksuitai.ToolCacheConfig{
+		Store: cache,
+		TTL:   6 * time.Hour,
+	})
+*/
+type ToolCacheConfig = tools.CacheConfig
+
+// Tool result cache re-exports.
+type (
+	// ResultCache stores encoded tool results until they expire.
+	ResultCache = tools.ResultCache
+	// ToolCacheScope decides who shares a tool's cached results.
+	ToolCacheScope = tools.CacheScope
+)
+
+// Tool cache scopes.
+const (
+	// ToolCacheGlobal shares results across sessions and users (the default).
+	ToolCacheGlobal = tools.CacheGlobal
+	// ToolCacheSession shares results within a session.
+	ToolCacheSession = tools.CacheSession
+	// ToolCacheUser shares results across one user's sessions.
+	ToolCacheUser = tools.CacheUser
+)
+
+// NewInMemoryResultCache returns a process-local tool result cache.
+func NewInMemoryResultCache() ResultCache {
+	return tools.NewInMemoryResultCache()
+}
+
+// NewMongoResultCache returns a MongoDB-backed tool result cache. Create a TTL
+// index on {expiresAt: 1} with expireAfterSeconds: 0 so expired results are
+// deleted.
+func NewMongoResultCache(collection *mongo.Collection) ResultCache {
+	return mongodb.NewMongoResultCache(collection)
+}
+
 /*
 NewToolWithSchema creates a tool that declares a structured JSON-schema input,
 for use with native (provider-side) tool calling (ToolProtocol "native").
@@ -216,18 +710,261 @@ func NewToolWithSchema(name string, description string, properties map[string]an
 	}
 }
 
-// ToolNodes is a slice that holds all registered tools, allowing them to be accessed by their indices.
+/*
+NewTypedTool builds a tool from a typed handler: the input schema is reflected
+from the struct In (json tags name the fields, jsonschema tags add
+"required", "description=...", "enum=a|b", "minimum=", "maximum=" and more),
+the model's arguments are validated and decoded into In before the handler
+runs, and Out is sent back as JSON (strings as is). Invalid arguments are
+reported to the model as a tool error so it can correct its call.
+
+Example:
+
+	type WeatherInput struct {
+		City  string `json:"city" jsonschema:"required,description=City name"`
+		Units string `json:"units,omitempty" jsonschema:"enum=celsius|fahrenheit"`
+	}
+
+	weather := darksuitai.NewTypedTool("get_weather", "Current weather for a city.",
+		func(ctx context.Context, in WeatherInput) (string, error) {
+			return fmt.Sprintf("21 degrees %s in %s", in.Units, in.City), nil
+		})
+	agent.Tools().Add(weather)
+*/
+func NewTypedTool[In, Out any](name, description string, handler func(ctx context.Context, in In) (Out, error)) tools.BaseTool {
+	return tools.NewTypedTool(name, description, handler)
+}
+
+/*
+NewRetrieverTool turns a vector store into a tool that searches it. The model
+passes a query, an optional top_k and optional metadata filters, and reads back
+numbered passages labelled with their source for citation. The raw MemoryHit
+values are returned as the tool's []interface{} result, so they appear in the
+agent's tool responses (e.g. to show sources in a UI).
+
+Example:
+
+	kb := darksuitai.NewRetrieverTool(store, embedder, darksuitai.RetrieverToolConfig{
+		Description:  "Searches the product manuals and support FAQ.",
+		Filter:       darksuitai.MemoryFilter{darksuitai.MetaEq("tenant", tenantID)},
+		FilterFields: []string{"product"},
+	})
+	agent.Tools().Add(kb)
+*/
+func NewRetrieverTool(store VectorStore, embedder Embedder, cfg RetrieverToolConfig) tools.BaseTool {
+	return tools.NewRetrieverTool(store, embedder, cfg)
+}
+
+// OpenAPIConfig controls how NewOpenAPITools builds tools from a spec: base
+// URL, an operation allowlist, auth headers and a name prefix.
+type OpenAPIConfig = openapi.Config
+
+/*
+NewOpenAPITools reads an OpenAPI 3 spec (JSON or YAML) and returns one tool
+per operation: named after its operationId, described by its summary, with an
+input schema built from its parameters and request body. Calling a tool sends
+the HTTP request, mapping path, query and header parameters and a JSON "body".
+Use LoadOpenAPIToolsFile or LoadOpenAPIToolsURL to read the spec from a file
+or a URL.
+
+Example:
+
+	billing, err := darksuitai.LoadOpenAPIToolsURL(ctx, "https://billing.internal/openapi.json", darksuitai.OpenAPIConfig{
+		Operations: []string{"getInvoice", "listInvoices"},
+		Headers:    http.Header{"Authorization": {"Bearer " + token}},
+	})
+	agent.Tools().Add(billing...)
+*/
+func NewOpenAPITools(spec []byte, cfg OpenAPIConfig) ([]tools.BaseTool, error) {
+	return openapi.Load(spec, cfg)
+}
+
+// LoadOpenAPIToolsFile is NewOpenAPITools for a spec file.
+func LoadOpenAPIToolsFile(path string, cfg OpenAPIConfig) ([]tools.BaseTool, error) {
+	return openapi.LoadFile(path, cfg)
+}
+
+// LoadOpenAPIToolsURL is NewOpenAPITools for a spec served over HTTP.
+func LoadOpenAPIToolsURL(ctx context.Context, specURL string, cfg OpenAPIConfig) ([]tools.BaseTool, error) {
+	return openapi.LoadURL(ctx, specURL, cfg)
+}
+
+// ToolRegistry holds one agent's tools and tool metadata; see
+// AgentSynapse.Tools.
+type ToolRegistry = tools.Registry
+
+/*
+ToolNodes is a slice that holds all registered tools, allowing them to be accessed by their indices.
+Every agent created afterwards starts with a copy of it.
+
+Deprecated: ToolNodes is shared by every agent in the process. Add tools to
+one agent with agent.Tools().Add instead.
+*/
 var ToolNodes = tools.ToolNodes
 
 /*
 ToolNodesMeta is a variable that holds metadata for all registered tools.
 This is useful when you need to pass extra data to the logic of a tool from other systems
+
+Deprecated: ToolNodesMeta is shared by every agent in the process. Use
+agent.Tools().SetMeta instead.
 */
 var ToolNodesMeta = tools.ToolNodesMeta
 
-// GoogleSearch is a premade tool provided by the framework from the tools package.
+// GoogleSearch is a premade tool provided by the framework from the tools
+// package. It reads its Serper key from the SERPER_API_KEY environment
+// variable.
+//
+// Deprecated: use NewSearchTool, which supports other providers and search
+// options.
 var GoogleSearch = tools.GoogleTool
 
+// Web search re-exports.
+type (
+	// SearchProvider runs web searches (Serper, Brave, Tavily, SearXNG or
+	// your own).
+	SearchProvider = search.Provider
+	// SearchOptions tune a search: result count, region, language and time
+	// range.
+	SearchOptions = search.Options
+	// SearchResult is one search hit.
+	SearchResult = search.Result
+	// SearchTimeRange limits results to pages published recently.
+	SearchTimeRange = search.TimeRange
+)
+
+// Search time ranges.
+const (
+	SearchAnyTime   = search.AnyTime
+	SearchPastDay   = search.PastDay
+	SearchPastWeek  = search.PastWeek
+	SearchPastMonth = search.PastMonth
+	SearchPastYear  = search.PastYear
+)
+
+/*
+NewSearchTool returns a "web_search" tool backed by provider. The model passes
+a query and may set the result count, region and time range; defaults fills in
+the rest.
+
+Example:
+
+	agent.Tools().Add(darksuitai.NewSearchTool(darksuitai.NewBraveSearch(""), darksuitai.SearchOptions{
+		Count:  8,
+		Region: "us",
+	}))
+*/
+func NewSearchTool(provider SearchProvider, defaults SearchOptions) tools.BaseTool {
+	return search.NewTool(provider, defaults)
+}
+
+// NewSerperSearch returns a Google search provider backed by serper.dev. An
+// empty apiKey reads SERPER_API_KEY.
+func NewSerperSearch(apiKey string) SearchProvider { return search.NewSerper(apiKey) }
+
+// NewBraveSearch returns a Brave Search provider. An empty apiKey reads
+// BRAVE_SEARCH_API_KEY.
+func NewBraveSearch(apiKey string) SearchProvider { return search.NewBrave(apiKey) }
+
+// NewTavilySearch returns a Tavily search provider. An empty apiKey reads
+// TAVILY_API_KEY.
+func NewTavilySearch(apiKey string) SearchProvider { return search.NewTavily(apiKey) }
+
+// NewSearXNGSearch returns a provider for the self-hosted SearXNG instance at
+// baseURL, which must have its JSON output format enabled.
+func NewSearXNGSearch(baseURL string) SearchProvider { return search.NewSearXNG(baseURL) }
+
+/*
+FetchConfig controls the web_fetch tool: allowed and denied domains, size
+limit, timeout, robots.txt, and the token budget of each page of content.
+Loopback and private network addresses are refused unless
+AllowPrivateNetworks is set.
+*/
+type FetchConfig = fetch.Config
+
+/*
+NewFetchTool returns a "web_fetch" tool that downloads a URL, extracts the
+page's main readable content as Markdown and hands it to the model one page
+(MaxTokens) at a time. Pair it with NewSearchTool so the agent can read the
+pages it finds.
+
+Example:
+
+	agent.Tools().Add(
+		darksuitai.NewSearchTool(darksuitai.NewSerperSearch(""), darksuitai.SearchOptions{}),
+		darksuitai.NewFetchTool(darksuitai.FetchConfig{
+			DenyDomains: []string{"internal.example.com"},
+			MaxTokens:   3000,
+		}),
+	)
+*/
+func NewFetchTool(cfg FetchConfig) tools.BaseTool {
+	return fetch.NewTool(cfg)
+}
+
+// MCP (Model Context Protocol) re-exports, for mounting MCP servers' tools on
+// agents.
+type (
+	// MCPClient is a connection to one MCP server.
+	MCPClient = mcp.Client
+	// MCPClientConfig names the client and sets a tool-name prefix and a
+	// per-call timeout.
+	MCPClientConfig = mcp.ClientConfig
+	// MCPTransport carries MCP messages: NewMCPStdioTransport or
+	// NewMCPHTTPTransport.
+	MCPTransport = mcp.Transport
+	// MCPServer publishes tools (and agents, via AgentSynapse.AsTool) to MCP
+	// hosts over stdio (ServeStdio) or streamable HTTP (as an http.Handler).
+	MCPServer = mcp.Server
+	// MCPServerConfig names an MCPServer.
+	MCPServerConfig = mcp.ServerConfig
+)
+
+// NewMCPStdioTransport runs an MCP server as a subprocess, speaking MCP over
+// its stdin and stdout.
+func NewMCPStdioTransport(command string, args ...string) *mcp.StdioTransport {
+	return mcp.NewStdioTransport(command, args...)
+}
+
+// NewMCPHTTPTransport connects to an MCP server's streamable HTTP endpoint.
+func NewMCPHTTPTransport(url string) *mcp.HTTPTransport { return mcp.NewHTTPTransport(url) }
+
+/*
+NewMCPServer returns an MCP server publishing tools, e.g. an agent's tools
+and the agent itself wrapped with AsTool.
+
+Over HTTP, anyone who can reach the server can call its tools: bind it to
+localhost, or set MCPServerConfig.Authorize (or put authentication in front
+of it). Browser origins other than localhost are refused unless listed in
+MCPServerConfig.AllowedOrigins.
+
+Example:
+
+	server := darksuitai.NewMCPServer(darksuitai.MCPServerConfig{
+		Name: "support",
+		Authorize: func(r *http.Request) (string, error) {
+			return users.FromToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		},
+	}, agent.Tools().List()...)
+	server.AddTools(agent.AsTool("ask_support", "Answers questions about our products."))
+	// stdio, for IDE assistants that launch the server as a subprocess:
+	err := server.ServeStdio(ctx, os.Stdin, os.Stdout)
+	// or streamable HTTP:
+	http.Handle("/mcp", server)
+	err = http.ListenAndServe("127.0.0.1:8080", nil)
+*/
+func NewMCPServer(cfg MCPServerConfig, toolNodes ...tools.BaseTool) *MCPServer {
+	server := mcp.NewServer(cfg)
+	server.AddTools(toolNodes...)
+	return server
+}
+
+// NewMCPClient returns a client for the MCP server behind transport; its
+// Tools method converts the server's tools into BaseTools.
+func NewMCPClient(transport MCPTransport, cfg MCPClientConfig) *MCPClient {
+	return mcp.NewClient(transport, cfg)
+}
+
 func NewMongoChatMemory(databaseURI, databaseName string) *mongo.Collection {
 	return mongodb.MongoChatMemory(databaseName, databaseURI)
 }
@@ -403,6 +1140,102 @@ func (args *LLMArgs) SetCompactor(compactor *Compactor) {
 	args.Compactor = compactor
 }
 
+/*
+SetChatMemory sets the store used for the agent's conversation transcript. It
+takes precedence over SetMongoDBChatMemory, so any ChatMemory implementation
+(such as the Redis-backed memory) can be used with agents and the Compactor.
+
+Example:
+
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	cfg := darksuitai.RedisMemoryConfig{TTL: 30 * time.Minute, MaxLength: 50}
+	args.SetChatMemory(darksuitai.NewRedisChatMemory(rdb, cfg))
+	args.SetCompactor(darksuitai.NewCompactor(
+		darksuitai.NewRedisSummaryStore(rdb, cfg), summarizer, darksuitai.CompactorConfig{}))
+*/
+func (args *LLMArgs) SetChatMemory(chatMemory ChatMemory) {
+	args.ChatMemory = chatMemory
+}
+
+/*
+SetRecaller enables automatic semantic recall. After each completed turn is
+saved, the agent embeds it into the Recaller's vector store; before answering,
+it retrieves the most relevant older turns (from the session, or from all of the
+user's sessions with RecallScopeUser) and injects them next to the compacted
+chat history. Turns already present in the history are not repeated.
+
+Example:
+
+	args.SetRecaller(darksuitai.NewRecaller(
+		darksuitai.NewInMemoryVectorStore(),
+		darksuitai.NewHTTPEmbedder(os.Getenv("OPENAI_API_KEY"), ""),
+		darksuitai.RecallConfig{TopK: 3, Scope: darksuitai.RecallScopeUser},
+	))
+*/
+func (args *LLMArgs) SetRecaller(recaller *Recaller) {
+	args.Recaller = recaller
+}
+
+/*
+SetUserMemory enables long-term user memory. After each completed turn the
+agent asks the UserMemory's extractor which durable facts about the user (set
+with AgentSynapse.SetUserID) to add, update or delete; the known facts are
+injected into the system prompt as a profile block when the agent is programmed.
+Facts can be listed and deleted with UserMemory.Facts, Forget and ForgetAll.
+
+Example:
+
+	extractorArgs := darksuitai.NewLLMArgs()
+	extractorArgs.SetModelType("anthropic", "claude-haiku-4-5")
+	extractorArgs.AddAPIKey([]byte(apiKey))
+	extractor, _ := extractorArgs.NewLLM()
+	userMemory := darksuitai.NewUserMemory(
+		darksuitai.NewMongoFactStore(factCollection),
+		darksuitai.NewFactExtractor(extractor),
+		darksuitai.UserMemoryConfig{},
+	)
+	args.SetUserMemory(userMemory)
+*/
+func (args *LLMArgs) SetUserMemory(userMemory *UserMemory) {
+	args.UserMemory = userMemory
+}
+
+/*
+SetSessionManager keeps a record of every session the agent talks in: after
+each saved turn the session is created or updated with the user ID (see
+AgentSynapse.SetUserID), a title generated from its first turn, the turn count
+and timestamps. Use the SessionManager to list, rename, tag, export and delete
+conversations.
+
+Example:
+
+	sessions := darksuitai.NewSessionManager(darksuitai.NewInMemorySessionStore(), darksuitai.SessionConfig{
+		ChatMemory: chatMemory,
+		Titler:     darksuitai.NewSessionTitler(llm),
+	})
+	args.SetChatMemory(chatMemory)
+	args.SetSessionManager(sessions)
+*/
+func (args *LLMArgs) SetSessionManager(sessions *SessionManager) {
+	args.Sessions = sessions
+}
+
+/*
+SetToolCallHistory lists each earlier turn's tool calls (name, input and
+truncated output) in the chat history injected into the prompt, so follow-up
+questions can refer to earlier tool results without running the tools again.
+Tool calls are always stored with the turn by chat memories that support it
+(MongoDB and Redis); with a Compactor, set CompactorConfig.IncludeToolCalls
+instead.
+
+Example:
+
+	args.SetToolCallHistory(true)
+*/
+func (args *LLMArgs) SetToolCallHistory(enabled bool) {
+	args.ToolCallHistory = enabled
+}
+
 /*
 	SetMongoDBChatMemory sets the MongoDB collection in LLMArgs.
 
@@ -456,9 +1289,21 @@ type ConvLLM struct {
 }
 
 type AgentSynapse struct {
-	synapse                agent.Synapse
+	synapse agent.Synapse
+	// mu guards programming: the pre-programs, the attached MCP clients,
+	// programmed and toolsVersion.
+	mu                     sync.Mutex
 	_chatAgentPreProgram   _chat.AgentPreProgram
 	_streamAgentPreProgram _stream.AgentPreProgram
+	mcpClients             []*mcp.Client
+	// systemTemplate and instructionTemplate are the prompts as configured,
+	// before Program renders them, so the agent can be programmed again.
+	systemTemplate      []byte
+	instructionTemplate []byte
+	// programmed is set by Program; toolsVersion is the tool registry
+	// version it saw.
+	programmed   bool
+	toolsVersion uint64
 }
 
 // NewLLM creates a new instance of DarkSuitAI LLM
@@ -476,6 +1321,14 @@ func (cargs *LLMArgs) NewLLM() (*LLM, error) {
 	}, nil
 }
 
+// completion adapts the LLM to the memory package's CompletionFunc. The
+// provider clients take no context, so ctx is not propagated.
+func (d *LLM) completion() memory.CompletionFunc {
+	return func(_ context.Context, system, prompt string) (string, error) {
+		return d.ai.Complete(prompt, system)
+	}
+}
+
 // NewConvLLM creates a new instance of DarkSuitAI LLM
 func (cargs *LLMArgs) NewConvLLM() (*ConvLLM, error) {
 
@@ -494,6 +1347,12 @@ func (cargs *LLMArgs) NewConvLLM() (*ConvLLM, error) {
 
 // NewSuitedAgent creates a new instance of DarkSuitAI Agent
 func (cargs *LLMArgs) NewSuitedAgent() (*AgentSynapse, error) {
+	// Agents start with their own copy of the process-wide ToolNodes and
+	// ToolNodesMeta, which predate per-agent registries.
+	registry := tools.NewRegistry(ToolNodes...)
+	for key, value := range ToolNodesMeta {
+		registry.SetMeta(key, value)
+	}
 
 	return &AgentSynapse{
 		synapse: agent.Synapse{
@@ -501,17 +1360,161 @@ func (cargs *LLMArgs) NewSuitedAgent() (*AgentSynapse, error) {
 			ChatInstructionPrompt: cargs.ChatInstruction,
 			PromptKeys:            cargs.PromptKeys,
 			ModelType:             cargs.ModelType,
-			ToolNodes:             ToolNodes,
+			Tools:                 registry,
 			MongoDB:               cargs.MongoDB,
 			ModelKwargs:           cargs.ModelKwargs,
 			APIKey:                cargs.APIKey,
 			ToolProtocol:          cargs.ToolProtocol,
 			Observer:              cargs.Observer,
 			Compactor:             cargs.Compactor,
+			ChatMemory:            cargs.ChatMemory,
+			Recaller:              cargs.Recaller,
+			UserMemory:            cargs.UserMemory,
+			Sessions:              cargs.Sessions,
+			ToolCallHistory:       cargs.ToolCallHistory,
 		},
+		systemTemplate:      cargs.ChatSystemInstruction,
+		instructionTemplate: cargs.ChatInstruction,
 	}, nil
 }
 
+/*
+Tools returns the agent's tool registry. Tools added, removed, enabled or
+disabled there, and metadata set with SetMeta, belong to this agent only; the
+changes reach the model from the agent's next Chat or Stream.
+
+Example:
+
+	agent, err := args.NewSuitedAgent()
+	agent.Tools().Add(weather, kb)
+	agent.Tools().SetMeta("tenant", tenantID)
+	if err := agent.Program(4, "session-1", false); err != nil {
+		return err
+	}
+	// Later, e.g. while the knowledge base is being reindexed:
+	_ = agent.Tools().Disable(kb.Name)
+*/
+func (a *AgentSynapse) Tools() *ToolRegistry {
+	return a.synapse.Tools
+}
+
+/*
+AttachMCPServer connects to an MCP server and gives the agent its tools. Call
+it before Program. The agent is programmed again before the next turn when
+the server announces a change to its tools, and the client reconnects if the
+server restarts or its session expires. While the server is unreachable its
+tools are left out, with a warning logged, and it is tried again next turn.
+
+Example:
+
+	fs := darksuitai.NewMCPStdioTransport("npx", "-y", "@modelcontextprotocol/server-filesystem", "/data")
+	client, err := agent.AttachMCPServer(ctx, fs, darksuitai.MCPClientConfig{ToolPrefix: "fs_"})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+*/
+func (a *AgentSynapse) AttachMCPServer(ctx context.Context, transport MCPTransport, cfg MCPClientConfig) (*MCPClient, error) {
+	client := mcp.NewClient(transport, cfg)
+	if _, err := client.Tools(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+	a.mu.Lock()
+	a.mcpClients = append(a.mcpClients, client)
+	a.mu.Unlock()
+	return client, nil
+}
+
+/*
+AsTool wraps the agent as a tool taking a single "question", so it can be
+published over MCP (see NewMCPServer) or given to another agent. Program the
+agent first. Every caller gets a conversation of its own, separate from the
+agent's: a question is asked in a session derived from the caller's session
+(the MCP session, or the stdio connection), so concurrent hosts neither wait
+on nor read each other's history. A call without a caller session is a
+conversation of its own. The answer is the tool's output.
+*/
+func (a *AgentSynapse) AsTool(name, description string) tools.BaseTool {
+	var tool tools.BaseTool
+	tool = tools.NewContextTool(name, description, func(tc *tools.ToolContext, input string) (string, []interface{}, error) {
+		args, err := tools.InputArguments(tool, input)
+		if err != nil {
+			return "", nil, err
+		}
+		var in struct {
+			Question string `json:"question"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return "", nil, err
+		}
+		sessionId := tc.SessionID
+		if sessionId == "" {
+			sessionId = observability.NewRunID()
+		}
+		session, err := a.forSession("tool:" + sessionId)
+		if err != nil {
+			return "", nil, fmt.Errorf("agent tool %q: %w", tc.ToolName, err)
+		}
+		answer, toolData, err := session.ChatContext(tc, in.Question)
+		if err != nil {
+			return "", nil, err
+		}
+		return answer, []interface{}{toolData}, nil
+	})
+	tool.InputSchema = map[string]any{
+		"question": map[string]any{"type": "string", "description": "The question or task for the agent, with any context it needs."},
+	}
+	tool.Required = []string{"question"}
+	return tool
+}
+
+// forSession returns a copy of the agent programmed as it is but for
+// sessionId, so a conversation can run without touching the agent's own.
+func (a *AgentSynapse) forSession(sessionId string) (*AgentSynapse, error) {
+	a.mu.Lock()
+	if !a.programmed {
+		a.mu.Unlock()
+		return nil, errors.New("program the agent before calling it")
+	}
+	p := a._chatAgentPreProgram
+	session := &AgentSynapse{
+		synapse:             a.synapse,
+		mcpClients:          a.mcpClients,
+		systemTemplate:      a.systemTemplate,
+		instructionTemplate: a.instructionTemplate,
+	}
+	a.mu.Unlock()
+	if err := session.program(p.MaxIteration, sessionId, p.Verbose); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// toolNodes returns the agent's enabled tools followed by those of its
+// attached MCP servers. A server that cannot list its tools is skipped, so one
+// unreachable server does not take the agent down.
+func (a *AgentSynapse) toolNodes(ctx context.Context) []tools.BaseTool {
+	nodes := a.synapse.Tools.Active()
+	for _, client := range a.mcpClients {
+		serverTools, err := client.Tools(ctx)
+		if err != nil {
+			slog.Warn("darksuitai: leaving out the tools of an unreachable MCP server", "server", client.Server().ServerInfo.Name, "error", err)
+			continue
+		}
+		nodes = append(nodes, serverTools...)
+	}
+	return nodes
+}
+
+// SetUserID identifies the end user the agent is talking to. It scopes
+// user-level recall and long-term user facts; call it before Program.
+func (a *AgentSynapse) SetUserID(userId string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.synapse.UserId = userId
+}
+
 func (a *AgentSynapse) Program(maxIteration int, sessionId string, verbose bool) error {
 	if verbose {
 		// Ensure the dark suit callback runs only once
@@ -521,12 +1524,51 @@ func (a *AgentSynapse) Program(maxIteration int, sessionId string, verbose bool)
 			darkSuitCallback()
 		})
 	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.program(maxIteration, sessionId, verbose)
+}
+
+// programs returns the agent's pre-programs for one turn, programming the
+// agent again first if its tools changed (see syncTools).
+func (a *AgentSynapse) programs() (_chat.AgentPreProgram, _stream.AgentPreProgram, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.syncTools(); err != nil {
+		return _chat.AgentPreProgram{}, _stream.AgentPreProgram{}, err
+	}
+	return a._chatAgentPreProgram, a._streamAgentPreProgram, nil
+}
+
+// syncTools programs the agent again when its tool registry or the tools of
+// an attached MCP server changed since Program, so the change reaches the
+// next turn. The caller holds a.mu.
+func (a *AgentSynapse) syncTools() error {
+	if !a.programmed || (a.synapse.Tools.Version() == a.toolsVersion && !a.mcpToolsStale()) {
+		return nil
+	}
+	p := a._chatAgentPreProgram
+	return a.program(p.MaxIteration, p.SessionId, p.Verbose)
+}
+
+// mcpToolsStale reports whether any attached MCP server's tool list needs
+// listing again.
+func (a *AgentSynapse) mcpToolsStale() bool {
+	for _, client := range a.mcpClients {
+		if client.ToolsStale() {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AgentSynapse) program(maxIteration int, sessionId string, verbose bool) error {
 	var promptAgent agent.PromptAgentInterface = agent.NewPromptAgent()
 
 	// Capture native tool-calling configuration before the ReAct/XML template
 	// overwrites the system prompt below. Native mode uses the raw system
 	// instruction (which may be nil) rather than the XML-rendered one.
-	rawSystem := a.synapse.SystemPrompt
+	rawSystem := a.systemTemplate
 	var provider, model string
 	for p, m := range a.synapse.ModelType {
 		provider, model = p, m
@@ -538,47 +1580,71 @@ func (a *AgentSynapse) Program(maxIteration int, sessionId string, verbose bool)
 		maxTokens, temperature = kw.MaxTokens, kw.Temperature
 	}
 
-	basePrompt, sysPrompt, tools, toolNames, err := promptAgent.PreparePrompt(a.synapse.SystemPrompt,
-		a.synapse.ChatInstructionPrompt, a.synapse.ToolNodes, a.synapse.PromptKeys, a.synapse.MongoDB, sessionId, a.synapse.Compactor)
+	toolsVersion := a.synapse.Tools.Version()
+	toolNodes := a.toolNodes(context.Background())
+	toolsMeta := a.synapse.Tools.Meta()
+	chatMemory := a.synapse.Memory()
+	basePrompt, sysPrompt, tools, toolNames, err := promptAgent.PreparePrompt(a.systemTemplate,
+		a.instructionTemplate, toolNodes, a.synapse.PromptKeys, chatMemory, sessionId, a.synapse.Compactor, a.synapse.Recaller, a.synapse.ToolCallHistory)
 	if err != nil {
 		return fmt.Errorf("failed to prepare prompt: %w", err)
 	}
+	// Known facts about the user go at the end of the system prompt as a
+	// profile block. This is best-effort: an unreachable fact store must not
+	// stop the agent from answering.
+	if a.synapse.UserMemory != nil && a.synapse.UserId != "" {
+		if profile, pErr := a.synapse.UserMemory.Profile(context.Background(), a.synapse.UserId); pErr == nil && profile != "" {
+			sysPrompt = append(sysPrompt, "\n\n"+profile...)
+			rawSystem = []byte(strings.TrimSpace(string(rawSystem) + "\n\n" + profile))
+		}
+	}
 	a.synapse.SystemPrompt = sysPrompt
 	a.synapse.ChatInstructionPrompt = basePrompt
 	a._chatAgentPreProgram = _chat.AgentPreProgram{
-		BasePrompt:           basePrompt,
-		SystemPrompt:         sysPrompt,
-		Tools:                tools,
-		ToolNames:            toolNames,
-		AdditionalToolsMeta:  ToolNodesMeta,
-		BaseRunnableCaller:   a.synapse.Basechat,
-		RunnableCaller:       a.synapse.ChatIterable,
-		MaxIteration:         maxIteration,
-		ChatMemoryCollection: a.synapse.MongoDB,
-		Verbose:              verbose,
-		SessionId:            sessionId,
-		ToolProtocol:         a.synapse.ToolProtocol,
-		Provider:             provider,
-		Model:                model,
-		APIKey:               a.synapse.APIKey,
-		MaxTokens:            maxTokens,
-		Temperature:          temperature,
-		RawSystemPrompt:      rawSystem,
-		Observer:             a.synapse.Observer,
+		BasePrompt:          basePrompt,
+		SystemPrompt:        sysPrompt,
+		Tools:               tools,
+		ToolNames:           toolNames,
+		AdditionalToolsMeta: toolsMeta,
+		BaseRunnableCaller:  a.synapse.Basechat,
+		RunnableCaller:      a.synapse.ChatIterable,
+		MaxIteration:        maxIteration,
+		ChatMemory:          chatMemory,
+		Verbose:             verbose,
+		SessionId:           sessionId,
+		ToolProtocol:        a.synapse.ToolProtocol,
+		Provider:            provider,
+		Model:               model,
+		APIKey:              a.synapse.APIKey,
+		MaxTokens:           maxTokens,
+		Temperature:         temperature,
+		RawSystemPrompt:     rawSystem,
+		Observer:            a.synapse.Observer,
+		Recaller:            a.synapse.Recaller,
+		UserId:              a.synapse.UserId,
+		UserMemory:          a.synapse.UserMemory,
+		Compactor:           a.synapse.Compactor,
+		Sessions:            a.synapse.Sessions,
 	}
 	a._streamAgentPreProgram = _stream.AgentPreProgram{
-		BasePrompt:           basePrompt,
-		SystemPrompt:         sysPrompt,
-		Tools:                tools,
-		ToolNames:            toolNames,
-		AdditionalToolsMeta:  ToolNodesMeta,
-		BaseRunnableCaller:   a.synapse.BaseStream,
-		RunnableCaller:       a.synapse.StreamIterable,
-		MaxIteration:         maxIteration,
-		ChatMemoryCollection: a.synapse.MongoDB,
-		Verbose:              verbose,
-		SessionId:            sessionId,
+		BasePrompt:          basePrompt,
+		SystemPrompt:        sysPrompt,
+		Tools:               tools,
+		ToolNames:           toolNames,
+		AdditionalToolsMeta: toolsMeta,
+		BaseRunnableCaller:  a.synapse.BaseStream,
+		RunnableCaller:      a.synapse.StreamIterable,
+		MaxIteration:        maxIteration,
+		ChatMemory:          chatMemory,
+		Verbose:             verbose,
+		SessionId:           sessionId,
+		Recaller:            a.synapse.Recaller,
+		UserId:              a.synapse.UserId,
+		UserMemory:          a.synapse.UserMemory,
+		Compactor:           a.synapse.Compactor,
+		Sessions:            a.synapse.Sessions,
 	}
+	a.programmed, a.toolsVersion = true, toolsVersion
 	return nil
 }
 
@@ -594,6 +1660,16 @@ func (a *AgentSynapse) Program(maxIteration int, sessionId string, verbose bool)
 //   - An interface containing any additional tool data.
 //   - An error if the execution fails.
 func (a *AgentSynapse) Chat(input string) (string, any, error) {
+	return a.ChatContext(context.Background(), input)
+}
+
+// ChatContext is Chat with a context: tools receive it in their ToolContext,
+// so cancelling ctx or reaching its deadline stops context-aware tools.
+func (a *AgentSynapse) ChatContext(ctx context.Context, input string) (string, any, error) {
+	p, _, err := a.programs()
+	if err != nil {
+		return "", nil, err
+	}
 
 	query := map[string][]byte{"question": []byte(input)}
 
@@ -603,14 +1679,11 @@ func (a *AgentSynapse) Chat(input string) (string, any, error) {
 	var (
 		response []byte
 		toolData any
-		err      error
 	)
-	if a._chatAgentPreProgram.ToolProtocol == "native" && a._chatAgentPreProgram.Provider == "anthropic" {
-		response, toolData, err = a._chatAgentPreProgram.NativeExecutor(query, a._chatAgentPreProgram.SessionId,
-			a._chatAgentPreProgram.MaxIteration, a._chatAgentPreProgram.Verbose)
+	if p.ToolProtocol == "native" && p.Provider == "anthropic" {
+		response, toolData, err = p.NativeExecutor(ctx, query, p.SessionId, p.MaxIteration, p.Verbose)
 	} else {
-		response, toolData, err = a._chatAgentPreProgram.Executor(query, a._chatAgentPreProgram.SessionId,
-			a._chatAgentPreProgram.MaxIteration, a._chatAgentPreProgram.Verbose)
+		response, toolData, err = p.Executor(ctx, query, p.SessionId, p.MaxIteration, p.Verbose)
 	}
 	if err != nil {
 		return "", nil, err
@@ -627,6 +1700,16 @@ func NewStreamWriter() *_stream.StreamWriter {
 }
 
 func (a *AgentSynapse) Stream(input string) (<-chan string, error) {
+	return a.StreamContext(context.Background(), input)
+}
+
+// StreamContext is Stream with a context, passed to tools as with
+// ChatContext.
+func (a *AgentSynapse) StreamContext(ctx context.Context, input string) (<-chan string, error) {
+	_, p, err := a.programs()
+	if err != nil {
+		return nil, err
+	}
 	streamWriter := NewStreamWriter()
 	outputChan := make(chan string)
 	var builder strings.Builder
@@ -638,11 +1721,12 @@ func (a *AgentSynapse) Stream(input string) (<-chan string, error) {
 		defer streamWriter.Wg.Done() // Mark this goroutine as done when it exits
 		defer streamWriter.Close()   // This will now safely close only once
 
-		err := a._streamAgentPreProgram.StreamExecutor(
+		err := p.StreamExecutor(
+			ctx,
 			map[string][]byte{"question": []byte(input)},
 			streamWriter,
-			a._streamAgentPreProgram.MaxIteration,
-			a._streamAgentPreProgram.Verbose,
+			p.MaxIteration,
+			p.Verbose,
 		)
 
 		if err != nil {
@@ -678,7 +1762,6 @@ func (a *AgentSynapse) Stream(input string) (<-chan string, error) {
 		}
 	}()
 
-	a._streamAgentPreProgram.SaveChatHistory(input, builder.String(), a._streamAgentPreProgram.SessionId)
 	return outputChan, nil
 }
 
@@ -119,8 +119,8 @@ func demoStream(provider, apiKey, model string) {
 func demoAgentWithTool(provider, apiKey, model, protocol string) {
 	section("AGENT + TOOL (" + strings.ToUpper(protocol) + ")")
 
-	// Register a simple tool. NewTool tools take a single string input and work
-	// in both protocols.
+	// Define a simple tool, registered on the agent below. NewTool tools take a
+	// single string input and work in both protocols.
 	weatherTool := darksuitai.NewTool(
 		"getweather",
 		"Get the current weather for a city. Input: the city name.",
@@ -129,7 +129,6 @@ func demoAgentWithTool(provider, apiKey, model, protocol string) {
 			return fmt.Sprintf("It is 21°C and sunny in %s.", input), []interface{}{raw}, nil
 		},
 	)
-	darksuitai.ToolNodes = append(darksuitai.ToolNodes, weatherTool)
 
 	args := darksuitai.NewLLMArgs()
 	args.AddAPIKey([]byte(apiKey))
@@ -144,6 +143,7 @@ func demoAgentWithTool(provider, apiKey, model, protocol string) {
 		fmt.Println("NewSuitedAgent error:", err)
 		return
 	}
+	agent.Tools().Add(weatherTool)
 	// maxIterations=4, a session id, verbose=true.
 	if err := agent.Program(4, "demo-session", true); err != nil {
 		fmt.Println("Program error:", err)
@@ -0,0 +1,98 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+
+	"google.golang.org/genai"
+)
+
+const defaultEmbeddingModel = "gemini-embedding-001"
+
+// GeminiEmbedder implements memory.BatchEmbedder using the Google Gen AI SDK.
+type GeminiEmbedder struct {
+	apiKey     string
+	model      string
+	taskType   string
+	dimensions int32
+	batch      memory.BatchConfig
+}
+
+// NewEmbedder builds a GeminiEmbedder. If model is empty it defaults to
+// gemini-embedding-001. EmbedBatch sends up to 100 texts per request (the
+// Gemini API limit), 4 requests at a time.
+func NewEmbedder(apiKey, model string) *GeminiEmbedder {
+	if model == "" {
+		model = defaultEmbeddingModel
+	}
+	return &GeminiEmbedder{apiKey: apiKey, model: model, batch: memory.BatchConfig{Size: 100, Concurrency: 4}}
+}
+
+// WithTaskType sets the embedding task type, e.g. "RETRIEVAL_DOCUMENT" for
+// ingested passages and "RETRIEVAL_QUERY" for search queries.
+func (e *GeminiEmbedder) WithTaskType(taskType string) *GeminiEmbedder {
+	e.taskType = taskType
+	return e
+}
+
+// WithDimensions truncates embeddings to n dimensions (e.g. 768 or 1536).
+func (e *GeminiEmbedder) WithDimensions(n int) *GeminiEmbedder {
+	e.dimensions = int32(n)
+	return e
+}
+
+// WithBatching overrides how EmbedBatch splits its input; zero fields keep
+// their current values.
+func (e *GeminiEmbedder) WithBatching(cfg memory.BatchConfig) *GeminiEmbedder {
+	if cfg.Size > 0 {
+		e.batch.Size = cfg.Size
+	}
+	if cfg.Concurrency > 0 {
+		e.batch.Concurrency = cfg.Concurrency
+	}
+	return e
+}
+
+// Embed returns the embedding vector for text.
+func (e *GeminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch returns the embedding vectors for texts, in order.
+func (e *GeminiEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	client, err := newGenaiClient(ctx, e.apiKey)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &genai.EmbedContentConfig{TaskType: e.taskType}
+	if e.dimensions > 0 {
+		cfg.OutputDimensionality = &e.dimensions
+	}
+	return memory.EmbedInBatches(ctx, texts, e.batch, func(ctx context.Context, batch []string) ([][]float32, error) {
+		contents := make([]*genai.Content, len(batch))
+		for i, text := range batch {
+			contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+		}
+		resp, err := client.Models.EmbedContent(ctx, e.model, contents, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("gemini: embed failed: %w", err)
+		}
+		if len(resp.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("gemini: %d embeddings in response for %d texts", len(resp.Embeddings), len(batch))
+		}
+		vectors := make([][]float32, len(batch))
+		for i, emb := range resp.Embeddings {
+			if emb == nil || len(emb.Values) == 0 {
+				return nil, fmt.Errorf("gemini: empty embedding for text %d", i)
+			}
+			vectors[i] = emb.Values
+		}
+		return vectors, nil
+	})
+}
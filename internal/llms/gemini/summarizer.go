@@ -0,0 +1,40 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+
+	"google.golang.org/genai"
+)
+
+// GeminiSummarizer implements memory.Summarizer using the Google Gen AI SDK.
+type GeminiSummarizer struct {
+	apiKey    string
+	model     string
+	maxTokens int32
+}
+
+// NewSummarizer builds a GeminiSummarizer. If model is empty it defaults to
+// gemini-2.5-flash.
+func NewSummarizer(apiKey, model string) *GeminiSummarizer {
+	return &GeminiSummarizer{apiKey: apiKey, model: modelOrDefault(model), maxTokens: 1024}
+}
+
+// Summarize folds the given turns into an updated running summary.
+func (s *GeminiSummarizer) Summarize(ctx context.Context, priorSummary string, turns []memory.Turn) (string, error) {
+	client, err := newGenaiClient(ctx, s.apiKey)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Models.GenerateContent(ctx, s.model, genai.Text(memory.SummarizerPrompt(priorSummary, turns)), &genai.GenerateContentConfig{
+		MaxOutputTokens:   s.maxTokens,
+		SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: memory.SummarizerSystemPrompt}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("gemini: summarize failed: %w", err)
+	}
+	return strings.TrimSpace(resp.Text()), nil
+}
@@ -0,0 +1,15 @@
+package groq
+
+import "github.com/darksuit-ai/darksuitai/internal/llms/openaicompat"
+
+// defaultSummarizerModel is used when NewSummarizer is given no model.
+const defaultSummarizerModel = "openai/gpt-oss-120b"
+
+// NewSummarizer returns a memory.Summarizer backed by Groq's OpenAI-compatible
+// endpoint.
+func NewSummarizer(apiKey, model string) *openaicompat.Summarizer {
+	if model == "" {
+		model = defaultSummarizerModel
+	}
+	return openaicompat.NewSummarizer(config(apiKey), model)
+}
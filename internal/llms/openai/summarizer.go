@@ -0,0 +1,14 @@
+package openai
+
+import "github.com/darksuit-ai/darksuitai/internal/llms/openaicompat"
+
+// defaultSummarizerModel is used when NewSummarizer is given no model.
+const defaultSummarizerModel = "gpt-5.6-terra"
+
+// NewSummarizer returns a memory.Summarizer backed by OpenAI Chat Completions.
+func NewSummarizer(apiKey, model string) *openaicompat.Summarizer {
+	if model == "" {
+		model = defaultSummarizerModel
+	}
+	return openaicompat.NewSummarizer(config(apiKey), model)
+}
@@ -0,0 +1,37 @@
+package openaicompat
+
+import (
+	"context"
+	"strings"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// Summarizer implements memory.Summarizer over any OpenAI-compatible Chat
+// Completions endpoint (OpenAI itself, or Groq via Config.BaseURL).
+type Summarizer struct {
+	cfg       Config
+	model     string
+	maxTokens int
+}
+
+// NewSummarizer builds a Summarizer for the endpoint in cfg.
+func NewSummarizer(cfg Config, model string) *Summarizer {
+	return &Summarizer{cfg: cfg, model: model, maxTokens: 1024}
+}
+
+// Summarize folds the given turns into an updated running summary.
+func (s *Summarizer) Summarize(ctx context.Context, priorSummary string, turns []memory.Turn) (string, error) {
+	out, err := Complete(ctx, s.cfg, Request{
+		Model: s.model,
+		Messages: []Message{
+			{Role: "system", Content: memory.SummarizerSystemPrompt},
+			{Role: "user", Content: memory.SummarizerPrompt(priorSummary, turns)},
+		},
+		MaxTokens: s.maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// KeywordIndex is a lexical (term-matching) index over texts. It complements a
+// VectorStore: embeddings find paraphrases, keywords find exact terms such as
+// product SKUs, error codes and names.
+type KeywordIndex interface {
+	Add(ctx context.Context, id, text string, meta map[string]any) error
+	// Search returns the k best-matching entries for query whose metadata
+	// matches filter (nil matches everything), highest score first.
+	Search(ctx context.Context, query string, k int, filter Filter) ([]Hit, error)
+	// Delete removes entries by ID; unknown IDs are ignored.
+	Delete(ctx context.Context, ids ...string) error
+	// DeleteByFilter removes every entry whose metadata matches filter.
+	DeleteByFilter(ctx context.Context, filter Filter) error
+}
+
+// BM25Config tunes a BM25Index.
+type BM25Config struct {
+	// K1 controls term-frequency saturation. Defaults to 1.2.
+	K1 float64
+	// B controls document-length normalization, from 0 (none) to 1 (full).
+	// Defaults to 0.75; set a negative value for 0.
+	B float64
+	// Tokenizer splits text into terms. Defaults to Tokenize.
+	Tokenizer func(text string) []string
+}
+
+// BM25Index is an in-memory KeywordIndex ranked with Okapi BM25. It is safe
+// for concurrent use.
+type BM25Index struct {
+	mu       sync.RWMutex
+	cfg      BM25Config
+	docs     map[string]*bm25Doc
+	postings map[string]map[string]int // term -> doc ID -> term frequency
+	totalLen int
+}
+
+type bm25Doc struct {
+	text   string
+	meta   map[string]any
+	terms  map[string]int
+	length int
+}
+
+// NewBM25Index returns an empty BM25 index, applying default config values.
+func NewBM25Index(cfg BM25Config) *BM25Index {
+	if cfg.K1 <= 0 {
+		cfg.K1 = 1.2
+	}
+	switch {
+	case cfg.B < 0:
+		cfg.B = 0
+	case cfg.B == 0:
+		cfg.B = 0.75
+	case cfg.B > 1:
+		cfg.B = 1
+	}
+	if cfg.Tokenizer == nil {
+		cfg.Tokenizer = Tokenize
+	}
+	return &BM25Index{
+		cfg:      cfg,
+		docs:     make(map[string]*bm25Doc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Len returns the number of indexed entries.
+func (x *BM25Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Add indexes (or replaces, by id) a text entry.
+func (x *BM25Index) Add(_ context.Context, id, text string, meta map[string]any) error {
+	terms := make(map[string]int)
+	length := 0
+	for _, t := range x.cfg.Tokenizer(text) {
+		terms[t]++
+		length++
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+	x.docs[id] = &bm25Doc{text: text, meta: meta, terms: terms, length: length}
+	x.totalLen += length
+	for t, tf := range terms {
+		p, ok := x.postings[t]
+		if !ok {
+			p = make(map[string]int)
+			x.postings[t] = p
+		}
+		p[id] = tf
+	}
+	return nil
+}
+
+// Search returns the k highest-scoring entries for query matching filter.
+// Entries that share no term with the query are never returned.
+func (x *BM25Index) Search(_ context.Context, query string, k int, filter Filter) ([]Hit, error) {
+	if k <= 0 {
+		k = 5
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(x.docs) == 0 {
+		return nil, nil
+	}
+	n := float64(len(x.docs))
+	avgLen := float64(x.totalLen) / n
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, t := range x.cfg.Tokenizer(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		p := x.postings[t]
+		if len(p) == 0 {
+			continue
+		}
+		df := float64(len(p))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range p {
+			norm := x.cfg.K1 * (1 - x.cfg.B + x.cfg.B*float64(x.docs[id].length)/avgLen)
+			scores[id] += idf * float64(tf) * (x.cfg.K1 + 1) / (float64(tf) + norm)
+		}
+	}
+
+	top := &hitHeap{}
+	for id, score := range scores {
+		doc := x.docs[id]
+		if !filter.Match(doc.meta) {
+			continue
+		}
+		if top.Len() == k && !hitBetter(Hit{ID: id, Score: score}, top.items[0]) {
+			continue
+		}
+		heap.Push(top, Hit{ID: id, Text: doc.text, Score: score, Meta: doc.meta})
+		if top.Len() > k {
+			heap.Pop(top)
+		}
+	}
+	hits := top.items
+	sortHits(hits)
+	return hits, nil
+}
+
+// Delete removes entries by ID.
+func (x *BM25Index) Delete(_ context.Context, ids ...string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, id := range ids {
+		x.remove(id)
+	}
+	return nil
+}
+
+// DeleteByFilter removes every entry matching filter.
+func (x *BM25Index) DeleteByFilter(_ context.Context, filter Filter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for id, doc := range x.docs {
+		if filter.Match(doc.meta) {
+			x.remove(id)
+		}
+	}
+	return nil
+}
+
+// remove drops one entry and its postings. Callers must hold the write lock.
+func (x *BM25Index) remove(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for t := range doc.terms {
+		p := x.postings[t]
+		delete(p, id)
+		if len(p) == 0 {
+			delete(x.postings, t)
+		}
+	}
+	x.totalLen -= doc.length
+	delete(x.docs, id)
+}
+
+// Tokenize is the default BM25 tokenizer. It lowercases text and splits it on
+// anything but letters and digits. Words joined by '-', '_', '.' or '/' (such
+// as "SKU-1234-B" or "v2.1") are kept whole as well as split, so an exact
+// identifier scores higher than its parts appearing separately.
+func Tokenize(text string) []string {
+	var out []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !isTokenJoiner(r)
+	}) {
+		word = strings.TrimFunc(word, isTokenJoiner)
+		if word == "" {
+			continue
+		}
+		parts := strings.FieldsFunc(word, isTokenJoiner)
+		if len(parts) > 1 {
+			out = append(out, word)
+		}
+		out = append(out, parts...)
+	}
+	return out
+}
+
+func isTokenJoiner(r rune) bool {
+	return r == '-' || r == '_' || r == '.' || r == '/'
+}
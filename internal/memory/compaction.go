@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"unicode/utf8"
 )
 
 // SummaryStore persists a session's rolling summary and how many leading turns
@@ -14,6 +15,12 @@ type SummaryStore interface {
 	SetSummary(ctx context.Context, sessionID, summary string, compactedUpTo int) error
 }
 
+// SummaryDeleter is implemented by summary stores that can delete a
+// session's summary.
+type SummaryDeleter interface {
+	DeleteSummary(ctx context.Context, sessionID string) error
+}
+
 // CompactorConfig tunes when and how aggressively context is compacted.
 type CompactorConfig struct {
 	// MaxTurns is the number of not-yet-summarized turns tolerated before a
@@ -22,6 +29,33 @@ type CompactorConfig struct {
 	// KeepRecent is the number of most-recent turns always kept verbatim
 	// (never folded into the summary). Defaults to 6.
 	KeepRecent int
+
+	// MaxContextTokens, when set, switches compaction to a token budget: a
+	// pass runs once the summary plus the un-summarized turns exceed it, and
+	// MaxTurns/KeepRecent are ignored.
+	MaxContextTokens int
+	// RecentTokens is the token budget for the most recent turns kept
+	// verbatim when compacting by tokens; the latest turn is always kept.
+	// Defaults to half of MaxContextTokens.
+	RecentTokens int
+	// Tokenizer counts the tokens in a string. Defaults to EstimateTokens.
+	Tokenizer func(string) int
+	// IncludeToolCalls renders the recent turns' tool calls (see
+	// RenderTurnsWithTools), so follow-up questions can refer to earlier tool
+	// results.
+	IncludeToolCalls bool
+
+	// Async takes summarization off the request path: BuildContext only
+	// renders the stored summary and pending turns, and the agent calls
+	// CompactInBackground after each response instead.
+	Async bool
+}
+
+// EstimateTokens approximates the token count of s at four characters per
+// token, which is close enough for budgeting across providers without
+// shipping a tokenizer.
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
 }
 
 // Compactor turns a long conversation into a compact context window: a rolling,
@@ -33,6 +67,11 @@ type Compactor struct {
 	store      SummaryStore
 	summarizer Summarizer
 	cfg        CompactorConfig
+
+	// sessions ensures only one compaction pass per session runs at a time,
+	// so concurrent requests never summarize the same turns twice.
+	sessions   keyedMutex
+	background sync.WaitGroup
 }
 
 // NewCompactor builds a Compactor, applying default config values.
@@ -46,47 +85,176 @@ func NewCompactor(store SummaryStore, summarizer Summarizer, cfg CompactorConfig
 	if cfg.KeepRecent > cfg.MaxTurns {
 		cfg.KeepRecent = cfg.MaxTurns
 	}
+	if cfg.MaxContextTokens > 0 && (cfg.RecentTokens <= 0 || cfg.RecentTokens > cfg.MaxContextTokens) {
+		cfg.RecentTokens = cfg.MaxContextTokens / 2
+	}
+	if cfg.Tokenizer == nil {
+		cfg.Tokenizer = EstimateTokens
+	}
 	return &Compactor{store: store, summarizer: summarizer, cfg: cfg}
 }
 
+// Async reports whether the compactor runs in asynchronous mode.
+func (c *Compactor) Async() bool { return c.cfg.Async }
+
 // BuildContext returns the compacted context string to inject as chat history.
 // allTurns must be the full, chronological (oldest-first) turn list. When the
-// backlog of un-summarized turns exceeds MaxTurns, the older ones are folded
-// into the rolling summary via the Summarizer and persisted to the SummaryStore.
+// backlog of un-summarized turns exceeds MaxTurns (or MaxContextTokens), the
+// older ones are folded into the rolling summary via the Summarizer and
+// persisted to the SummaryStore. In Async mode no summarization happens here.
 func (c *Compactor) BuildContext(ctx context.Context, sessionID string, allTurns []Turn) (string, error) {
-	summary, upTo, err := c.store.GetSummary(ctx, sessionID)
+	return c.BuildContextWindow(ctx, sessionID, allTurns, 0)
+}
+
+// BuildContextWindow is BuildContext for chat memories that only retain the
+// most recent turns (see WindowedChatMemory). turns is the retained,
+// oldest-first window and offset is the absolute index of turns[0]; the
+// summary coverage persisted to the SummaryStore is always absolute. Turns that
+// were trimmed before they could be summarized are simply skipped.
+func (c *Compactor) BuildContextWindow(ctx context.Context, sessionID string, turns []Turn, offset int) (string, error) {
+	if c.cfg.Async {
+		summary, _, pending, err := c.pending(ctx, sessionID, turns, offset)
+		if err != nil {
+			return "", err
+		}
+		return renderContext(summary, pending, c.cfg.IncludeToolCalls), nil
+	}
+
+	unlock := c.sessions.Lock(sessionID)
+	defer unlock()
+	summary, pending, err := c.compact(ctx, sessionID, turns, offset)
 	if err != nil {
 		return "", err
 	}
-	if upTo < 0 {
-		upTo = 0
+	return renderContext(summary, pending, c.cfg.IncludeToolCalls), nil
+}
+
+// Compact runs a compaction pass over the session's retained turns (see
+// BuildContextWindow for turns and offset) if the budget is exceeded. When a
+// pass for the session is already running it returns immediately, since that
+// pass will cover the same turns.
+func (c *Compactor) Compact(ctx context.Context, sessionID string, turns []Turn, offset int) error {
+	unlock, ok := c.sessions.TryLock(sessionID)
+	if !ok {
+		return nil
+	}
+	defer unlock()
+	_, _, err := c.compact(ctx, sessionID, turns, offset)
+	return err
+}
+
+// CompactInBackground loads the session's turns from chatMemory and runs
+// Compact in a new goroutine, so summarization never adds to a request's
+// latency. It is best-effort: a failed pass is retried after the next turn.
+// Use Wait to drain pending passes before shutdown.
+func (c *Compactor) CompactInBackground(sessionID string, chatMemory ChatMemory) {
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		ctx := context.Background()
+		if windowed, ok := chatMemory.(WindowedChatMemory); ok {
+			if turns, offset, err := windowed.RetrieveWindow(sessionID); err == nil {
+				_ = c.Compact(ctx, sessionID, turns, offset)
+			}
+			return
+		}
+		if turns, err := chatMemory.RetrieveTurns(sessionID); err == nil {
+			_ = c.Compact(ctx, sessionID, turns, 0)
+		}
+	}()
+}
+
+// Wait blocks until every compaction started by CompactInBackground finishes.
+func (c *Compactor) Wait() { c.background.Wait() }
+
+// pending returns the stored summary, its absolute coverage and the retained
+// turns it does not cover yet.
+func (c *Compactor) pending(ctx context.Context, sessionID string, turns []Turn, offset int) (string, int, []Turn, error) {
+	summary, upTo, err := c.store.GetSummary(ctx, sessionID)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if offset < 0 {
+		offset = 0
 	}
-	if upTo > len(allTurns) {
-		upTo = len(allTurns)
+	if upTo < offset {
+		upTo = offset
 	}
+	if upTo > offset+len(turns) {
+		upTo = offset + len(turns)
+	}
+
+	return summary, upTo, turns[upTo-offset:], nil
+}
 
-	pending := allTurns[upTo:]
+// compact folds the oldest pending turns into the summary when the backlog is
+// over budget, and returns the resulting summary and still-pending turns.
+// Callers must hold the session lock.
+func (c *Compactor) compact(ctx context.Context, sessionID string, turns []Turn, offset int) (string, []Turn, error) {
+	summary, upTo, pending, err := c.pending(ctx, sessionID, turns, offset)
+	if err != nil {
+		return "", nil, err
+	}
+	n := c.foldCount(summary, pending)
+	if n == 0 {
+		return summary, pending, nil
+	}
+	summary, err = c.summarizer.Summarize(ctx, summary, pending[:n])
+	if err != nil {
+		return "", nil, err
+	}
+	if err := c.store.SetSummary(ctx, sessionID, summary, upTo+n); err != nil {
+		return "", nil, err
+	}
+	return summary, pending[n:], nil
+}
 
-	// Compact when the un-summarized backlog is too large.
-	if len(pending) > c.cfg.MaxTurns {
-		toFold := pending[:len(pending)-c.cfg.KeepRecent]
-		newSummary, sErr := c.summarizer.Summarize(ctx, summary, toFold)
-		if sErr != nil {
-			return "", sErr
+// foldCount returns how many of the oldest pending turns to fold into the
+// summary: none while within budget, otherwise all but the recent window.
+func (c *Compactor) foldCount(summary string, pending []Turn) int {
+	if c.cfg.MaxContextTokens <= 0 {
+		if len(pending) > c.cfg.MaxTurns {
+			return len(pending) - c.cfg.KeepRecent
 		}
-		summary = newSummary
-		if err := c.store.SetSummary(ctx, sessionID, summary, upTo+len(toFold)); err != nil {
-			return "", err
+		return 0
+	}
+
+	sizes := make([]int, len(pending))
+	total := c.cfg.Tokenizer(summary)
+	for i, t := range pending {
+		sizes[i] = c.cfg.Tokenizer(renderContext("", []Turn{t}, c.cfg.IncludeToolCalls))
+		total += sizes[i]
+	}
+	if total <= c.cfg.MaxContextTokens {
+		return 0
+	}
+	keep, recent := 0, 0
+	for i := len(pending) - 1; i >= 0; i-- {
+		if keep > 0 && recent+sizes[i] > c.cfg.RecentTokens {
+			break
 		}
-		pending = pending[len(pending)-c.cfg.KeepRecent:]
+		recent += sizes[i]
+		keep++
 	}
+	return len(pending) - keep
+}
+
+// RenderTurns formats turns as a "Human: ... / AI: ..." transcript, oldest
+// first. An empty transcript renders as the "[]" sentinel used by the prompts.
+func RenderTurns(turns []Turn) string {
+	return renderContext("", turns, false)
+}
 
-	return renderContext(summary, pending), nil
+// RenderTurnsWithTools is RenderTurns with each turn's tool calls listed
+// between the question and the answer, one "Tool call: name(input) -> output"
+// line per call.
+func RenderTurnsWithTools(turns []Turn) string {
+	return renderContext("", turns, true)
 }
 
 // renderContext formats the rolling summary and recent turns into a single
 // chat-history string.
-func renderContext(summary string, recent []Turn) string {
+func renderContext(summary string, recent []Turn, withTools bool) string {
 	var b strings.Builder
 	if strings.TrimSpace(summary) != "" {
 		b.WriteString("Summary of earlier conversation:\n")
@@ -101,6 +269,12 @@ func renderContext(summary string, recent []Turn) string {
 			if t.Human != "" {
 				fmt.Fprintf(&b, "Human: %s\n", t.Human)
 			}
+			if withTools {
+				for _, call := range t.ToolCalls {
+					b.WriteString(call.String())
+					b.WriteByte('\n')
+				}
+			}
 			if t.AI != "" {
 				fmt.Fprintf(&b, "AI: %s\n", t.AI)
 			}
@@ -150,3 +324,11 @@ func (s *InMemorySummaryStore) SetSummary(_ context.Context, sessionID, summary
 	s.data[sessionID] = memSummary{summary: summary, upTo: compactedUpTo}
 	return nil
 }
+
+// DeleteSummary removes a session's summary.
+func (s *InMemorySummaryStore) DeleteSummary(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, sessionID)
+	return nil
+}
@@ -2,9 +2,12 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // fakeSummarizer records how many turns it was asked to fold and returns a
@@ -111,3 +114,203 @@ func TestCompactor_EmptyIsSentinel(t *testing.T) {
 		t.Errorf("expected sentinel [] for empty history, got %q", out)
 	}
 }
+
+func TestCompactor_WindowKeepsAbsoluteCoverage(t *testing.T) {
+	ctx := context.Background()
+	sum := &fakeSummarizer{}
+	store := NewInMemorySummaryStore()
+	c := NewCompactor(store, sum, CompactorConfig{MaxTurns: 10, KeepRecent: 4})
+
+	// The memory has trimmed the first 20 turns; 15 are retained (20..34).
+	all := turns(35)
+	if _, err := c.BuildContextWindow(ctx, "s1", all[20:], 20); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum.folded != 11 {
+		t.Errorf("expected 11 turns folded, got %d", sum.folded)
+	}
+	_, upTo, _ := store.GetSummary(ctx, "s1")
+	if upTo != 31 {
+		t.Errorf("expected absolute compactedUpTo=31, got %d", upTo)
+	}
+
+	// Two more turns arrive and the window slides by two; the 4 kept turns
+	// plus 2 new ones stay under MaxTurns, so nothing is re-summarized.
+	all = turns(37)
+	out, err := c.BuildContextWindow(ctx, "s1", all[22:], 22)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum.calls != 1 {
+		t.Errorf("expected no further summarization, got %d calls", sum.calls)
+	}
+	if !strings.Contains(out, "Human: q31") || strings.Contains(out, "Human: q30") {
+		t.Errorf("expected turns from q31 onwards, got:\n%s", out)
+	}
+}
+
+func TestCompactor_TokenBudget(t *testing.T) {
+	ctx := context.Background()
+	sum := &fakeSummarizer{}
+	store := NewInMemorySummaryStore()
+	// Turns q0..q9 estimate at 4 tokens each, q10 onwards at 5.
+	c := NewCompactor(store, sum, CompactorConfig{MaxContextTokens: 40, RecentTokens: 15})
+
+	if _, err := c.BuildContext(ctx, "s1", turns(10)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum.calls != 0 {
+		t.Fatalf("40 tokens fit the budget, got %d summarizations", sum.calls)
+	}
+
+	out, err := c.BuildContext(ctx, "s1", turns(15))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum.folded != 12 {
+		t.Errorf("expected 12 turns folded (3 fit RecentTokens), got %d", sum.folded)
+	}
+	if !strings.Contains(out, "Human: q12") || strings.Contains(out, "Human: q11") {
+		t.Errorf("expected q12..q14 verbatim, got:\n%s", out)
+	}
+}
+
+func TestCompactor_TokenBudgetKeepsLatestTurn(t *testing.T) {
+	sum := &fakeSummarizer{}
+	c := NewCompactor(NewInMemorySummaryStore(), sum, CompactorConfig{
+		MaxContextTokens: 10,
+		Tokenizer:        func(s string) int { return len(s) },
+	})
+	out, err := c.BuildContext(context.Background(), "s1", turns(3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum.folded != 2 || !strings.Contains(out, "Human: q2") {
+		t.Errorf("an oversized latest turn must still be kept: folded=%d\n%s", sum.folded, out)
+	}
+}
+
+// sliceMemory is a ChatMemory over a fixed transcript.
+type sliceMemory struct{ turns []Turn }
+
+func (m *sliceMemory) AddConversationToMemory(_, prompt, aiMessage string) error {
+	m.turns = append(m.turns, Turn{Human: prompt, AI: aiMessage})
+	return nil
+}
+func (m *sliceMemory) RetrieveMemoryWithK(string, int64) (string, error) { return "", nil }
+func (m *sliceMemory) RetrieveTurns(string) ([]Turn, error)              { return m.turns, nil }
+
+func TestCompactor_AsyncCompactsInBackground(t *testing.T) {
+	ctx := context.Background()
+	sum := &fakeSummarizer{}
+	store := NewInMemorySummaryStore()
+	c := NewCompactor(store, sum, CompactorConfig{MaxTurns: 10, KeepRecent: 4, Async: true})
+
+	out, err := c.BuildContext(ctx, "s1", turns(15))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sum.calls != 0 || !strings.Contains(out, "Human: q0") {
+		t.Fatalf("async BuildContext must not summarize: calls=%d\n%s", sum.calls, out)
+	}
+
+	c.CompactInBackground("s1", &sliceMemory{turns: turns(15)})
+	c.Wait()
+	if _, upTo, _ := store.GetSummary(ctx, "s1"); upTo != 11 || sum.calls != 1 {
+		t.Fatalf("expected one background pass covering 11 turns, got calls=%d upTo=%d", sum.calls, upTo)
+	}
+	out, _ = c.BuildContext(ctx, "s1", turns(15))
+	if !strings.Contains(out, "Summary of earlier conversation:") || strings.Contains(out, "Human: q0") {
+		t.Errorf("expected the background summary to be used, got:\n%s", out)
+	}
+}
+
+// blockingSummarizer blocks until released so tests can overlap passes.
+type blockingSummarizer struct {
+	entered chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (b *blockingSummarizer) Summarize(_ context.Context, _ string, turns []Turn) (string, error) {
+	b.calls.Add(1)
+	b.entered <- struct{}{}
+	<-b.release
+	return fmt.Sprintf("summary of %d", len(turns)), nil
+}
+
+func TestCompactor_OnePassPerSession(t *testing.T) {
+	ctx := context.Background()
+	sum := &blockingSummarizer{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	c := NewCompactor(NewInMemorySummaryStore(), sum, CompactorConfig{MaxTurns: 10, KeepRecent: 4, Async: true})
+
+	done := make(chan error)
+	go func() { done <- c.Compact(ctx, "s1", turns(15), 0) }()
+	<-sum.entered
+
+	// A concurrent pass for the same session is skipped, not queued.
+	if err := c.Compact(ctx, "s1", turns(15), 0); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	close(sum.release)
+	if err := <-done; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := sum.calls.Load(); n != 1 {
+		t.Errorf("expected a single summarization, got %d", n)
+	}
+}
+
+// turnMemory is a sliceMemory that also stores whole turns.
+type turnMemory struct{ sliceMemory }
+
+func (m *turnMemory) AddTurnToMemory(_ string, turn Turn) error {
+	m.turns = append(m.turns, turn)
+	return nil
+}
+
+func TestToolCalls_StoredAndRendered(t *testing.T) {
+	long := strings.Repeat("x", MaxToolOutput+50)
+	turn := Turn{Human: "weather in Paris?", AI: "Sunny, 21°C.", ToolCalls: []ToolCall{
+		NewToolCall("weather", `{"city": "Paris"}`, "sunny\n21C", 120*time.Millisecond, nil),
+		NewToolCall("forecast", "Paris", long, time.Second, errors.New("quota exceeded")),
+	}}
+	if got := turn.ToolCalls[1]; got.Error != "quota exceeded" || len([]rune(got.Output)) != MaxToolOutput+1 {
+		t.Errorf("NewToolCall: error %q, output length %d", got.Error, len([]rune(got.Output)))
+	}
+
+	plain, full := &sliceMemory{}, &turnMemory{}
+	_ = SaveTurn(plain, "s", turn)
+	_ = SaveTurn(full, "s", turn)
+	if plain.turns[0].ToolCalls != nil || len(full.turns[0].ToolCalls) != 2 {
+		t.Errorf("SaveTurn: plain %+v, full %+v", plain.turns[0], full.turns[0])
+	}
+
+	want := "Human: weather in Paris?\n" +
+		`Tool call: weather({"city": "Paris"}) -> sunny 21C` + "\n" +
+		"Tool call: forecast(Paris) -> error: quota exceeded\n" +
+		"AI: Sunny, 21°C."
+	if got := RenderTurnsWithTools([]Turn{turn}); got != want {
+		t.Errorf("RenderTurnsWithTools:\n got %q\nwant %q", got, want)
+	}
+	if got := RenderTurns([]Turn{turn}); strings.Contains(got, "Tool call") {
+		t.Errorf("RenderTurns should omit tool calls: %q", got)
+	}
+
+	c := NewCompactor(NewInMemorySummaryStore(), &fakeSummarizer{}, CompactorConfig{IncludeToolCalls: true})
+	out, err := c.BuildContext(context.Background(), "s", full.turns)
+	if err != nil || !strings.Contains(out, "Tool call: weather") {
+		t.Errorf("compactor with IncludeToolCalls: %v\n%s", err, out)
+	}
+}
+
+func TestRecentTurns_FallsBackToFullTranscript(t *testing.T) {
+	m := &sliceMemory{turns: turns(5)}
+	got, err := RecentTurns(m, "s1", 2)
+	if err != nil || len(got) != 2 || got[0].Human != "q3" || got[1].Human != "q4" {
+		t.Errorf("want the last two turns, got %+v, %v", got, err)
+	}
+	if got, _ := RecentTurns(m, "s1", 10); len(got) != 5 {
+		t.Errorf("k beyond the transcript should return every turn, got %d", len(got))
+	}
+}
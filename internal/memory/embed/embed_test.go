@@ -0,0 +1,218 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// numbered returns n texts "t0", "t1", ...; the stub servers embed "tN" as
+// [N] so tests can check which vector came back for which text.
+func numbered(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = "t" + strconv.Itoa(i)
+	}
+	return texts
+}
+
+func vectorFor(text string) []float32 {
+	n, _ := strconv.Atoi(strings.TrimPrefix(text, "t"))
+	return []float32{float32(n)}
+}
+
+func checkNumbered(t *testing.T, vectors [][]float32, n int) {
+	t.Helper()
+	if len(vectors) != n {
+		t.Fatalf("want %d vectors, got %d", n, len(vectors))
+	}
+	for i, v := range vectors {
+		if len(v) != 1 || v[0] != float32(i) {
+			t.Errorf("vector %d = %v, want [%d]", i, v, i)
+		}
+	}
+}
+
+// openAIStub answers /embeddings requests, listing the data entries in
+// reverse so clients must place them by index. It records batch sizes and
+// the peak number of requests in flight.
+type openAIStub struct {
+	mu       sync.Mutex
+	batches  []int
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	delay    time.Duration
+}
+
+func (s *openAIStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		p := s.peak.Load()
+		if n <= p || s.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+
+	if r.Header.Get("Authorization") != "Bearer sk-test" {
+		http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Model string          `json:"model"`
+		Input json.RawMessage `json:"input"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	var texts []string
+	if json.Unmarshal(req.Input, &texts) != nil {
+		var text string
+		_ = json.Unmarshal(req.Input, &text)
+		texts = []string{text}
+	}
+	s.mu.Lock()
+	s.batches = append(s.batches, len(texts))
+	s.mu.Unlock()
+
+	type item struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	}
+	data := make([]item, 0, len(texts))
+	for i := len(texts) - 1; i >= 0; i-- {
+		data = append(data, item{Index: i, Embedding: vectorFor(texts[i])})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"model": req.Model, "data": data})
+}
+
+func TestHTTPEmbedder_EmbedBatchSplitsAndRemaps(t *testing.T) {
+	stub := &openAIStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	e := NewHTTPEmbedder("sk-test", "").WithEndpoint(srv.URL).WithBatching(memory.BatchConfig{Size: 3, Concurrency: 1})
+	vectors, err := e.EmbedBatch(context.Background(), numbered(7))
+	if err != nil {
+		t.Fatalf("embed batch: %v", err)
+	}
+	checkNumbered(t, vectors, 7)
+	if fmt.Sprint(stub.batches) != "[3 3 1]" {
+		t.Errorf("want batches of 3, 3 and 1, got %v", stub.batches)
+	}
+
+	v, err := e.Embed(context.Background(), "t42")
+	if err != nil || len(v) != 1 || v[0] != 42 {
+		t.Errorf("single embed: %v, %v", v, err)
+	}
+}
+
+func TestHTTPEmbedder_LimitsConcurrency(t *testing.T) {
+	stub := &openAIStub{delay: 20 * time.Millisecond}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	e := NewHTTPEmbedder("sk-test", "").WithEndpoint(srv.URL).WithBatching(memory.BatchConfig{Size: 1, Concurrency: 2})
+	vectors, err := e.EmbedBatch(context.Background(), numbered(8))
+	if err != nil {
+		t.Fatalf("embed batch: %v", err)
+	}
+	checkNumbered(t, vectors, 8)
+	if peak := stub.peak.Load(); peak != 2 {
+		t.Errorf("want 2 requests in flight at most (and reached), got %d", peak)
+	}
+}
+
+func TestHTTPEmbedder_Errors(t *testing.T) {
+	stub := &openAIStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	if _, err := NewHTTPEmbedder("wrong", "").WithEndpoint(srv.URL).Embed(context.Background(), "t1"); err == nil || !strings.Contains(err.Error(), "bad key") {
+		t.Errorf("want the API error message, got %v", err)
+	}
+
+	short := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[1]}]}`))
+	}))
+	defer short.Close()
+	_, err := NewHTTPEmbedder("sk-test", "").WithEndpoint(short.URL).EmbedBatch(context.Background(), numbered(2))
+	if err == nil || !strings.Contains(err.Error(), "1 embeddings in response for 2 texts") {
+		t.Errorf("want a missing-embedding error, got %v", err)
+	}
+}
+
+func TestOllamaEmbedder_RequestAndResponseShape(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches []int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "nomic-embed-text" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "bad request"})
+			return
+		}
+		mu.Lock()
+		batches = append(batches, len(req.Input))
+		mu.Unlock()
+		embeddings := make([][]float32, len(req.Input))
+		for i, text := range req.Input {
+			embeddings[i] = vectorFor(text)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"model": req.Model, "embeddings": embeddings})
+	}))
+	defer srv.Close()
+
+	e := NewOllamaEmbedder(srv.URL+"/", "").WithBatching(memory.BatchConfig{Size: 2})
+	vectors, err := e.EmbedBatch(context.Background(), numbered(5))
+	if err != nil {
+		t.Fatalf("embed batch: %v", err)
+	}
+	checkNumbered(t, vectors, 5)
+	if fmt.Sprint(batches) != "[2 2 1]" {
+		t.Errorf("want batches of 2, 2 and 1, got %v", batches)
+	}
+	if v, err := e.Embed(context.Background(), "t9"); err != nil || v[0] != 9 {
+		t.Errorf("single embed: %v, %v", v, err)
+	}
+}
+
+func TestOllamaEmbedder_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Input) > 1 {
+			_, _ = w.Write([]byte(`{"embeddings":[[1]]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+	}))
+	defer srv.Close()
+
+	e := NewOllamaEmbedder(srv.URL, "missing")
+	if _, err := e.Embed(context.Background(), "t1"); err == nil || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("want the server error, got %v", err)
+	}
+	if _, err := e.EmbedBatch(context.Background(), numbered(3)); err == nil || !strings.Contains(err.Error(), "1 embeddings in response for 3 texts") {
+		t.Errorf("want a count mismatch error, got %v", err)
+	}
+}
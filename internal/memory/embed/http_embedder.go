@@ -1,4 +1,5 @@
-// Package embed provides a dependency-free text embedder for semantic memory.
+// Package embed provides dependency-free HTTP clients for semantic memory:
+// text embedders (OpenAI-compatible and Ollama) and a cross-encoder reranker.
 package embed
 
 import (
@@ -8,21 +9,26 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
 )
 
 // HTTPEmbedder calls an OpenAI-compatible /embeddings endpoint over HTTP and
 // implements memory.Embedder. It has no third-party dependencies, so it works
 // with OpenAI, Azure OpenAI, Voyage (OpenAI-compatible), or any local server
-// that speaks the same request/response shape.
+// that speaks the same request/response shape. EmbedBatch sends many texts
+// per request, so it also implements memory.BatchEmbedder.
 type HTTPEmbedder struct {
 	apiKey   string
 	model    string
 	endpoint string
 	client   *http.Client
+	batch    memory.BatchConfig
 }
 
 // NewHTTPEmbedder builds an embedder. Defaults: model "text-embedding-3-small",
-// endpoint https://api.openai.com/v1/embeddings, 30s HTTP timeout.
+// endpoint https://api.openai.com/v1/embeddings, 30s HTTP timeout, and
+// batches of 128 texts with 4 requests in flight.
 func NewHTTPEmbedder(apiKey, model string) *HTTPEmbedder {
 	if model == "" {
 		model = "text-embedding-3-small"
@@ -32,6 +38,7 @@ func NewHTTPEmbedder(apiKey, model string) *HTTPEmbedder {
 		model:    model,
 		endpoint: "https://api.openai.com/v1/embeddings",
 		client:   &http.Client{Timeout: 30 * time.Second},
+		batch:    memory.BatchConfig{Size: 128, Concurrency: 4},
 	}
 }
 
@@ -43,13 +50,26 @@ func (e *HTTPEmbedder) WithEndpoint(endpoint string) *HTTPEmbedder {
 	return e
 }
 
+// WithBatching overrides how EmbedBatch splits its input; zero fields keep
+// their current values.
+func (e *HTTPEmbedder) WithBatching(cfg memory.BatchConfig) *HTTPEmbedder {
+	if cfg.Size > 0 {
+		e.batch.Size = cfg.Size
+	}
+	if cfg.Concurrency > 0 {
+		e.batch.Concurrency = cfg.Concurrency
+	}
+	return e
+}
+
 type embedRequest struct {
 	Model string `json:"model"`
-	Input string `json:"input"`
+	Input any    `json:"input"` // a string, or []string for a batch
 }
 
 type embedResponse struct {
 	Data []struct {
+		Index     int       `json:"index"`
 		Embedding []float32 `json:"embedding"`
 	} `json:"data"`
 	Error *struct {
@@ -59,32 +79,63 @@ type embedResponse struct {
 
 // Embed returns the embedding vector for text.
 func (e *HTTPEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
-	payload, err := json.Marshal(embedRequest{Model: e.model, Input: text})
+	decoded, status, err := e.post(ctx, text)
 	if err != nil {
 		return nil, err
 	}
+	if len(decoded.Data) == 0 || len(decoded.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embed: empty embedding in response (status %d)", status)
+	}
+	return decoded.Data[0].Embedding, nil
+}
+
+// EmbedBatch returns the embedding vectors for texts, in order, sending them
+// in batches with a bounded number of requests in flight.
+func (e *HTTPEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return memory.EmbedInBatches(ctx, texts, e.batch, func(ctx context.Context, batch []string) ([][]float32, error) {
+		decoded, status, err := e.post(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors := make([][]float32, len(batch))
+		for _, d := range decoded.Data {
+			if d.Index >= 0 && d.Index < len(vectors) {
+				vectors[d.Index] = d.Embedding
+			}
+		}
+		for _, v := range vectors {
+			if len(v) == 0 {
+				return nil, fmt.Errorf("embed: %d embeddings in response for %d texts (status %d)", len(decoded.Data), len(batch), status)
+			}
+		}
+		return vectors, nil
+	})
+}
+
+func (e *HTTPEmbedder) post(ctx context.Context, input any) (embedResponse, int, error) {
+	var decoded embedResponse
+	payload, err := json.Marshal(embedRequest{Model: e.model, Input: input})
+	if err != nil {
+		return decoded, 0, err
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(payload))
 	if err != nil {
-		return nil, err
+		return decoded, 0, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+e.apiKey)
 
 	resp, err := e.client.Do(req)
 	if err != nil {
-		return nil, err
+		return decoded, 0, err
 	}
 	defer resp.Body.Close()
 
-	var decoded embedResponse
 	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
-		return nil, fmt.Errorf("embed: decoding response: %w", err)
+		return decoded, resp.StatusCode, fmt.Errorf("embed: decoding response: %w", err)
 	}
 	if decoded.Error != nil {
-		return nil, fmt.Errorf("embed: API error: %s", decoded.Error.Message)
+		return decoded, resp.StatusCode, fmt.Errorf("embed: API error: %s", decoded.Error.Message)
 	}
-	if len(decoded.Data) == 0 || len(decoded.Data[0].Embedding) == 0 {
-		return nil, fmt.Errorf("embed: empty embedding in response (status %d)", resp.StatusCode)
-	}
-	return decoded.Data[0].Embedding, nil
+	return decoded, resp.StatusCode, nil
 }
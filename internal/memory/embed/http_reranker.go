@@ -0,0 +1,108 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// HTTPReranker calls a cross-encoder /rerank endpoint over HTTP and implements
+// memory.Reranker. It speaks the request/response shape shared by Cohere, Jina,
+// Voyage and self-hosted servers such as Hugging Face TEI and Infinity.
+type HTTPReranker struct {
+	apiKey   string
+	model    string
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPReranker builds a reranker. Defaults: model "rerank-v3.5", endpoint
+// https://api.cohere.com/v2/rerank, 30s HTTP timeout.
+func NewHTTPReranker(apiKey, model string) *HTTPReranker {
+	if model == "" {
+		model = "rerank-v3.5"
+	}
+	return &HTTPReranker{
+		apiKey:   apiKey,
+		model:    model,
+		endpoint: "https://api.cohere.com/v2/rerank",
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithEndpoint overrides the rerank endpoint (e.g. Jina or a local server).
+func (r *HTTPReranker) WithEndpoint(endpoint string) *HTTPReranker {
+	if endpoint != "" {
+		r.endpoint = endpoint
+	}
+	return r
+}
+
+type rerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+	Message string `json:"message"`
+	Detail  any    `json:"detail"`
+}
+
+// Rerank scores every hit against query and returns them most relevant first.
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, hits []memory.Hit) ([]memory.Hit, error) {
+	if len(hits) == 0 {
+		return hits, nil
+	}
+	docs := make([]string, len(hits))
+	for i, h := range hits {
+		docs[i] = h.Text
+	}
+	payload, err := json.Marshal(rerankRequest{Model: r.model, Query: query, Documents: docs, TopN: len(docs)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("rerank: decoding response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 {
+		msg := decoded.Message
+		if msg == "" && decoded.Detail != nil {
+			msg = fmt.Sprint(decoded.Detail)
+		}
+		return nil, fmt.Errorf("rerank: API error (status %d): %s", resp.StatusCode, msg)
+	}
+	scores := make(map[int]float64, len(decoded.Results))
+	for _, res := range decoded.Results {
+		if res.Index >= 0 && res.Index < len(hits) {
+			scores[res.Index] = res.RelevanceScore
+		}
+	}
+	return memory.ApplyRerank(hits, scores), nil
+}
@@ -0,0 +1,106 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// OllamaEmbedder calls a local (or remote) Ollama server's /api/embed
+// endpoint and implements memory.BatchEmbedder.
+type OllamaEmbedder struct {
+	host   string
+	model  string
+	client *http.Client
+	batch  memory.BatchConfig
+}
+
+// NewOllamaEmbedder builds an embedder. Defaults: host http://localhost:11434,
+// model "nomic-embed-text", 2 minute HTTP timeout (the first request loads the
+// model), and batches of 64 texts one request at a time.
+func NewOllamaEmbedder(host, model string) *OllamaEmbedder {
+	if host == "" {
+		host = "http://localhost:11434"
+	}
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+	return &OllamaEmbedder{
+		host:   strings.TrimRight(host, "/"),
+		model:  model,
+		client: &http.Client{Timeout: 2 * time.Minute},
+		batch:  memory.BatchConfig{Size: 64, Concurrency: 1},
+	}
+}
+
+// WithBatching overrides how EmbedBatch splits its input; zero fields keep
+// their current values.
+func (e *OllamaEmbedder) WithBatching(cfg memory.BatchConfig) *OllamaEmbedder {
+	if cfg.Size > 0 {
+		e.batch.Size = cfg.Size
+	}
+	if cfg.Concurrency > 0 {
+		e.batch.Concurrency = cfg.Concurrency
+	}
+	return e
+}
+
+type ollamaRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error"`
+}
+
+// Embed returns the embedding vector for text.
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch returns the embedding vectors for texts, in order.
+func (e *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return memory.EmbedInBatches(ctx, texts, e.batch, e.embed)
+}
+
+func (e *OllamaEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	payload, err := json.Marshal(ollamaRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.host+"/api/embed", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("ollama embed: decoding response (status %d): %w", resp.StatusCode, err)
+	}
+	if decoded.Error != "" {
+		return nil, fmt.Errorf("ollama embed: %s", decoded.Error)
+	}
+	if len(decoded.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama embed: %d embeddings in response for %d texts (status %d)", len(decoded.Embeddings), len(texts), resp.StatusCode)
+	}
+	return decoded.Embeddings, nil
+}
@@ -0,0 +1,211 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// BatchConfig bounds how an embedder splits a large EmbedBatch call into
+// provider requests.
+type BatchConfig struct {
+	// Size is the maximum number of texts per request.
+	Size int
+	// Concurrency caps the requests in flight.
+	Concurrency int
+}
+
+// EmbedBatch embeds texts with e: in one EmbedBatch call when e is a
+// BatchEmbedder, otherwise with one Embed call per text, in order.
+func EmbedBatch(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	if b, ok := e.(BatchEmbedder); ok {
+		return b.EmbedBatch(ctx, texts)
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// EmbedInBatches splits texts into batches of cfg.Size, embeds them with
+// embed, running up to cfg.Concurrency batches at once, and returns the
+// vectors in the order of texts. Sizes and concurrency below one are treated
+// as one. The first error cancels the batches still running. Embedder
+// backends use it to implement EmbedBatch.
+func EmbedInBatches(ctx context.Context, texts []string, cfg BatchConfig, embed func(ctx context.Context, batch []string) ([][]float32, error)) ([][]float32, error) {
+	size := max(cfg.Size, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	vectors := make([][]float32, len(texts))
+	sem := make(chan struct{}, max(cfg.Concurrency, 1))
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) { once.Do(func() { firstErr = err; cancel() }) }
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(ctx.Err())
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer func() { <-sem; wg.Done() }()
+			got, err := embed(ctx, texts[start:end])
+			if err == nil && len(got) != end-start {
+				err = fmt.Errorf("embedder returned %d vectors for %d texts", len(got), end-start)
+			}
+			if err != nil {
+				fail(err)
+				return
+			}
+			copy(vectors[start:end], got)
+		}(start, end)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return vectors, nil
+}
+
+// CachingEmbedder wraps an Embedder with an in-process LRU cache keyed by a
+// hash of the text, so unchanged text (re-ingested documents, repeated
+// queries) is never embedded twice. It implements BatchEmbedder and only
+// sends cache misses to the wrapped embedder. Cached vectors are shared
+// between callers and must not be modified.
+type CachingEmbedder struct {
+	inner Embedder
+	size  int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	order   *list.List // front = most recently used
+	hits    int
+	misses  int
+}
+
+type cacheEntry struct {
+	key    [sha256.Size]byte
+	vector []float32
+}
+
+// NewCachingEmbedder caches up to size vectors (10000 when size <= 0) from
+// inner.
+func NewCachingEmbedder(inner Embedder, size int) *CachingEmbedder {
+	if size <= 0 {
+		size = 10000
+	}
+	return &CachingEmbedder{
+		inner:   inner,
+		size:    size,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Embed returns the cached vector for text, embedding it on a miss.
+func (c *CachingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	key := sha256.Sum256([]byte(text))
+	if v, ok := c.get(key); ok {
+		return v, nil
+	}
+	v, err := c.inner.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, v)
+	return v, nil
+}
+
+// EmbedBatch returns the vectors for texts, embedding only the distinct
+// texts that are not cached, in a single batch.
+func (c *CachingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	keys := make([][sha256.Size]byte, len(texts))
+	var missing []string
+	pending := make(map[[sha256.Size]byte][]int)
+	for i, text := range texts {
+		keys[i] = sha256.Sum256([]byte(text))
+		if v, ok := c.get(keys[i]); ok {
+			vectors[i] = v
+			continue
+		}
+		if _, ok := pending[keys[i]]; !ok {
+			missing = append(missing, text)
+		}
+		pending[keys[i]] = append(pending[keys[i]], i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+	embedded, err := EmbedBatch(ctx, c.inner, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(embedded), len(missing))
+	}
+	for j, text := range missing {
+		key := sha256.Sum256([]byte(text))
+		c.put(key, embedded[j])
+		for _, i := range pending[key] {
+			vectors[i] = embedded[j]
+		}
+	}
+	return vectors, nil
+}
+
+// Len reports the number of cached vectors.
+func (c *CachingEmbedder) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats reports the cache hits and misses so far.
+func (c *CachingEmbedder) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *CachingEmbedder) get(key [sha256.Size]byte) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).vector, true
+}
+
+func (c *CachingEmbedder) put(key [sha256.Size]byte, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cacheEntry).vector = vector
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, vector: vector})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
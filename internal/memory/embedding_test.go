@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// lenEmbedder embeds text as its length and records every call.
+type lenEmbedder struct {
+	mu      sync.Mutex
+	singles []string
+	batches [][]string
+}
+
+func (e *lenEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.singles = append(e.singles, text)
+	return []float32{float32(len(text))}, nil
+}
+
+type lenBatchEmbedder struct{ lenEmbedder }
+
+func (e *lenBatchEmbedder) EmbedBatch(_ context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, append([]string(nil), texts...))
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func TestEmbedInBatches_OrderAndConcurrency(t *testing.T) {
+	texts := make([]string, 25)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+	var inFlight, peak, calls atomic.Int64
+	vectors, err := EmbedInBatches(context.Background(), texts, BatchConfig{Size: 4, Concurrency: 2}, func(_ context.Context, batch []string) ([][]float32, error) {
+		calls.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		if len(batch) > 4 {
+			t.Errorf("batch of %d texts", len(batch))
+		}
+		out := make([][]float32, len(batch))
+		for i, text := range batch {
+			out[i] = []float32{float32(len(text))}
+		}
+		return out, nil
+	})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if calls.Load() != 7 || peak.Load() > 2 {
+		t.Errorf("calls = %d, peak concurrency = %d", calls.Load(), peak.Load())
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i+1) {
+			t.Fatalf("vector %d out of order: %v", i, v)
+		}
+	}
+
+	boom := errors.New("boom")
+	_, err = EmbedInBatches(context.Background(), texts, BatchConfig{Size: 10}, func(context.Context, []string) ([][]float32, error) {
+		return nil, boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("want the batch error, got %v", err)
+	}
+	_, err = EmbedInBatches(context.Background(), texts, BatchConfig{Size: 10}, func(context.Context, []string) ([][]float32, error) {
+		return [][]float32{{1}}, nil
+	})
+	if err == nil {
+		t.Error("a short response should fail")
+	}
+}
+
+func TestEmbedBatch_FallsBackToEmbed(t *testing.T) {
+	e := &lenEmbedder{}
+	vectors, err := EmbedBatch(context.Background(), e, []string{"a", "bb"})
+	if err != nil || len(vectors) != 2 || vectors[1][0] != 2 || len(e.singles) != 2 {
+		t.Errorf("fallback: %v %v %v", vectors, e.singles, err)
+	}
+}
+
+func TestCachingEmbedder(t *testing.T) {
+	ctx := context.Background()
+	inner := &lenBatchEmbedder{}
+	c := NewCachingEmbedder(inner, 3)
+
+	if _, err := c.Embed(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Embed(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.singles) != 1 {
+		t.Errorf("repeated text embedded %d times", len(inner.singles))
+	}
+
+	vectors, err := c.EmbedBatch(ctx, []string{"one", "three", "three", "fourth"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.batches) != 1 || strings.Join(inner.batches[0], ",") != "three,fourth" {
+		t.Errorf("want only the distinct misses in one batch, got %q", inner.batches)
+	}
+	for i, want := range []float32{3, 5, 5, 6} {
+		if vectors[i][0] != want {
+			t.Errorf("vector %d = %v, want %v", i, vectors[i], want)
+		}
+	}
+	if hits, misses := c.Stats(); hits != 2 || misses != 4 {
+		t.Errorf("stats = %d hits, %d misses", hits, misses)
+	}
+
+	// Size 3: "one" is the least recently used after "three" and "fourth"
+	// were added, so adding a fourth entry evicts it.
+	_, _ = c.Embed(ctx, "five!")
+	if c.Len() != 3 {
+		t.Errorf("len = %d, want 3", c.Len())
+	}
+	_, _ = c.Embed(ctx, "one")
+	if len(inner.singles) != 3 {
+		t.Errorf("evicted text not re-embedded: %q", inner.singles)
+	}
+}
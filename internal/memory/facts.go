@@ -0,0 +1,421 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fact is a durable piece of knowledge about a user (a preference, a name, an
+// account detail) that should outlive any single session.
+type Fact struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FactStore persists user facts.
+type FactStore interface {
+	ListFacts(ctx context.Context, userID string) ([]Fact, error)
+	UpsertFact(ctx context.Context, fact Fact) error
+	DeleteFact(ctx context.Context, userID, factID string) error
+	DeleteFacts(ctx context.Context, userID string) error
+}
+
+// Fact update operations returned by a FactExtractor.
+const (
+	FactAdd    = "add"
+	FactUpdate = "update"
+	FactDelete = "delete"
+)
+
+// FactChange is one change a FactExtractor wants applied to a user's facts.
+// ID refers to an existing fact for FactUpdate and FactDelete.
+type FactChange struct {
+	Op   string `json:"op"`
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// FactExtractor reads a completed turn and decides how the user's known facts
+// change: new facts to add, stale ones to update, contradicted ones to delete.
+type FactExtractor interface {
+	ExtractFacts(ctx context.Context, known []Fact, turn Turn) ([]FactChange, error)
+}
+
+// CompletionFunc sends a single system + user prompt to a language model and
+// returns its text reply. It lets memory components that need a model (fact
+// extraction, summarization) run on any configured provider without this
+// package importing one.
+type CompletionFunc func(ctx context.Context, system, prompt string) (string, error)
+
+// ---- LLM fact extractor ----
+
+const factExtractorSystemPrompt = `You maintain a list of durable facts about a user: preferences, names, relationships, account details and other information that will still be true in future conversations.
+You will be given the KNOWN FACTS (each with an id) and a NEW TURN between the user (Human) and an AI assistant.
+Decide how the known facts must change. Only record facts the user states or clearly implies about themselves; ignore one-off requests, questions and anything the AI says that the user did not confirm.
+Reply with ONLY a JSON array of operations, using:
+  {"op":"add","text":"<new fact>"}
+  {"op":"update","id":"<known fact id>","text":"<corrected fact>"}
+  {"op":"delete","id":"<known fact id>"}
+Write each fact as a short third-person sentence (e.g. "Prefers email over phone calls."). Reply with [] when nothing changes.`
+
+type llmFactExtractor struct {
+	complete CompletionFunc
+}
+
+// NewFactExtractor returns a FactExtractor that asks a language model, through
+// complete, for the fact changes implied by each turn.
+func NewFactExtractor(complete CompletionFunc) FactExtractor {
+	return &llmFactExtractor{complete: complete}
+}
+
+func (e *llmFactExtractor) ExtractFacts(ctx context.Context, known []Fact, turn Turn) ([]FactChange, error) {
+	var b strings.Builder
+	b.WriteString("KNOWN FACTS:\n")
+	if len(known) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, f := range known {
+		fmt.Fprintf(&b, "- [%s] %s\n", f.ID, f.Text)
+	}
+	b.WriteString("\nNEW TURN:\n")
+	b.WriteString(RenderTurns([]Turn{turn}))
+
+	reply, err := e.complete(ctx, factExtractorSystemPrompt, b.String())
+	if err != nil {
+		return nil, err
+	}
+	return parseFactChanges(reply)
+}
+
+// parseFactChanges decodes the JSON array in a model reply, tolerating prose
+// or code fences around it.
+func parseFactChanges(reply string) ([]FactChange, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("memory: fact extractor reply has no JSON array: %q", reply)
+	}
+	var changes []FactChange
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &changes); err != nil {
+		return nil, fmt.Errorf("memory: decoding fact changes: %w", err)
+	}
+	return changes, nil
+}
+
+// ---- user memory ----
+
+// UserMemoryConfig tunes long-term user memory.
+type UserMemoryConfig struct {
+	// Embedder and Index enable semantic deduplication: every fact is embedded
+	// into Index, and a new fact that closely matches an existing one updates
+	// it instead of being added twice. When nil, only exact (case- and
+	// whitespace-insensitive) duplicates are detected.
+	Embedder Embedder
+	Index    VectorStore
+	// DuplicateThreshold is the cosine similarity at or above which a new fact
+	// is treated as a restatement of an existing one. Defaults to 0.9.
+	DuplicateThreshold float64
+	// MaxProfileFacts caps how many facts (most recently updated first) are
+	// rendered into the profile block. Defaults to 50.
+	MaxProfileFacts int
+}
+
+// UserMemory keeps durable facts about each user. After every turn, Observe
+// asks the FactExtractor how the user's facts change and applies the result;
+// Profile renders the facts as a block for the system prompt. Facts can be
+// inspected, added and deleted directly through Facts, AddFact, Forget and
+// ForgetAll.
+type UserMemory struct {
+	store     FactStore
+	extractor FactExtractor
+	cfg       UserMemoryConfig
+
+	// users serializes updates per user so concurrent turns cannot
+	// interleave their read-modify-write of the same fact list.
+	users keyedMutex
+}
+
+// NewUserMemory builds a UserMemory, applying default config values.
+func NewUserMemory(store FactStore, extractor FactExtractor, cfg UserMemoryConfig) *UserMemory {
+	if cfg.DuplicateThreshold <= 0 {
+		cfg.DuplicateThreshold = 0.9
+	}
+	if cfg.MaxProfileFacts <= 0 {
+		cfg.MaxProfileFacts = 50
+	}
+	return &UserMemory{store: store, extractor: extractor, cfg: cfg}
+}
+
+// Observe extracts fact changes from a completed turn and applies them.
+func (m *UserMemory) Observe(ctx context.Context, userID string, turn Turn) error {
+	if userID == "" {
+		return errors.New("memory: user memory requires a user ID")
+	}
+	if m.extractor == nil {
+		return errors.New("memory: user memory has no fact extractor")
+	}
+	unlock := m.users.Lock(userID)
+	defer unlock()
+
+	known, err := m.store.ListFacts(ctx, userID)
+	if err != nil {
+		return err
+	}
+	changes, err := m.extractor.ExtractFacts(ctx, known, turn)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]Fact, len(known))
+	for _, f := range known {
+		byID[f.ID] = f
+	}
+	for _, c := range changes {
+		text := strings.TrimSpace(c.Text)
+		switch c.Op {
+		case FactAdd:
+			if text != "" {
+				f, err := m.put(ctx, userID, text, known)
+				if err != nil {
+					return err
+				}
+				known = append(known, f)
+			}
+		case FactUpdate:
+			f, ok := byID[c.ID]
+			if !ok || text == "" {
+				continue
+			}
+			f.Text, f.UpdatedAt = text, time.Now().UTC()
+			if err := m.save(ctx, f); err != nil {
+				return err
+			}
+		case FactDelete:
+			if _, ok := byID[c.ID]; ok {
+				if err := m.delete(ctx, userID, c.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// AddFact records a fact directly, deduplicating it against the user's known
+// facts like an extracted one.
+func (m *UserMemory) AddFact(ctx context.Context, userID, text string) (Fact, error) {
+	text = strings.TrimSpace(text)
+	if userID == "" || text == "" {
+		return Fact{}, errors.New("memory: a fact needs a user ID and text")
+	}
+	unlock := m.users.Lock(userID)
+	defer unlock()
+
+	known, err := m.store.ListFacts(ctx, userID)
+	if err != nil {
+		return Fact{}, err
+	}
+	return m.put(ctx, userID, text, known)
+}
+
+// put adds text as a fact, or updates the known fact it duplicates.
+func (m *UserMemory) put(ctx context.Context, userID, text string, known []Fact) (Fact, error) {
+	now := time.Now().UTC()
+	if dup, ok, err := m.duplicateOf(ctx, userID, text, known); err != nil {
+		return Fact{}, err
+	} else if ok {
+		dup.Text, dup.UpdatedAt = text, now
+		return dup, m.save(ctx, dup)
+	}
+	f := Fact{ID: newFactID(), UserID: userID, Text: text, CreatedAt: now, UpdatedAt: now}
+	return f, m.save(ctx, f)
+}
+
+// duplicateOf finds a known fact that text restates.
+func (m *UserMemory) duplicateOf(ctx context.Context, userID, text string, known []Fact) (Fact, bool, error) {
+	norm := normalizeFact(text)
+	for _, f := range known {
+		if normalizeFact(f.Text) == norm {
+			return f, true, nil
+		}
+	}
+	if m.cfg.Embedder == nil || m.cfg.Index == nil || len(known) == 0 {
+		return Fact{}, false, nil
+	}
+	vector, err := m.cfg.Embedder.Embed(ctx, text)
+	if err != nil {
+		return Fact{}, false, err
+	}
+	hits, err := m.cfg.Index.SearchFiltered(ctx, vector, 4, Filter{Eq("user_id", userID)})
+	if err != nil {
+		return Fact{}, false, err
+	}
+	byID := make(map[string]Fact, len(known))
+	for _, f := range known {
+		byID[f.ID] = f
+	}
+	for _, h := range hits {
+		if h.Score < m.cfg.DuplicateThreshold {
+			break
+		}
+		// Only live facts count, in case the index missed a deletion.
+		if f, ok := byID[h.ID]; ok {
+			return f, true, nil
+		}
+	}
+	return Fact{}, false, nil
+}
+
+// save persists a fact and refreshes its embedding in the dedup index.
+func (m *UserMemory) save(ctx context.Context, f Fact) error {
+	if err := m.store.UpsertFact(ctx, f); err != nil {
+		return err
+	}
+	if m.cfg.Embedder == nil || m.cfg.Index == nil {
+		return nil
+	}
+	vector, err := m.cfg.Embedder.Embed(ctx, f.Text)
+	if err != nil {
+		return err
+	}
+	return m.cfg.Index.Add(ctx, f.ID, f.Text, vector, map[string]any{"user_id": f.UserID, "kind": "fact"})
+}
+
+// Facts returns the user's known facts, most recently updated first.
+func (m *UserMemory) Facts(ctx context.Context, userID string) ([]Fact, error) {
+	facts, err := m.store.ListFacts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(facts, func(i, j int) bool { return facts[i].UpdatedAt.After(facts[j].UpdatedAt) })
+	return facts, nil
+}
+
+// delete removes a fact from the store and the dedup index.
+func (m *UserMemory) delete(ctx context.Context, userID, factID string) error {
+	if err := m.store.DeleteFact(ctx, userID, factID); err != nil {
+		return err
+	}
+	if m.cfg.Index != nil {
+		return m.cfg.Index.Delete(ctx, factID)
+	}
+	return nil
+}
+
+// Forget deletes one of the user's facts.
+func (m *UserMemory) Forget(ctx context.Context, userID, factID string) error {
+	unlock := m.users.Lock(userID)
+	defer unlock()
+	return m.delete(ctx, userID, factID)
+}
+
+// ForgetAll deletes every fact known about the user.
+func (m *UserMemory) ForgetAll(ctx context.Context, userID string) error {
+	unlock := m.users.Lock(userID)
+	defer unlock()
+	if err := m.store.DeleteFacts(ctx, userID); err != nil {
+		return err
+	}
+	if m.cfg.Index != nil {
+		return m.cfg.Index.DeleteByFilter(ctx, Filter{Eq("user_id", userID)})
+	}
+	return nil
+}
+
+// Profile renders the user's facts as a block for the system prompt, or ""
+// when nothing is known about the user.
+func (m *UserMemory) Profile(ctx context.Context, userID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	facts, err := m.Facts(ctx, userID)
+	if err != nil || len(facts) == 0 {
+		return "", err
+	}
+	if len(facts) > m.cfg.MaxProfileFacts {
+		facts = facts[:m.cfg.MaxProfileFacts]
+	}
+	var b strings.Builder
+	b.WriteString("What you know about the user:\n")
+	for _, f := range facts {
+		b.WriteString("- ")
+		b.WriteString(f.Text)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func normalizeFact(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.TrimRight(s, ". ")), " "))
+}
+
+func newFactID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("fact-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// ---- in-memory fact store ----
+
+// InMemoryFactStore is a process-local FactStore for tests and single-node
+// use. Production deployments should use the MongoDB-backed store.
+type InMemoryFactStore struct {
+	mu    sync.RWMutex
+	facts map[string]map[string]Fact // userID -> factID -> fact
+}
+
+// NewInMemoryFactStore returns an empty in-memory fact store.
+func NewInMemoryFactStore() *InMemoryFactStore {
+	return &InMemoryFactStore{facts: make(map[string]map[string]Fact)}
+}
+
+// ListFacts returns the user's facts in creation order.
+func (s *InMemoryFactStore) ListFacts(_ context.Context, userID string) ([]Fact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Fact, 0, len(s.facts[userID]))
+	for _, f := range s.facts[userID] {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// UpsertFact stores (or replaces, by ID) a fact.
+func (s *InMemoryFactStore) UpsertFact(_ context.Context, fact Fact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.facts[fact.UserID] == nil {
+		s.facts[fact.UserID] = make(map[string]Fact)
+	}
+	s.facts[fact.UserID][fact.ID] = fact
+	return nil
+}
+
+// DeleteFact removes one fact; deleting an unknown fact is not an error.
+func (s *InMemoryFactStore) DeleteFact(_ context.Context, userID, factID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.facts[userID], factID)
+	return nil
+}
+
+// DeleteFacts removes every fact of a user.
+func (s *InMemoryFactStore) DeleteFacts(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.facts, userID)
+	return nil
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedExtractor returns a fixed set of changes and records what it saw.
+type scriptedExtractor struct {
+	changes func(known []Fact) []FactChange
+	seen    []Fact
+}
+
+func (e *scriptedExtractor) ExtractFacts(_ context.Context, known []Fact, _ Turn) ([]FactChange, error) {
+	e.seen = known
+	return e.changes(known), nil
+}
+
+func TestUserMemory_AddUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	ex := &scriptedExtractor{changes: func([]Fact) []FactChange {
+		return []FactChange{{Op: FactAdd, Text: "Lives in Lagos."}, {Op: FactAdd, Text: "Prefers email."}}
+	}}
+	m := NewUserMemory(NewInMemoryFactStore(), ex, UserMemoryConfig{})
+	if err := m.Observe(ctx, "u1", Turn{Human: "I live in Lagos, email me"}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	facts, _ := m.Facts(ctx, "u1")
+	if len(facts) != 2 {
+		t.Fatalf("want 2 facts, got %+v", facts)
+	}
+
+	var lagos, email Fact
+	for _, f := range facts {
+		if strings.Contains(f.Text, "Lagos") {
+			lagos = f
+		} else {
+			email = f
+		}
+	}
+	ex.changes = func([]Fact) []FactChange {
+		return []FactChange{
+			{Op: FactUpdate, ID: lagos.ID, Text: "Lives in Abuja."},
+			{Op: FactDelete, ID: email.ID},
+			{Op: FactDelete, ID: "unknown"},
+		}
+	}
+	if err := m.Observe(ctx, "u1", Turn{Human: "I moved to Abuja; call me instead"}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if len(ex.seen) != 2 {
+		t.Errorf("extractor should see the known facts, saw %+v", ex.seen)
+	}
+	facts, _ = m.Facts(ctx, "u1")
+	if len(facts) != 1 || facts[0].ID != lagos.ID || facts[0].Text != "Lives in Abuja." {
+		t.Fatalf("want the updated Lagos fact only, got %+v", facts)
+	}
+	if !facts[0].CreatedAt.Equal(lagos.CreatedAt) {
+		t.Errorf("update changed CreatedAt")
+	}
+}
+
+func TestUserMemory_DeduplicatesExactAndSemantic(t *testing.T) {
+	ctx := context.Background()
+	m := NewUserMemory(NewInMemoryFactStore(), nil, UserMemoryConfig{
+		Embedder:           testVocab,
+		Index:              NewInMemoryVectorStore(),
+		DuplicateThreshold: 0.95,
+	})
+	first, err := m.AddFact(ctx, "u1", "Wants a refund for order 12.")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := m.AddFact(ctx, "u1", "  wants a REFUND for order 12 "); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	second, _ := m.AddFact(ctx, "u1", "Asked about a refund twice.")
+	if second.ID != first.ID {
+		t.Errorf("semantic duplicate should update the existing fact, got new ID %s", second.ID)
+	}
+	if _, err := m.AddFact(ctx, "u1", "Forgot their password."); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	// Same embedding, different user: not a duplicate.
+	other, _ := m.AddFact(ctx, "u2", "Wants a refund.")
+	if other.ID == first.ID {
+		t.Errorf("fact deduplicated across users")
+	}
+
+	facts, _ := m.Facts(ctx, "u1")
+	if len(facts) != 2 {
+		t.Fatalf("want 2 distinct facts for u1, got %+v", facts)
+	}
+}
+
+func TestUserMemory_ProfileAndForget(t *testing.T) {
+	ctx := context.Background()
+	m := NewUserMemory(NewInMemoryFactStore(), nil, UserMemoryConfig{MaxProfileFacts: 1})
+	if p, _ := m.Profile(ctx, "u1"); p != "" {
+		t.Errorf("empty profile should render as empty, got %q", p)
+	}
+	f, _ := m.AddFact(ctx, "u1", "Is vegetarian.")
+	_, _ = m.AddFact(ctx, "u1", "Has two cats.")
+
+	p, _ := m.Profile(ctx, "u1")
+	if !strings.HasPrefix(p, "What you know about the user:\n- ") || strings.Count(p, "\n- ") != 1 {
+		t.Errorf("profile should hold one fact, got %q", p)
+	}
+
+	if err := m.Forget(ctx, "u1", f.ID); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if facts, _ := m.Facts(ctx, "u1"); len(facts) != 1 || facts[0].Text != "Has two cats." {
+		t.Errorf("forget removed the wrong fact: %+v", facts)
+	}
+	if err := m.ForgetAll(ctx, "u1"); err != nil {
+		t.Fatalf("forget all: %v", err)
+	}
+	if facts, _ := m.Facts(ctx, "u1"); len(facts) != 0 {
+		t.Errorf("facts remain after ForgetAll: %+v", facts)
+	}
+}
+
+func TestUserMemory_ForgetRemovesEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	index := NewInMemoryVectorStore()
+	m := NewUserMemory(NewInMemoryFactStore(), nil, UserMemoryConfig{
+		Embedder: testVocab,
+		Index:    index,
+	})
+	refund, _ := m.AddFact(ctx, "u1", "Wants a refund for order 12.")
+	_, _ = m.AddFact(ctx, "u1", "Forgot their password.")
+	query, _ := testVocab.Embed(ctx, "refund")
+
+	if err := m.Forget(ctx, "u1", refund.ID); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	hits, _ := index.Search(ctx, query, 10)
+	for _, h := range hits {
+		if h.ID == refund.ID {
+			t.Errorf("forgotten fact still in the index")
+		}
+	}
+	if err := m.ForgetAll(ctx, "u1"); err != nil {
+		t.Fatalf("forget all: %v", err)
+	}
+	if hits, _ := index.Search(ctx, query, 10); len(hits) != 0 {
+		t.Errorf("index not emptied by ForgetAll: %+v", hits)
+	}
+}
+
+func TestFactExtractor_ParsesModelReply(t *testing.T) {
+	var gotPrompt string
+	ex := NewFactExtractor(func(_ context.Context, _, prompt string) (string, error) {
+		gotPrompt = prompt
+		return "Sure:\n```json\n[{\"op\":\"add\",\"text\":\"Is left-handed.\"},{\"op\":\"delete\",\"id\":\"f1\"}]\n```", nil
+	})
+	changes, err := ex.ExtractFacts(context.Background(),
+		[]Fact{{ID: "f1", Text: "Is right-handed."}},
+		Turn{Human: "Actually I'm left-handed", AI: "Noted!"})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Op != FactAdd || changes[1].ID != "f1" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if !strings.Contains(gotPrompt, "[f1] Is right-handed.") || !strings.Contains(gotPrompt, "left-handed") {
+		t.Errorf("prompt missing known facts or turn:\n%s", gotPrompt)
+	}
+
+	bad := NewFactExtractor(func(context.Context, string, string) (string, error) { return "no changes", nil })
+	if _, err := bad.ExtractFacts(context.Background(), nil, Turn{}); err == nil {
+		t.Error("expected an error for a reply without JSON")
+	}
+	failing := NewFactExtractor(func(context.Context, string, string) (string, error) { return "", errors.New("boom") })
+	if _, err := failing.ExtractFacts(context.Background(), nil, Turn{}); err == nil {
+		t.Error("expected the completion error to propagate")
+	}
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Filter operators for Condition.Op.
+const (
+	FilterEq  = "eq"
+	FilterIn  = "in"
+	FilterNin = "nin"
+	FilterGt  = "gt"
+	FilterGte = "gte"
+	FilterLt  = "lt"
+	FilterLte = "lte"
+)
+
+// Condition tests one metadata field. For FilterIn and FilterNin, Value is a
+// slice of values; range operators compare numbers or strings.
+type Condition struct {
+	Field string
+	Op    string
+	Value any
+}
+
+// Filter restricts a VectorStore operation to entries whose metadata matches
+// every condition. An empty Filter matches everything.
+type Filter []Condition
+
+// Eq matches entries whose field equals value.
+func Eq(field string, value any) Condition {
+	return Condition{Field: field, Op: FilterEq, Value: value}
+}
+
+// In matches entries whose field equals any of values.
+func In(field string, values ...any) Condition {
+	return Condition{Field: field, Op: FilterIn, Value: values}
+}
+
+// Nin matches entries whose field is missing or equals none of values.
+func Nin(field string, values ...any) Condition {
+	return Condition{Field: field, Op: FilterNin, Value: values}
+}
+
+// Gt matches entries whose field is greater than value.
+func Gt(field string, value any) Condition {
+	return Condition{Field: field, Op: FilterGt, Value: value}
+}
+
+// Gte matches entries whose field is greater than or equal to value.
+func Gte(field string, value any) Condition {
+	return Condition{Field: field, Op: FilterGte, Value: value}
+}
+
+// Lt matches entries whose field is less than value.
+func Lt(field string, value any) Condition {
+	return Condition{Field: field, Op: FilterLt, Value: value}
+}
+
+// Lte matches entries whose field is less than or equal to value.
+func Lte(field string, value any) Condition {
+	return Condition{Field: field, Op: FilterLte, Value: value}
+}
+
+// Validate reports conditions with no field or an unknown operator.
+func (f Filter) Validate() error {
+	for _, c := range f {
+		if strings.TrimSpace(c.Field) == "" {
+			return fmt.Errorf("memory: filter condition has no field")
+		}
+		switch c.Op {
+		case FilterEq, FilterIn, FilterNin, FilterGt, FilterGte, FilterLt, FilterLte:
+		default:
+			return fmt.Errorf("memory: unknown filter operator %q on field %q", c.Op, c.Field)
+		}
+	}
+	return nil
+}
+
+// Match reports whether meta satisfies every condition. A missing field only
+// matches FilterNin.
+func (f Filter) Match(meta map[string]any) bool {
+	for _, c := range f {
+		if !c.match(meta) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) match(meta map[string]any) bool {
+	v, ok := meta[c.Field]
+	if !ok {
+		return c.Op == FilterNin
+	}
+	switch c.Op {
+	case FilterEq:
+		return filterEqual(v, c.Value)
+	case FilterIn:
+		for _, want := range FilterValues(c.Value) {
+			if filterEqual(v, want) {
+				return true
+			}
+		}
+		return false
+	case FilterNin:
+		for _, unwanted := range FilterValues(c.Value) {
+			if filterEqual(v, unwanted) {
+				return false
+			}
+		}
+		return true
+	case FilterGt, FilterGte, FilterLt, FilterLte:
+		cmp, ok := filterCompare(v, c.Value)
+		if !ok {
+			return false
+		}
+		switch c.Op {
+		case FilterGt:
+			return cmp > 0
+		case FilterGte:
+			return cmp >= 0
+		case FilterLt:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	}
+	return false
+}
+
+// FilterValues flattens the Value of a FilterIn or FilterNin condition into a []any; a
+// non-slice value is treated as a one-element list.
+func FilterValues(v any) []any {
+	if vs, ok := v.([]any); ok {
+		return vs
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []any{v}
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// filterNumber converts any Go numeric type to float64, so metadata decoded
+// as int64 (from BSON) or float64 (from JSON) compares equal to an int.
+func filterNumber(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func filterEqual(a, b any) bool {
+	if fa, ok := filterNumber(a); ok {
+		fb, ok := filterNumber(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func filterCompare(a, b any) (int, bool) {
+	if fa, ok := filterNumber(a); ok {
+		fb, ok := filterNumber(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	sa, ok1 := a.(string)
+	sb, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return strings.Compare(sa, sb), true
+}
@@ -0,0 +1,46 @@
+package memory
+
+import "testing"
+
+func TestFilter_Match(t *testing.T) {
+	meta := map[string]any{"user_id": "u1", "score": int64(7), "ratio": 0.5, "tag": "beta"}
+	cases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", nil, true},
+		{"eq string", Filter{Eq("user_id", "u1")}, true},
+		{"eq across numeric types", Filter{Eq("score", 7)}, true},
+		{"eq mismatch", Filter{Eq("user_id", "u2")}, false},
+		{"missing field", Filter{Eq("session_id", "s1")}, false},
+		{"in variadic", Filter{In("user_id", "u0", "u1")}, true},
+		{"in typed slice", Filter{{Field: "user_id", Op: FilterIn, Value: []string{"u2", "u3"}}}, false},
+		{"nin", Filter{Nin("user_id", "u0", "u1")}, false},
+		{"nin across numeric types", Filter{Nin("score", 1, 2)}, true},
+		{"nin missing field", Filter{Nin("session_id", "s1")}, true},
+		{"gt", Filter{Gt("score", 6.5)}, true},
+		{"lte boundary", Filter{Lte("score", 7)}, true},
+		{"lt", Filter{Lt("ratio", 0.5)}, false},
+		{"string range", Filter{Gte("tag", "alpha"), Lt("tag", "gamma")}, true},
+		{"mixed types never compare", Filter{Gt("tag", 1)}, false},
+		{"all conditions must hold", Filter{Eq("user_id", "u1"), Gt("score", 10)}, false},
+	}
+	for _, c := range cases {
+		if got := c.filter.Match(meta); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	if err := (Filter{Eq("a", 1), In("b", 1, 2)}).Validate(); err != nil {
+		t.Errorf("valid filter rejected: %v", err)
+	}
+	if err := (Filter{{Field: "a", Op: "like"}}).Validate(); err == nil {
+		t.Error("unknown operator accepted")
+	}
+	if err := (Filter{Eq(" ", 1)}).Validate(); err == nil {
+		t.Error("empty field accepted")
+	}
+}
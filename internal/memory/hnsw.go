@@ -0,0 +1,506 @@
+package memory
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HNSWConfig tunes the HNSW index. Larger values trade memory and insert time
+// for recall.
+type HNSWConfig struct {
+	// M is the number of neighbours each node keeps per layer (twice that on
+	// the bottom layer). Defaults to 16.
+	M int
+	// EfConstruction is the candidate list size used while inserting.
+	// Defaults to 200.
+	EfConstruction int
+	// EfSearch is the candidate list size used while searching; it is raised
+	// to k when k is larger. Defaults to 64.
+	EfSearch int
+	// Seed seeds the level generator so graphs are reproducible. Defaults to 1.
+	Seed int64
+}
+
+// HNSWVectorStore is an approximate nearest-neighbour VectorStore built on a
+// Hierarchical Navigable Small World graph (Malkov & Yashunin, 2016). Search
+// cost grows roughly logarithmically with the number of entries, instead of
+// linearly as in InMemoryVectorStore, at the price of occasionally missing a
+// true neighbour (tune with EfSearch).
+//
+// Vectors are stored unit-normalized and ranked by cosine similarity. Deleted
+// or replaced entries are tombstoned and the graph is rebuilt once tombstones
+// outnumber live entries. Filtered searches widen the candidate list until k
+// matches are found, so very selective filters approach brute-force cost.
+type HNSWVectorStore struct {
+	index     *hnswIndex
+	namespace string
+}
+
+type hnswIndex struct {
+	mu        sync.RWMutex
+	cfg       HNSWConfig
+	rng       *rand.Rand
+	levelMult float64
+	graphs    map[string]*hnswGraph
+}
+
+type hnswNode struct {
+	id      string
+	text    string
+	vec     []float32 // unit-normalized
+	meta    map[string]any
+	friends [][]int32 // neighbour lists, one per layer the node lives on
+	deleted bool
+}
+
+type hnswGraph struct {
+	nodes    []*hnswNode
+	ids      map[string]int32 // live entries only
+	entry    int32
+	maxLevel int
+	deleted  int
+}
+
+// NewHNSWVectorStore returns an empty HNSW-indexed vector store, applying
+// default config values.
+func NewHNSWVectorStore(cfg HNSWConfig) *HNSWVectorStore {
+	if cfg.M <= 1 {
+		cfg.M = 16
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = 200
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = 64
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = 1
+	}
+	return &HNSWVectorStore{index: &hnswIndex{
+		cfg:       cfg,
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		graphs:    make(map[string]*hnswGraph),
+	}}
+}
+
+// Namespace returns a view of the store scoped to name. Each namespace has its
+// own graph.
+func (s *HNSWVectorStore) Namespace(name string) VectorStore {
+	return &HNSWVectorStore{index: s.index, namespace: name}
+}
+
+// Len returns the number of live entries in the namespace.
+func (s *HNSWVectorStore) Len() int {
+	s.index.mu.RLock()
+	defer s.index.mu.RUnlock()
+	if g := s.index.graphs[s.namespace]; g != nil {
+		return len(g.ids)
+	}
+	return 0
+}
+
+// Add stores (or replaces, by id) an embedded text entry.
+func (s *HNSWVectorStore) Add(_ context.Context, id, text string, vector []float32, meta map[string]any) error {
+	if len(vector) == 0 {
+		return errors.New("memory: empty vector")
+	}
+	x := s.index
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	g, ok := x.graphs[s.namespace]
+	if !ok {
+		g = &hnswGraph{ids: make(map[string]int32)}
+		x.graphs[s.namespace] = g
+	}
+	if old, ok := g.ids[id]; ok {
+		g.tombstone(old)
+	}
+	x.insert(g, &hnswNode{id: id, text: text, vec: normalize(vector), meta: meta})
+	x.compact(s.namespace)
+	return nil
+}
+
+// Existing reports which of ids are live in the namespace.
+func (s *HNSWVectorStore) Existing(_ context.Context, ids ...string) (map[string]bool, error) {
+	s.index.mu.RLock()
+	defer s.index.mu.RUnlock()
+	found := make(map[string]bool)
+	if g := s.index.graphs[s.namespace]; g != nil {
+		for _, id := range ids {
+			if _, ok := g.ids[id]; ok {
+				found[id] = true
+			}
+		}
+	}
+	return found, nil
+}
+
+// Search returns the approximate k most cosine-similar entries, highest score
+// first.
+func (s *HNSWVectorStore) Search(ctx context.Context, vector []float32, k int) ([]Hit, error) {
+	return s.SearchFiltered(ctx, vector, k, nil)
+}
+
+// SearchFiltered returns the approximate k most cosine-similar entries
+// matching filter.
+func (s *HNSWVectorStore) SearchFiltered(_ context.Context, vector []float32, k int, filter Filter) ([]Hit, error) {
+	if k <= 0 {
+		k = 5
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	x := s.index
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	g := x.graphs[s.namespace]
+	if g == nil || len(g.ids) == 0 {
+		return nil, nil
+	}
+	query := normalize(vector)
+	ef := max(x.cfg.EfSearch, k)
+	for {
+		hits := g.search(query, ef, k, filter)
+		if len(hits) >= k || ef >= len(g.nodes) {
+			return hits, nil
+		}
+		ef *= 2
+	}
+}
+
+// Delete removes entries by ID.
+func (s *HNSWVectorStore) Delete(_ context.Context, ids ...string) error {
+	x := s.index
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	g := x.graphs[s.namespace]
+	if g == nil {
+		return nil
+	}
+	for _, id := range ids {
+		if i, ok := g.ids[id]; ok {
+			g.tombstone(i)
+		}
+	}
+	x.compact(s.namespace)
+	return nil
+}
+
+// DeleteByFilter removes every entry in the namespace matching filter.
+func (s *HNSWVectorStore) DeleteByFilter(_ context.Context, filter Filter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	x := s.index
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	g := x.graphs[s.namespace]
+	if g == nil {
+		return nil
+	}
+	for _, i := range g.ids {
+		if filter.Match(g.nodes[i].meta) {
+			g.tombstone(i)
+		}
+	}
+	x.compact(s.namespace)
+	return nil
+}
+
+// ---- graph construction ----
+
+func (x *hnswIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-x.rng.Float64()) * x.levelMult))
+}
+
+func (x *hnswIndex) maxFriends(level int) int {
+	if level == 0 {
+		return 2 * x.cfg.M
+	}
+	return x.cfg.M
+}
+
+// insert links n into g. Callers must hold the write lock.
+func (x *hnswIndex) insert(g *hnswGraph, n *hnswNode) {
+	level := x.randomLevel()
+	n.friends = make([][]int32, level+1)
+	idx := int32(len(g.nodes))
+	g.nodes = append(g.nodes, n)
+	g.ids[n.id] = idx
+	if idx == 0 {
+		g.entry, g.maxLevel = idx, level
+		return
+	}
+
+	entry := []int32{g.entry}
+	for l := g.maxLevel; l > level; l-- {
+		entry = []int32{g.searchLayer(n.vec, entry, 1, l)[0].idx}
+	}
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		candidates := g.searchLayer(n.vec, entry, x.cfg.EfConstruction, l)
+		n.friends[l] = g.selectNeighbours(candidates, x.cfg.M)
+		for _, nb := range n.friends[l] {
+			g.link(nb, idx, l, x.maxFriends(l))
+		}
+		entry = entry[:0]
+		for _, c := range candidates {
+			entry = append(entry, c.idx)
+		}
+	}
+	if level > g.maxLevel {
+		g.entry, g.maxLevel = idx, level
+	}
+}
+
+// compact rebuilds a namespace's graph from its live entries once tombstones
+// outnumber them. Callers must hold the write lock.
+func (x *hnswIndex) compact(namespace string) {
+	g := x.graphs[namespace]
+	if g.deleted == 0 || g.deleted*2 <= len(g.nodes) {
+		return
+	}
+	fresh := &hnswGraph{ids: make(map[string]int32, len(g.ids))}
+	for _, n := range g.nodes {
+		if !n.deleted {
+			x.insert(fresh, &hnswNode{id: n.id, text: n.text, vec: n.vec, meta: n.meta})
+		}
+	}
+	x.graphs[namespace] = fresh
+}
+
+// link adds a directed edge from -> to on level, pruning from's neighbour list
+// back to maxLen with the selection heuristic.
+func (g *hnswGraph) link(from, to int32, level, maxLen int) {
+	node := g.nodes[from]
+	friends := append(node.friends[level], to)
+	if len(friends) > maxLen {
+		candidates := make([]hnswCandidate, len(friends))
+		for i, f := range friends {
+			candidates[i] = hnswCandidate{idx: f, sim: similarity(node.vec, g.nodes[f].vec)}
+		}
+		sortCandidates(candidates)
+		friends = g.selectNeighbours(candidates, maxLen)
+	}
+	node.friends[level] = friends
+}
+
+// selectNeighbours picks up to m neighbours from candidates (sorted by
+// descending similarity to the new node) with the HNSW heuristic: a candidate
+// is preferred only if it is closer to the new node than to any neighbour
+// already picked, which keeps edges pointing in diverse directions. Remaining
+// slots are filled with the closest pruned candidates.
+func (g *hnswGraph) selectNeighbours(candidates []hnswCandidate, m int) []int32 {
+	picked := make([]int32, 0, m)
+	var pruned []int32
+	for _, c := range candidates {
+		if len(picked) == m {
+			break
+		}
+		diverse := true
+		for _, p := range picked {
+			if similarity(g.nodes[c.idx].vec, g.nodes[p].vec) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			picked = append(picked, c.idx)
+		} else {
+			pruned = append(pruned, c.idx)
+		}
+	}
+	for _, p := range pruned {
+		if len(picked) == m {
+			break
+		}
+		picked = append(picked, p)
+	}
+	return picked
+}
+
+func (g *hnswGraph) tombstone(i int32) {
+	n := g.nodes[i]
+	delete(g.ids, n.id)
+	n.deleted, n.text, n.meta = true, "", nil
+	g.deleted++
+}
+
+// ---- graph search ----
+
+// search descends to the bottom layer and returns up to k live entries
+// matching filter. Tombstoned nodes still route the search.
+func (g *hnswGraph) search(query []float32, ef, k int, filter Filter) []Hit {
+	entry := []int32{g.entry}
+	for l := g.maxLevel; l > 0; l-- {
+		entry = []int32{g.searchLayer(query, entry, 1, l)[0].idx}
+	}
+	hits := make([]Hit, 0, k)
+	for _, c := range g.searchLayer(query, entry, ef, 0) {
+		if len(hits) == k {
+			break
+		}
+		n := g.nodes[c.idx]
+		if n.deleted || !filter.Match(n.meta) {
+			continue
+		}
+		hits = append(hits, Hit{ID: n.id, Text: n.text, Score: c.sim, Meta: n.meta})
+	}
+	return hits
+}
+
+// searchLayer is the greedy beam search of the HNSW paper: it returns the ef
+// nodes most similar to query reachable on level from entry, sorted by
+// descending similarity.
+func (g *hnswGraph) searchLayer(query []float32, entry []int32, ef, level int) []hnswCandidate {
+	visited := getVisitSet(len(g.nodes))
+	defer visitSets.Put(visited)
+	candidates := &nearestFirst{}
+	results := &farthestFirst{}
+	for _, e := range entry {
+		visited.visit(e)
+		c := hnswCandidate{idx: e, sim: similarity(query, g.nodes[e].vec)}
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.sim < results.items[0].sim {
+			break
+		}
+		for _, f := range g.nodes[c.idx].friends[level] {
+			if !visited.visit(f) {
+				continue
+			}
+			sim := similarity(query, g.nodes[f].vec)
+			if results.Len() < ef || sim > results.items[0].sim {
+				heap.Push(candidates, hnswCandidate{idx: f, sim: sim})
+				heap.Push(results, hnswCandidate{idx: f, sim: sim})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := results.items
+	sortCandidates(out)
+	return out
+}
+
+// ---- helpers ----
+
+// visitSet marks nodes seen during one searchLayer call. Marks are stamped
+// with a generation number so a pooled set is reset in O(1).
+type visitSet struct {
+	marks []uint32
+	gen   uint32
+}
+
+var visitSets = sync.Pool{New: func() any { return &visitSet{} }}
+
+func getVisitSet(n int) *visitSet {
+	v := visitSets.Get().(*visitSet)
+	if len(v.marks) < n {
+		v.marks = make([]uint32, n+n/2)
+		v.gen = 0
+	}
+	v.gen++
+	if v.gen == 0 {
+		clear(v.marks)
+		v.gen = 1
+	}
+	return v
+}
+
+// visit marks i and reports whether it was unvisited.
+func (v *visitSet) visit(i int32) bool {
+	if v.marks[i] == v.gen {
+		return false
+	}
+	v.marks[i] = v.gen
+	return true
+}
+
+type hnswCandidate struct {
+	idx int32
+	sim float64
+}
+
+func sortCandidates(c []hnswCandidate) {
+	sort.Slice(c, func(i, j int) bool { return c[i].sim > c[j].sim })
+}
+
+// nearestFirst is a max-heap on similarity.
+type nearestFirst struct{ items []hnswCandidate }
+
+func (h nearestFirst) Len() int           { return len(h.items) }
+func (h nearestFirst) Less(i, j int) bool { return h.items[i].sim > h.items[j].sim }
+func (h nearestFirst) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *nearestFirst) Push(x any)        { h.items = append(h.items, x.(hnswCandidate)) }
+func (h *nearestFirst) Pop() any          { return popLast(&h.items) }
+
+// farthestFirst is a min-heap on similarity, used to evict the worst result.
+type farthestFirst struct{ items []hnswCandidate }
+
+func (h farthestFirst) Len() int           { return len(h.items) }
+func (h farthestFirst) Less(i, j int) bool { return h.items[i].sim < h.items[j].sim }
+func (h farthestFirst) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *farthestFirst) Push(x any)        { h.items = append(h.items, x.(hnswCandidate)) }
+func (h *farthestFirst) Pop() any          { return popLast(&h.items) }
+
+func popLast(items *[]hnswCandidate) any {
+	old := *items
+	last := old[len(old)-1]
+	*items = old[:len(old)-1]
+	return last
+}
+
+// normalize returns a unit-length copy of v (a zero vector stays zero), so
+// cosine similarity reduces to a dot product.
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	inv := 1 / math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = float32(float64(x) * inv)
+	}
+	return out
+}
+
+// similarity is the dot product of two unit vectors, i.e. their cosine
+// similarity; vectors of different lengths score 0, as in Cosine.
+func similarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	// Four independent accumulators let the CPU pipeline the multiplies;
+	// float32 precision is ample for unit vectors.
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return float64(s0 + s1 + s2 + s3)
+}
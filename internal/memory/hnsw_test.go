@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func randomVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	out := make([][]float32, n)
+	for i := range out {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// recallAt returns the fraction of the exact top-k IDs that approx found.
+func recallAt(exact, approx []Hit) float64 {
+	want := make(map[string]bool, len(exact))
+	for _, h := range exact {
+		want[h.ID] = true
+	}
+	found := 0
+	for _, h := range approx {
+		if want[h.ID] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(exact))
+}
+
+func fillStores(ctx context.Context, vectors [][]float32, stores ...VectorStore) {
+	for i, v := range vectors {
+		id := fmt.Sprintf("doc-%d", i)
+		for _, s := range stores {
+			_ = s.Add(ctx, id, id, v, map[string]any{"shard": i % 4})
+		}
+	}
+}
+
+func TestHNSW_RecallAgainstBruteForce(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(7))
+	exact, approx := NewInMemoryVectorStore(), NewHNSWVectorStore(HNSWConfig{})
+	fillStores(ctx, randomVectors(rng, 2000, 32), exact, approx)
+
+	var total float64
+	queries := randomVectors(rng, 50, 32)
+	for _, q := range queries {
+		want, _ := exact.Search(ctx, q, 10)
+		got, err := approx.Search(ctx, q, 10)
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		if len(got) != 10 {
+			t.Fatalf("want 10 hits, got %d", len(got))
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i].Score > got[i-1].Score {
+				t.Fatalf("hits not sorted by score: %+v", got)
+			}
+		}
+		total += recallAt(want, got)
+	}
+	if recall := total / float64(len(queries)); recall < 0.95 {
+		t.Errorf("recall@10 = %.3f, want >= 0.95", recall)
+	}
+}
+
+func TestHNSW_ReplaceDeleteAndFilter(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(3))
+	s := NewHNSWVectorStore(HNSWConfig{M: 8})
+	fillStores(ctx, randomVectors(rng, 200, 8), s)
+
+	q := []float32{1, 0, 0, 0, 0, 0, 0, 0}
+	_ = s.Add(ctx, "doc-0", "replaced", q, map[string]any{"shard": 9})
+	if s.Len() != 200 {
+		t.Fatalf("replace changed the entry count: %d", s.Len())
+	}
+	hits, _ := s.SearchFiltered(ctx, q, 3, Filter{Eq("shard", 9)})
+	if len(hits) != 1 || hits[0].Text != "replaced" {
+		t.Fatalf("want the replaced entry only, got %+v", hits)
+	}
+
+	hits, _ = s.SearchFiltered(ctx, q, 5, Filter{Eq("shard", 1)})
+	if len(hits) != 5 {
+		t.Fatalf("filtered search returned %d hits", len(hits))
+	}
+	for _, h := range hits {
+		if h.Meta["shard"] != 1 {
+			t.Errorf("hit outside the filter: %+v", h)
+		}
+	}
+
+	if err := s.DeleteByFilter(ctx, Filter{In("shard", 0, 1, 2)}); err != nil {
+		t.Fatalf("delete by filter: %v", err)
+	}
+	if err := s.Delete(ctx, "doc-0"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if s.Len() != 50 {
+		t.Fatalf("want the 50 shard-3 entries left, got %d", s.Len())
+	}
+	hits, _ = s.Search(ctx, q, 100)
+	if len(hits) != 50 {
+		t.Fatalf("search after rebuild returned %d hits, want 50", len(hits))
+	}
+	for _, h := range hits {
+		if h.Meta["shard"] != 3 {
+			t.Errorf("deleted entry returned: %+v", h)
+		}
+	}
+}
+
+func TestHNSW_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	s := NewHNSWVectorStore(HNSWConfig{})
+	a := s.Namespace("a")
+	_ = a.Add(ctx, "x", "in a", []float32{1, 0}, nil)
+	if hits, _ := s.Search(ctx, []float32{1, 0}, 5); len(hits) != 0 {
+		t.Errorf("default namespace sees namespace a: %+v", hits)
+	}
+	if hits, _ := a.Search(ctx, []float32{1, 0}, 5); len(hits) != 1 || hits[0].Text != "in a" {
+		t.Errorf("namespace a search wrong: %+v", hits)
+	}
+	if err := s.Add(ctx, "x", "empty", nil, nil); err == nil {
+		t.Error("expected an error for an empty vector")
+	}
+}
+
+// ---- benchmarks ----
+
+const (
+	benchN   = 20000
+	benchDim = 64
+	benchK   = 10
+)
+
+var (
+	benchOnce    sync.Once
+	benchExact   *InMemoryVectorStore
+	benchHNSW    *HNSWVectorStore
+	benchQueries [][]float32
+)
+
+func benchStores() {
+	benchOnce.Do(func() {
+		ctx := context.Background()
+		rng := rand.New(rand.NewSource(11))
+		benchExact, benchHNSW = NewInMemoryVectorStore(), NewHNSWVectorStore(HNSWConfig{})
+		fillStores(ctx, randomVectors(rng, benchN, benchDim), benchExact, benchHNSW)
+		benchQueries = randomVectors(rng, 200, benchDim)
+	})
+}
+
+// BenchmarkVectorSearch compares brute-force and HNSW search latency; the
+// HNSW case also reports its recall@10 against the exact results.
+//
+//	go test ./internal/memory -run '^$' -bench VectorSearch
+func BenchmarkVectorSearch(b *testing.B) {
+	benchStores()
+	ctx := context.Background()
+
+	b.Run("BruteForce", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = benchExact.Search(ctx, benchQueries[i%len(benchQueries)], benchK)
+		}
+	})
+	for _, ef := range []int{16, 64, 256} {
+		b.Run(fmt.Sprintf("HNSW/efSearch=%d", ef), func(b *testing.B) {
+			benchHNSW.index.cfg.EfSearch = ef
+			var recall float64
+			for _, q := range benchQueries {
+				want, _ := benchExact.Search(ctx, q, benchK)
+				got, _ := benchHNSW.Search(ctx, q, benchK)
+				recall += recallAt(want, got)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = benchHNSW.Search(ctx, benchQueries[i%len(benchQueries)], benchK)
+			}
+			b.ReportMetric(recall/float64(len(benchQueries)), "recall@10")
+		})
+	}
+}
+
+func BenchmarkHNSWAdd(b *testing.B) {
+	ctx := context.Background()
+	vectors := randomVectors(rand.New(rand.NewSource(5)), b.N, benchDim)
+	s := NewHNSWVectorStore(HNSWConfig{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = s.Add(ctx, fmt.Sprintf("doc-%d", i), "", vectors[i], nil)
+	}
+}
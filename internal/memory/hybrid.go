@@ -0,0 +1,325 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fusion methods for HybridConfig.Fusion.
+const (
+	// FusionRRF ranks by reciprocal rank fusion: each source contributes
+	// weight / (RRFK + rank), so only positions matter, not raw scores.
+	FusionRRF = "rrf"
+	// FusionWeighted min-max normalizes each source's scores to [0, 1] and
+	// takes their weighted mean.
+	FusionWeighted = "weighted"
+)
+
+// Score sources reported in Hit.Scores.
+const (
+	ScoreVector  = "vector"
+	ScoreKeyword = "keyword"
+	ScoreFused   = "fused"
+	ScoreRerank  = "rerank"
+)
+
+// Reranker reorders search hits by their relevance to query, typically with a
+// model that reads query and text together (an LLM or a cross-encoder). It
+// returns the hits most relevant first, with Score and Scores[ScoreRerank]
+// set to the relevance score.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error)
+}
+
+// HybridConfig tunes a HybridRetriever.
+type HybridConfig struct {
+	// Fusion is FusionRRF (default) or FusionWeighted.
+	Fusion string
+	// RRFK is the rank offset of reciprocal rank fusion. Defaults to 60.
+	RRFK int
+	// VectorWeight and KeywordWeight weigh the two sources in either fusion
+	// method. Both default to 1.
+	VectorWeight  float64
+	KeywordWeight float64
+	// Candidates is how many hits are fetched from each source before fusion.
+	// Defaults to 4×k, at least 20.
+	Candidates int
+	// Reranker, when set, reorders the best RerankDepth fused hits before the
+	// top k are returned; hits below that depth follow in fused order.
+	Reranker Reranker
+	// RerankDepth defaults to the number of candidates.
+	RerankDepth int
+}
+
+// HybridRetriever searches a VectorStore and a KeywordIndex together and fuses
+// the two rankings, so a query matches both paraphrases and exact terms.
+// Either source may be nil, e.g. to add reranking to a vector-only pipeline.
+type HybridRetriever struct {
+	vectors  VectorStore
+	embedder Embedder
+	keywords KeywordIndex
+	cfg      HybridConfig
+}
+
+// NewHybridRetriever builds a HybridRetriever, applying default config values.
+func NewHybridRetriever(vectors VectorStore, embedder Embedder, keywords KeywordIndex, cfg HybridConfig) *HybridRetriever {
+	if cfg.Fusion != FusionWeighted {
+		cfg.Fusion = FusionRRF
+	}
+	if cfg.RRFK <= 0 {
+		cfg.RRFK = 60
+	}
+	if cfg.VectorWeight <= 0 {
+		cfg.VectorWeight = 1
+	}
+	if cfg.KeywordWeight <= 0 {
+		cfg.KeywordWeight = 1
+	}
+	return &HybridRetriever{vectors: vectors, embedder: embedder, keywords: keywords, cfg: cfg}
+}
+
+// Add embeds text into the vector store and indexes it for keyword search
+// under the same id.
+func (r *HybridRetriever) Add(ctx context.Context, id, text string, meta map[string]any) error {
+	if r.vectors != nil {
+		vector, err := r.embedder.Embed(ctx, text)
+		if err != nil {
+			return err
+		}
+		if err := r.vectors.Add(ctx, id, text, vector, meta); err != nil {
+			return err
+		}
+	}
+	if r.keywords != nil {
+		return r.keywords.Add(ctx, id, text, meta)
+	}
+	return nil
+}
+
+// Delete removes entries by ID from both sources.
+func (r *HybridRetriever) Delete(ctx context.Context, ids ...string) error {
+	if r.vectors != nil {
+		if err := r.vectors.Delete(ctx, ids...); err != nil {
+			return err
+		}
+	}
+	if r.keywords != nil {
+		return r.keywords.Delete(ctx, ids...)
+	}
+	return nil
+}
+
+// DeleteByFilter removes every entry matching filter from both sources.
+func (r *HybridRetriever) DeleteByFilter(ctx context.Context, filter Filter) error {
+	if r.vectors != nil {
+		if err := r.vectors.DeleteByFilter(ctx, filter); err != nil {
+			return err
+		}
+	}
+	if r.keywords != nil {
+		return r.keywords.DeleteByFilter(ctx, filter)
+	}
+	return nil
+}
+
+// Search returns the k best hits for query matching filter (nil matches
+// everything). Each hit's Scores holds its score from every source that
+// returned it, the fused score and, when reranked, the rerank score; Score is
+// the score the hits are ordered by.
+func (r *HybridRetriever) Search(ctx context.Context, query string, k int, filter Filter) ([]Hit, error) {
+	if k <= 0 {
+		k = 5
+	}
+	if r.vectors == nil && r.keywords == nil {
+		return nil, errors.New("memory: hybrid retriever has no vector store or keyword index")
+	}
+	candidates := r.cfg.Candidates
+	if candidates <= 0 {
+		candidates = max(4*k, 20)
+	}
+	candidates = max(candidates, k)
+
+	var vectorHits, keywordHits []Hit
+	if r.vectors != nil {
+		vector, err := r.embedder.Embed(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if vectorHits, err = r.vectors.SearchFiltered(ctx, vector, candidates, filter); err != nil {
+			return nil, err
+		}
+	}
+	if r.keywords != nil {
+		var err error
+		if keywordHits, err = r.keywords.Search(ctx, query, candidates, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	hits := r.fuse(vectorHits, keywordHits)
+	if r.cfg.Reranker != nil && len(hits) > 0 {
+		depth := r.cfg.RerankDepth
+		if depth <= 0 {
+			depth = candidates
+		}
+		depth = min(depth, len(hits))
+		reranked, err := r.cfg.Reranker.Rerank(ctx, query, hits[:depth])
+		if err != nil {
+			return nil, fmt.Errorf("memory: rerank: %w", err)
+		}
+		// Hits below the rerank depth keep their fused order after the
+		// reranked head.
+		hits = append(reranked[:len(reranked):len(reranked)], hits[depth:]...)
+	}
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// fuse merges the per-source rankings into one list ordered by fused score.
+func (r *HybridRetriever) fuse(vectorHits, keywordHits []Hit) []Hit {
+	merged := make(map[string]*Hit)
+	fused := make(map[string]float64)
+	add := func(source string, hits []Hit, weight float64) {
+		lo, hi := scoreRange(hits)
+		for rank, h := range hits {
+			m, ok := merged[h.ID]
+			if !ok {
+				m = &Hit{ID: h.ID, Text: h.Text, Meta: h.Meta, Scores: make(map[string]float64, 3)}
+				merged[h.ID] = m
+			}
+			m.Scores[source] = h.Score
+			switch r.cfg.Fusion {
+			case FusionWeighted:
+				norm := 1.0
+				if hi > lo {
+					norm = (h.Score - lo) / (hi - lo)
+				}
+				fused[h.ID] += weight * norm / (r.cfg.VectorWeight + r.cfg.KeywordWeight)
+			default:
+				fused[h.ID] += weight / float64(r.cfg.RRFK+rank+1)
+			}
+		}
+	}
+	add(ScoreVector, vectorHits, r.cfg.VectorWeight)
+	add(ScoreKeyword, keywordHits, r.cfg.KeywordWeight)
+
+	hits := make([]Hit, 0, len(merged))
+	for id, m := range merged {
+		m.Score = fused[id]
+		m.Scores[ScoreFused] = m.Score
+		hits = append(hits, *m)
+	}
+	sortHits(hits)
+	return hits
+}
+
+func scoreRange(hits []Hit) (lo, hi float64) {
+	for i, h := range hits {
+		if i == 0 || h.Score < lo {
+			lo = h.Score
+		}
+		if i == 0 || h.Score > hi {
+			hi = h.Score
+		}
+	}
+	return lo, hi
+}
+
+// ApplyRerank returns hits reordered by scores (hit index -> relevance), with
+// Score and Scores[ScoreRerank] set. Hits without a score keep their relative
+// order after the scored ones. Reranker implementations share it.
+func ApplyRerank(hits []Hit, scores map[int]float64) []Hit {
+	type ranked struct {
+		hit    Hit
+		scored bool
+	}
+	out := make([]ranked, len(hits))
+	for i, h := range hits {
+		s, ok := scores[i]
+		if ok {
+			breakdown := make(map[string]float64, len(h.Scores)+1)
+			for k, v := range h.Scores {
+				breakdown[k] = v
+			}
+			breakdown[ScoreRerank] = s
+			h.Score, h.Scores = s, breakdown
+		}
+		out[i] = ranked{hit: h, scored: ok}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].scored != out[j].scored {
+			return out[i].scored
+		}
+		return out[i].scored && out[i].hit.Score > out[j].hit.Score
+	})
+	reranked := make([]Hit, len(out))
+	for i, r := range out {
+		reranked[i] = r.hit
+	}
+	return reranked
+}
+
+// ---- LLM reranker ----
+
+const rerankerSystemPrompt = `You judge how relevant passages are to a search query.
+You will be given a QUERY and numbered PASSAGES. Score every passage from 0 (unrelated) to 10 (directly answers the query); exact matches of names, codes and identifiers in the query count as strong evidence.
+Reply with ONLY a JSON array with one object per passage, e.g. [{"index":0,"score":7},{"index":1,"score":2}].`
+
+type llmReranker struct {
+	complete CompletionFunc
+}
+
+// NewLLMReranker returns a Reranker that asks a language model, through
+// complete, to score each hit against the query. Scores are scaled to [0, 1].
+func NewLLMReranker(complete CompletionFunc) Reranker {
+	return &llmReranker{complete: complete}
+}
+
+func (r *llmReranker) Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error) {
+	if len(hits) == 0 {
+		return hits, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "QUERY:\n%s\n\nPASSAGES:\n", query)
+	for i, h := range hits {
+		fmt.Fprintf(&b, "[%d] %s\n", i, strings.ReplaceAll(h.Text, "\n", " "))
+	}
+	reply, err := r.complete(ctx, rerankerSystemPrompt, b.String())
+	if err != nil {
+		return nil, err
+	}
+	scores, err := parseRerankScores(reply, len(hits))
+	if err != nil {
+		return nil, err
+	}
+	return ApplyRerank(hits, scores), nil
+}
+
+// parseRerankScores decodes the JSON array in a model reply, tolerating prose
+// or code fences around it, and scales the scores to [0, 1].
+func parseRerankScores(reply string, n int) (map[int]float64, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("memory: reranker reply has no JSON array: %q", reply)
+	}
+	var items []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &items); err != nil {
+		return nil, fmt.Errorf("memory: decoding rerank scores: %w", err)
+	}
+	scores := make(map[int]float64, len(items))
+	for _, it := range items {
+		if it.Index >= 0 && it.Index < n {
+			scores[it.Index] = min(max(it.Score, 0), 10) / 10
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Order SKU-1234-B shipped, v2.1 (see docs/faq).")
+	want := []string{"order", "sku-1234-b", "sku", "1234", "b", "shipped", "v2.1", "v2", "1", "see", "docs/faq", "docs", "faq"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestBM25_RanksExactTerms(t *testing.T) {
+	ctx := context.Background()
+	x := NewBM25Index(BM25Config{})
+	_ = x.Add(ctx, "a", "The SKU-1234-B widget is out of stock", map[string]any{"lang": "en"})
+	_ = x.Add(ctx, "b", "Widget stock levels are updated hourly", map[string]any{"lang": "en"})
+	_ = x.Add(ctx, "c", "SKU 9999 replaces SKU 1234", map[string]any{"lang": "de"})
+	_ = x.Add(ctx, "d", "Shipping takes three days", nil)
+
+	hits, err := x.Search(ctx, "sku-1234-b", 5, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != "a" || hits[1].ID != "c" {
+		t.Fatalf("want a then c, got %+v", hits)
+	}
+
+	hits, _ = x.Search(ctx, "widget stock", 5, Filter{Eq("lang", "en")})
+	if len(hits) != 2 {
+		t.Fatalf("want both widget docs, got %+v", hits)
+	}
+	if hits, _ := x.Search(ctx, "refund", 5, nil); len(hits) != 0 {
+		t.Errorf("unrelated query matched: %+v", hits)
+	}
+
+	_ = x.Add(ctx, "a", "Replaced text about shipping", nil)
+	if hits, _ := x.Search(ctx, "sku-1234-b", 5, nil); len(hits) != 1 || hits[0].ID != "c" {
+		t.Errorf("replaced entry still indexed under old terms: %+v", hits)
+	}
+	_ = x.DeleteByFilter(ctx, Filter{Eq("lang", "de")})
+	_ = x.Delete(ctx, "d")
+	if x.Len() != 2 {
+		t.Errorf("want 2 entries left, got %d", x.Len())
+	}
+	if hits, _ := x.Search(ctx, "shipping", 5, nil); len(hits) != 1 || hits[0].ID != "a" {
+		t.Errorf("want only the replaced entry, got %+v", hits)
+	}
+}
+
+func newTestHybrid(t *testing.T, cfg HybridConfig) *HybridRetriever {
+	t.Helper()
+	ctx := context.Background()
+	r := NewHybridRetriever(NewInMemoryVectorStore(), testVocab, NewBM25Index(BM25Config{}), cfg)
+	docs := map[string]string{
+		"refund-policy": "Refund policy: a refund is issued within 14 days",
+		"refund-faq":    "Refund questions and refund timelines",
+		"sku":           "Part AX-77 is compatible with the refund kiosk",
+		"shipping":      "Shipping is free over 50 euros",
+	}
+	for id, text := range docs {
+		if err := r.Add(ctx, id, text, map[string]any{"kind": "doc"}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	return r
+}
+
+func TestHybrid_FusesBothSources(t *testing.T) {
+	ctx := context.Background()
+	for _, fusion := range []string{FusionRRF, FusionWeighted} {
+		r := newTestHybrid(t, HybridConfig{Fusion: fusion})
+		// "refund" favours the refund docs by vector; "ax-77" only matches
+		// lexically, and the fused ranking must surface it first.
+		hits, err := r.Search(ctx, "AX-77 refund", 3, Filter{Eq("kind", "doc")})
+		if err != nil {
+			t.Fatalf("%s: search: %v", fusion, err)
+		}
+		if len(hits) != 3 || hits[0].ID != "sku" {
+			t.Fatalf("%s: want the AX-77 doc first, got %+v", fusion, hits)
+		}
+		top := hits[0].Scores
+		if _, ok := top[ScoreVector]; !ok {
+			t.Errorf("%s: missing vector score: %v", fusion, top)
+		}
+		if top[ScoreKeyword] <= 0 || top[ScoreFused] != hits[0].Score {
+			t.Errorf("%s: bad score breakdown: %v (score %v)", fusion, top, hits[0].Score)
+		}
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Score > hits[i-1].Score {
+				t.Errorf("%s: hits not ordered: %+v", fusion, hits)
+			}
+		}
+	}
+}
+
+func TestHybrid_DeleteRemovesFromBothSources(t *testing.T) {
+	ctx := context.Background()
+	r := newTestHybrid(t, HybridConfig{})
+	_ = r.Delete(ctx, "sku")
+	hits, _ := r.Search(ctx, "AX-77", 5, nil)
+	for _, h := range hits {
+		if h.ID == "sku" {
+			t.Fatalf("deleted entry returned: %+v", h)
+		}
+	}
+}
+
+func TestHybrid_LLMRerank(t *testing.T) {
+	ctx := context.Background()
+	var prompt string
+	reranker := NewLLMReranker(func(_ context.Context, system, p string) (string, error) {
+		prompt = p
+		// Score whichever passage mentions shipping highest.
+		var parts []string
+		for i, line := range strings.Split(strings.SplitN(p, "PASSAGES:\n", 2)[1], "\n") {
+			if line == "" {
+				continue
+			}
+			score := "1"
+			if strings.Contains(line, "Shipping") {
+				score = "9"
+			}
+			parts = append(parts, `{"index":`+strconv.Itoa(i)+`,"score":`+score+`}`)
+		}
+		return "```json\n[" + strings.Join(parts, ",") + "]\n```", nil
+	})
+	r := newTestHybrid(t, HybridConfig{Reranker: reranker})
+	hits, err := r.Search(ctx, "refund shipping", 2, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(prompt, "QUERY:\nrefund shipping") {
+		t.Errorf("query missing from prompt: %q", prompt)
+	}
+	if len(hits) != 2 || hits[0].ID != "shipping" || hits[0].Score != 0.9 || hits[0].Scores[ScoreRerank] != 0.9 {
+		t.Fatalf("want the shipping doc reranked first, got %+v", hits)
+	}
+	if _, ok := hits[0].Scores[ScoreFused]; !ok {
+		t.Errorf("rerank dropped the fused score: %v", hits[0].Scores)
+	}
+
+	failing := NewHybridRetriever(nil, nil, NewBM25Index(BM25Config{}), HybridConfig{
+		Reranker: NewLLMReranker(func(context.Context, string, string) (string, error) {
+			return "", errors.New("model down")
+		}),
+	})
+	_ = failing.Add(ctx, "x", "refund", nil)
+	if _, err := failing.Search(ctx, "refund", 1, nil); err == nil {
+		t.Error("expected the rerank error")
+	}
+}
+
+// reverseReranker reverses the hits it is given and records how many.
+type reverseReranker struct{ seen int }
+
+func (r *reverseReranker) Rerank(_ context.Context, _ string, hits []Hit) ([]Hit, error) {
+	r.seen = len(hits)
+	out := make([]Hit, len(hits))
+	for i, h := range hits {
+		out[len(hits)-1-i] = h
+	}
+	return out, nil
+}
+
+func TestHybrid_RerankDepthBelowK(t *testing.T) {
+	ctx := context.Background()
+	fused, err := newTestHybrid(t, HybridConfig{}).Search(ctx, "refund policy", 4, nil)
+	if err != nil || len(fused) != 4 {
+		t.Fatalf("fused search: %+v, %v", fused, err)
+	}
+
+	reranker := &reverseReranker{}
+	r := newTestHybrid(t, HybridConfig{Reranker: reranker, RerankDepth: 2})
+	hits, err := r.Search(ctx, "refund policy", 4, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if reranker.seen != 2 {
+		t.Errorf("reranker saw %d hits, want 2", reranker.seen)
+	}
+	var got, want []string
+	for _, h := range hits {
+		got = append(got, h.ID)
+	}
+	for _, i := range []int{1, 0, 2, 3} {
+		want = append(want, fused[i].ID)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want reranked head then fused tail %v, got %v", want, got)
+	}
+}
+
+func TestApplyRerank_KeepsUnscoredHitsLast(t *testing.T) {
+	hits := []Hit{{ID: "a", Score: 3}, {ID: "b", Score: 2}, {ID: "c", Score: 1}, {ID: "d"}}
+	got := ApplyRerank(hits, map[int]float64{1: 0.2, 3: 0.8})
+	var ids []string
+	for _, h := range got {
+		ids = append(ids, h.ID)
+	}
+	if strings.Join(ids, ",") != "d,b,a,c" {
+		t.Errorf("order = %v, want d,b,a,c", ids)
+	}
+	if hits[1].Score != 2 || hits[1].Scores != nil {
+		t.Error("ApplyRerank modified its input")
+	}
+}
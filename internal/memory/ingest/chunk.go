@@ -0,0 +1,277 @@
+package ingest
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk is one piece of a document, embedded and stored as a single entry.
+type Chunk struct {
+	Text string
+	// Heading is the Markdown heading path the chunk sits under, e.g.
+	// "Setup > Linux"; empty when the chunker does not track headings.
+	Heading string
+}
+
+// Chunker splits a document's text into chunks. Sizes are measured in
+// characters (runes).
+type Chunker interface {
+	Chunk(text string) []Chunk
+}
+
+// ---- fixed-size chunker ----
+
+// FixedChunker cuts text into windows of at most Size characters, each
+// starting Overlap characters before the previous one ended. Cuts prefer the
+// last whitespace in the second half of a window, so words are not split.
+type FixedChunker struct {
+	// Size defaults to 1000.
+	Size int
+	// Overlap defaults to Size/10; set a negative value for none.
+	Overlap int
+}
+
+// Chunk implements Chunker.
+func (c FixedChunker) Chunk(text string) []Chunk {
+	size, overlap := c.Size, c.Overlap
+	if size <= 0 {
+		size = 1000
+	}
+	switch {
+	case overlap < 0:
+		overlap = 0
+	case overlap == 0:
+		overlap = size / 10
+	}
+	overlap = min(overlap, size/2)
+
+	var chunks []Chunk
+	for _, piece := range splitFixed([]rune(text), size, overlap) {
+		chunks = append(chunks, Chunk{Text: piece})
+	}
+	return chunks
+}
+
+func splitFixed(runes []rune, size, overlap int) []string {
+	var out []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			for i := end; i > start+size/2; i-- {
+				if unicode.IsSpace(runes[i-1]) {
+					end = i
+					break
+				}
+			}
+		}
+		if piece := strings.TrimSpace(string(runes[start:end])); piece != "" {
+			out = append(out, piece)
+		}
+		if end == len(runes) {
+			break
+		}
+		next := max(end-overlap, start+1)
+		// Start the overlap on a word boundary.
+		for i := next; i < end && next > start+1; i++ {
+			if unicode.IsSpace(runes[i-1]) {
+				next = i
+				break
+			}
+		}
+		start = next
+	}
+	return out
+}
+
+// ---- sentence chunker ----
+
+// SentenceChunker packs whole sentences into chunks of at most MaxSize
+// characters. Sentences end at '.', '!' or '?' followed by whitespace, or at a
+// blank line; a single sentence longer than MaxSize is split with a
+// FixedChunker.
+type SentenceChunker struct {
+	// MaxSize defaults to 1000.
+	MaxSize int
+	// OverlapSentences repeats the last n sentences of a chunk at the start
+	// of the next. Defaults to 0.
+	OverlapSentences int
+}
+
+// Chunk implements Chunker.
+func (c SentenceChunker) Chunk(text string) []Chunk {
+	maxSize := c.MaxSize
+	if maxSize <= 0 {
+		maxSize = 1000
+	}
+	var chunks []Chunk
+	var window []string
+	for _, s := range Sentences(text) {
+		n := utf8.RuneCountInString(s)
+		if n > maxSize {
+			if len(window) > 0 {
+				chunks = append(chunks, Chunk{Text: strings.Join(window, " ")})
+			}
+			window = nil
+			for _, piece := range splitFixed([]rune(s), maxSize, 0) {
+				chunks = append(chunks, Chunk{Text: piece})
+			}
+			continue
+		}
+		if len(window) > 0 && joinedLen(window)+1+n > maxSize {
+			chunks = append(chunks, Chunk{Text: strings.Join(window, " ")})
+			keep := min(max(c.OverlapSentences, 0), len(window)-1)
+			window = append([]string(nil), window[len(window)-keep:]...)
+			for len(window) > 0 && joinedLen(window)+1+n > maxSize {
+				window = window[1:]
+			}
+		}
+		window = append(window, s)
+	}
+	if len(window) > 0 {
+		chunks = append(chunks, Chunk{Text: strings.Join(window, " ")})
+	}
+	return chunks
+}
+
+// joinedLen is the length in runes of sentences joined by single spaces.
+func joinedLen(sentences []string) int {
+	n := len(sentences) - 1
+	for _, s := range sentences {
+		n += utf8.RuneCountInString(s)
+	}
+	return n
+}
+
+// Sentences splits text into trimmed sentences, collapsing internal
+// whitespace. Blank lines always end a sentence, so headings and list items
+// without punctuation stay separate.
+func Sentences(text string) []string {
+	var out []string
+	var b strings.Builder
+	emit := func() {
+		if s := strings.Join(strings.Fields(b.String()), " "); s != "" {
+			out = append(out, s)
+		}
+		b.Reset()
+	}
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' && i+1 < len(runes) && strings.TrimSpace(string(runes[i+1:nextNewline(runes, i+1)])) == "" {
+			emit()
+			continue
+		}
+		b.WriteRune(r)
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			emit()
+		}
+	}
+	emit()
+	return out
+}
+
+// nextNewline returns the index of the next '\n' at or after i, or len(runes).
+func nextNewline(runes []rune, i int) int {
+	for ; i < len(runes); i++ {
+		if runes[i] == '\n' {
+			return i
+		}
+	}
+	return len(runes)
+}
+
+// ---- markdown chunker ----
+
+// MarkdownChunker splits Markdown at ATX headings ("#" to "######"), so each
+// chunk covers one section and records its heading path. Sections longer than
+// MaxSize are split further with a SentenceChunker. Headings inside fenced
+// code blocks are ignored.
+type MarkdownChunker struct {
+	// MaxSize defaults to 1500.
+	MaxSize int
+}
+
+// Chunk implements Chunker.
+func (c MarkdownChunker) Chunk(text string) []Chunk {
+	maxSize := c.MaxSize
+	if maxSize <= 0 {
+		maxSize = 1500
+	}
+	var chunks []Chunk
+	var path []string // heading text by level - 1
+	var body strings.Builder
+	heading, hasBody := "", false
+	flush := func() {
+		section := strings.TrimSpace(body.String())
+		body.Reset()
+		if !hasBody {
+			return // nothing under this heading but subsections
+		}
+		hasBody = false
+		if utf8.RuneCountInString(section) <= maxSize {
+			chunks = append(chunks, Chunk{Text: section, Heading: heading})
+			return
+		}
+		for _, piece := range (SentenceChunker{MaxSize: maxSize}).Chunk(section) {
+			piece.Heading = heading
+			chunks = append(chunks, piece)
+		}
+	}
+
+	fence := ""
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+		} else if level, title := atxHeading(line); level > 0 {
+			flush()
+			for len(path) < level {
+				path = append(path, "")
+			}
+			path = append(path[:level-1], title)
+			heading = joinHeadings(path)
+			body.WriteString(line)
+			body.WriteByte('\n')
+			continue
+		}
+		if trimmed != "" {
+			hasBody = true
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return chunks
+}
+
+// atxHeading parses "## Title" lines, returning level 0 for anything else.
+func atxHeading(line string) (int, string) {
+	if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+		return 0, ""
+	}
+	line = strings.TrimLeft(line, " ")
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, ""
+	}
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	return level, title
+}
+
+func joinHeadings(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, p := range path {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
@@ -0,0 +1,262 @@
+// Package ingest feeds documents into a memory.VectorStore for
+// retrieval-augmented agents: loaders extract text from plain text, Markdown,
+// HTML and PDF files, chunkers split it, and an Ingester embeds the chunks in
+// batches and stores them under content-hash IDs, so re-ingesting a document
+// only embeds and writes what changed.
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// Chunk metadata keys set by the Ingester, next to the document's own Meta.
+const (
+	MetaSource      = "source"
+	MetaFormat      = "format"
+	MetaChunk       = "chunk"
+	MetaHeading     = "heading"
+	MetaContentHash = "content_hash"
+)
+
+// Config tunes an Ingester.
+type Config struct {
+	// Chunker splits every document. When nil, Markdown and HTML documents
+	// use a MarkdownChunker and everything else a SentenceChunker.
+	Chunker Chunker
+	// BatchSize is how many chunks are embedded before they are written to
+	// the store. Defaults to 32.
+	BatchSize int
+	// Concurrency caps the embedding requests in flight when the embedder
+	// embeds one text per call. Defaults to 4. A memory.BatchEmbedder gets
+	// each batch in one EmbedBatch call and applies its own limits.
+	Concurrency int
+	// Loaders replaces the built-in loader of a format in IngestFiles,
+	// keyed by format (FormatPDF, ...). The built-in PDFLoader is a small
+	// dependency-free parser; to read complex or untrusted PDFs, plug in a
+	// maintained PDF library here.
+	Loaders map[string]Loader
+}
+
+// Result reports what an Ingest call wrote.
+type Result struct {
+	Documents int
+	Chunks    int
+	// Embedded counts the chunks that were new and had to be embedded; the
+	// rest were already stored under the same content hash.
+	Embedded int
+}
+
+// Ingester chunks, embeds and stores documents.
+type Ingester struct {
+	store    memory.VectorStore
+	embedder memory.Embedder
+	cfg      Config
+}
+
+// New builds an Ingester, applying default config values.
+func New(store memory.VectorStore, embedder memory.Embedder, cfg Config) *Ingester {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 32
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	return &Ingester{store: store, embedder: embedder, cfg: cfg}
+}
+
+// IngestFiles loads each file (picking the loader from its extension, or
+// from Config.Loaders) and ingests it with its path as the source.
+func (in *Ingester) IngestFiles(ctx context.Context, paths ...string) (Result, error) {
+	var total Result
+	for _, path := range paths {
+		doc, err := loadFile(path, in.cfg.Loaders)
+		if err != nil {
+			return total, err
+		}
+		res, err := in.Ingest(ctx, doc)
+		total.Documents += res.Documents
+		total.Chunks += res.Chunks
+		total.Embedded += res.Embedded
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Ingest chunks, embeds and stores docs. Each chunk's ID is a hash of its
+// source, heading and text. When the store is a memory.VectorLookup, chunks
+// already stored under their ID are neither embedded nor written again (their
+// MetaChunk position is the one they were first stored with); other stores
+// get every chunk upserted. Once a document is stored, its chunks from
+// earlier versions that no longer appear are deleted.
+func (in *Ingester) Ingest(ctx context.Context, docs ...Document) (Result, error) {
+	var res Result
+	for _, doc := range docs {
+		if doc.Source == "" {
+			return res, errors.New("ingest: document has no source")
+		}
+		n, embedded, err := in.ingest(ctx, doc)
+		if err != nil {
+			return res, fmt.Errorf("ingest: %s: %w", doc.Source, err)
+		}
+		res.Documents++
+		res.Chunks += n
+		res.Embedded += embedded
+	}
+	return res, nil
+}
+
+// Remove deletes every chunk stored for source.
+func (in *Ingester) Remove(ctx context.Context, source string) error {
+	return in.store.DeleteByFilter(ctx, memory.Filter{memory.Eq(MetaSource, source)})
+}
+
+type pendingChunk struct {
+	id   string
+	text string
+	meta map[string]any
+}
+
+func (in *Ingester) ingest(ctx context.Context, doc Document) (int, int, error) {
+	chunker := in.cfg.Chunker
+	if chunker == nil {
+		chunker = defaultChunker(doc.Format)
+	}
+	var chunks []pendingChunk
+	seen := make(map[string]bool)
+	for _, c := range chunker.Chunk(doc.Text) {
+		if c.Text == "" {
+			continue
+		}
+		id := chunkID(doc.Source, c)
+		if seen[id] {
+			continue // repeated boilerplate
+		}
+		seen[id] = true
+		meta := make(map[string]any, len(doc.Meta)+5)
+		for k, v := range doc.Meta {
+			meta[k] = v
+		}
+		meta[MetaSource] = doc.Source
+		meta[MetaFormat] = doc.Format
+		meta[MetaChunk] = len(chunks)
+		meta[MetaContentHash] = id
+		if c.Heading != "" {
+			meta[MetaHeading] = c.Heading
+		}
+		chunks = append(chunks, pendingChunk{id: id, text: c.Text, meta: meta})
+	}
+
+	fresh, err := in.unstored(ctx, chunks)
+	if err != nil {
+		return 0, 0, err
+	}
+	for start := 0; start < len(fresh); start += in.cfg.BatchSize {
+		batch := fresh[start:min(start+in.cfg.BatchSize, len(fresh))]
+		vectors, err := in.embed(ctx, batch)
+		if err != nil {
+			return 0, 0, err
+		}
+		for i, c := range batch {
+			if err := in.store.Add(ctx, c.id, c.text, vectors[i], c.meta); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	// Drop chunks of earlier versions of the document.
+	current := make([]any, len(chunks))
+	for i, c := range chunks {
+		current[i] = c.id
+	}
+	stale := memory.Filter{memory.Eq(MetaSource, doc.Source)}
+	if len(current) > 0 {
+		stale = append(stale, memory.Nin(MetaContentHash, current...))
+	}
+	if err := in.store.DeleteByFilter(ctx, stale); err != nil {
+		return 0, 0, err
+	}
+	return len(chunks), len(fresh), nil
+}
+
+// unstored returns the chunks whose IDs the store does not hold yet, or every
+// chunk when the store cannot tell.
+func (in *Ingester) unstored(ctx context.Context, chunks []pendingChunk) ([]pendingChunk, error) {
+	lookup, ok := in.store.(memory.VectorLookup)
+	if !ok || len(chunks) == 0 {
+		return chunks, nil
+	}
+	ids := make([]string, len(chunks))
+	for i, c := range chunks {
+		ids[i] = c.id
+	}
+	existing, err := lookup.Existing(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	fresh := make([]pendingChunk, 0, len(chunks))
+	for _, c := range chunks {
+		if !existing[c.id] {
+			fresh = append(fresh, c)
+		}
+	}
+	return fresh, nil
+}
+
+// embed embeds a batch in one EmbedBatch call when the embedder supports it,
+// else with up to Concurrency Embed calls in flight.
+func (in *Ingester) embed(ctx context.Context, batch []pendingChunk) ([][]float32, error) {
+	if b, ok := in.embedder.(memory.BatchEmbedder); ok {
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			texts[i] = c.text
+		}
+		vectors, err := b.EmbedBatch(ctx, texts)
+		if err == nil && len(vectors) != len(batch) {
+			err = fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(batch))
+		}
+		return vectors, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	vectors := make([][]float32, len(batch))
+	sem := make(chan struct{}, in.cfg.Concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i, c := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, text string) {
+			defer func() { <-sem; wg.Done() }()
+			v, err := in.embedder.Embed(ctx, text)
+			if err != nil {
+				once.Do(func() { firstErr = err; cancel() })
+				return
+			}
+			vectors[i] = v
+		}(i, c.text)
+	}
+	wg.Wait()
+	return vectors, firstErr
+}
+
+func defaultChunker(format string) Chunker {
+	if format == FormatMarkdown || format == FormatHTML {
+		return MarkdownChunker{}
+	}
+	return SentenceChunker{}
+}
+
+func chunkID(source string, c Chunk) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + c.Heading + "\x00" + c.Text))
+	return hex.EncodeToString(sum[:16])
+}
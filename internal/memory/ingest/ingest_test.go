@@ -0,0 +1,364 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+func TestFixedChunker_OverlapsOnWordBoundaries(t *testing.T) {
+	words := make([]string, 100)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	chunks := FixedChunker{Size: 60, Overlap: 15}.Chunk(strings.Join(words, " "))
+	if len(chunks) < 8 {
+		t.Fatalf("want the text split into many chunks, got %d", len(chunks))
+	}
+	valid := make(map[string]bool, len(words))
+	for _, w := range words {
+		valid[w] = true
+	}
+	for i, c := range chunks {
+		if len([]rune(c.Text)) > 60 {
+			t.Errorf("chunk %d longer than Size: %q", i, c.Text)
+		}
+		for _, w := range strings.Fields(c.Text) {
+			if !valid[w] {
+				t.Errorf("chunk %d split a word: %q", i, c.Text)
+			}
+		}
+		if i > 0 && !strings.Contains(chunks[i-1].Text+" ", strings.Fields(c.Text)[0]+" ") {
+			t.Errorf("chunk %d does not overlap the previous one: %q / %q", i, chunks[i-1].Text, c.Text)
+		}
+	}
+	if last := chunks[len(chunks)-1].Text; !strings.HasSuffix(last, "w99") {
+		t.Errorf("text not fully covered: %q", last)
+	}
+}
+
+func TestSentenceChunker(t *testing.T) {
+	text := "First sentence here. Second one!\nThird, still going? Fourth.\n\nA heading\n\nLast line"
+	got := Sentences(text)
+	want := []string{"First sentence here.", "Second one!", "Third, still going?", "Fourth.", "A heading", "Last line"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("Sentences = %q", got)
+	}
+
+	chunks := SentenceChunker{MaxSize: 40, OverlapSentences: 1}.Chunk(text)
+	var texts []string
+	for _, c := range chunks {
+		if len([]rune(c.Text)) > 40 {
+			t.Errorf("chunk over MaxSize: %q", c.Text)
+		}
+		texts = append(texts, c.Text)
+	}
+	wantChunks := []string{
+		"First sentence here. Second one!",
+		"Second one! Third, still going? Fourth.",
+		"Fourth. A heading Last line",
+	}
+	if strings.Join(texts, "|") != strings.Join(wantChunks, "|") {
+		t.Errorf("chunks:\n got %q\nwant %q", texts, wantChunks)
+	}
+
+	long := SentenceChunker{MaxSize: 10}.Chunk("Short. " + strings.Repeat("x", 25) + ". End.")
+	if len(long) != 5 || long[0].Text != "Short." || long[4].Text != "End." {
+		t.Errorf("over-long sentence not split: %+v", long)
+	}
+}
+
+func TestMarkdownChunker_TracksHeadings(t *testing.T) {
+	md := "Intro text.\n\n# Guide\n## Install\nRun it.\n```sh\n# not a heading\n```\n## Use\nCall it.\n# FAQ\nAsk.\n"
+	chunks := MarkdownChunker{}.Chunk(md)
+	var got []string
+	for _, c := range chunks {
+		got = append(got, c.Heading+": "+strings.ReplaceAll(c.Text, "\n", "/"))
+	}
+	want := []string{
+		": Intro text.",
+		"Guide > Install: ## Install/Run it./```sh/# not a heading/```",
+		"Guide > Use: ## Use/Call it.",
+		"FAQ: # FAQ/Ask.",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("chunks:\n got %q\nwant %q", got, want)
+	}
+
+	big := MarkdownChunker{MaxSize: 30}.Chunk("# Big\n" + strings.Repeat("A sentence here. ", 6))
+	if len(big) < 3 {
+		t.Fatalf("oversized section not split: %+v", big)
+	}
+	for _, c := range big {
+		if c.Heading != "Big" {
+			t.Errorf("split chunk lost its heading: %+v", c)
+		}
+	}
+}
+
+func TestHTMLLoader(t *testing.T) {
+	page := `<html><head><title> Shipping  FAQ </title><style>p{}</style></head>
+<body><nav><a href="/">Home</a></nav>
+<h1>Shipping</h1><p>We ship   to <b>most</b> countries.<br>Returns are free.</p>
+<script>track()</script>
+<h2>Costs</h2><ul><li>EU: free</li><li>US: $5</li></ul>
+<pre>code  block
+  kept</pre><img alt="map of zones" src="x.png"></body></html>`
+	doc, err := Load(strings.NewReader(page), "https://example.com/faq.html", "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := "Home\n\n# Shipping\n\nWe ship to most countries.\nReturns are free.\n\n## Costs\n\n- EU: free\n- US: $5\n\ncode  block\n  kept\n\nmap of zones\n"
+	if doc.Text != want {
+		t.Errorf("text:\n got %q\nwant %q", doc.Text, want)
+	}
+	if doc.Format != FormatHTML || doc.Meta["title"] != "Shipping FAQ" {
+		t.Errorf("format/title wrong: %q %v", doc.Format, doc.Meta)
+	}
+}
+
+func TestMarkdownLoader_FrontMatter(t *testing.T) {
+	doc, err := MarkdownLoader{}.Load(strings.NewReader("---\ntitle: \"Setup\"\ntags: [a]\n---\n# Setup\r\nBody\r\n"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if doc.Text != "# Setup\nBody\n" || doc.Meta["title"] != "Setup" {
+		t.Errorf("got %q %v", doc.Text, doc.Meta)
+	}
+}
+
+// buildPDF assembles a PDF from numbered object bodies, with a valid xref.
+func buildPDF(objects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func flateStream(dict, content string) string {
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+	return fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", dict, z.Len(), z.String())
+}
+
+func TestPDFLoader(t *testing.T) {
+	cmap := "/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n" +
+		"1 beginbfchar\n<0001> <00DC>\nendbfchar\n" +
+		"1 beginbfrange\n<0002> <0003> <0062>\nendbfrange\n" +
+		"endcmap\nend\nend"
+	page2 := "BT /F2 10 Tf 50 700 Td <000100020003> Tj ET"
+	pdf := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 6 0 R] /Count 2 /Resources << /Font << /F1 4 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		flateStream("", "BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\) world) Tj 0 -14 Td [(Second) -300 (line)] TJ ET"),
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F2 7 0 R >> >> /Contents 9 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Custom /ToUnicode 8 0 R >>",
+		flateStream("", cmap),
+		fmt.Sprintf("<< /Length 10 0 R >>\nstream\n%s\nendstream", page2),
+		fmt.Sprint(len(page2)),
+	})
+
+	doc, err := Load(bytes.NewReader(pdf), "manual.pdf", "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := "Hello (PDF) world\nSecond line\n\nÜbc"; doc.Text != want {
+		t.Errorf("text:\n got %q\nwant %q", doc.Text, want)
+	}
+	if doc.Meta["pages"] != 2 || doc.Format != FormatPDF {
+		t.Errorf("meta/format wrong: %v %q", doc.Meta, doc.Format)
+	}
+
+	if _, err := (PDFLoader{}).Load(strings.NewReader("not a pdf")); err == nil {
+		t.Error("expected an error for a non-PDF")
+	}
+}
+
+func TestPDFLoader_ObjectStreams(t *testing.T) {
+	// Page and font dictionaries packed in a compressed object stream.
+	packed := []string{
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 3 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	header := fmt.Sprintf("4 0 5 %d ", len(packed[0])+1)
+	body := packed[0] + "\n" + packed[1]
+	pdf := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+		flateStream("", "BT /F1 12 Tf (Packed page) Tj ET"),
+		"null", // replaced by the object stream entry below
+		"null",
+		flateStream(fmt.Sprintf("/Type /ObjStm /N 2 /First %d", len(header)), header+body),
+	})
+	// Objects 4 and 5 must come from the object stream, so drop the
+	// placeholders.
+	pdf = bytes.Replace(pdf, []byte("4 0 obj\nnull\nendobj\n"), nil, 1)
+	pdf = bytes.Replace(pdf, []byte("5 0 obj\nnull\nendobj\n"), nil, 1)
+
+	doc, err := (PDFLoader{}).Load(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if doc.Text != "Packed page" {
+		t.Errorf("text = %q", doc.Text)
+	}
+}
+
+// countingEmbedder embeds text as letter counts and counts its calls.
+type countingEmbedder struct{ calls atomic.Int64 }
+
+func (e *countingEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	e.calls.Add(1)
+	v := make([]float32, 27)
+	for _, r := range strings.ToLower(text) {
+		if r >= 'a' && r <= 'z' {
+			v[r-'a']++
+		}
+	}
+	v[26] = 1
+	return v, nil
+}
+
+func TestIngester_IdempotentUpserts(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryVectorStore()
+	emb := &countingEmbedder{}
+	in := New(store, emb, Config{BatchSize: 2, Concurrency: 2})
+
+	v1 := Document{Source: "kb/faq.md", Format: FormatMarkdown, Meta: map[string]any{"team": "support"},
+		Text: "# Refunds\nRefunds take 5 days.\n# Shipping\nShipping is free.\n# Contact\nMail us.\n"}
+	res, err := in.Ingest(ctx, v1)
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	if res.Documents != 1 || res.Chunks != 3 || res.Embedded != 3 || emb.calls.Load() != 3 {
+		t.Fatalf("result %+v after %d embeds", res, emb.calls.Load())
+	}
+	hits, _ := store.SearchFiltered(ctx, make([]float32, 27), 10, memory.Filter{memory.Eq(MetaSource, "kb/faq.md")})
+	if len(hits) != 3 {
+		t.Fatalf("want 3 stored chunks, got %d", len(hits))
+	}
+	for _, h := range hits {
+		if h.Meta["team"] != "support" || h.Meta[MetaFormat] != FormatMarkdown || h.Meta[MetaHeading] == "" || h.Meta[MetaContentHash] != h.ID {
+			t.Errorf("chunk metadata incomplete: %+v", h.Meta)
+		}
+	}
+
+	// Same content again: nothing embedded, nothing duplicated.
+	res, _ = in.Ingest(ctx, v1)
+	if res.Chunks != 3 || res.Embedded != 0 || emb.calls.Load() != 3 {
+		t.Fatalf("re-ingest embedded stored chunks: %+v after %d embeds", res, emb.calls.Load())
+	}
+	if hits, _ := store.Search(ctx, make([]float32, 27), 10); len(hits) != 3 {
+		t.Fatalf("re-ingest duplicated chunks: %d", len(hits))
+	}
+
+	// Edited document: the changed and removed sections are replaced.
+	v2 := v1
+	v2.Text = "# Refunds\nRefunds take 3 days.\n# Shipping\nShipping is free.\n"
+	if res, err = in.Ingest(ctx, v2); err != nil {
+		t.Fatalf("ingest v2: %v", err)
+	}
+	if res.Embedded != 1 || emb.calls.Load() != 4 {
+		t.Errorf("want only the edited chunk embedded, got %+v after %d embeds", res, emb.calls.Load())
+	}
+	hits, _ = store.Search(ctx, make([]float32, 27), 10)
+	var texts []string
+	for _, h := range hits {
+		texts = append(texts, h.Text)
+	}
+	joined := strings.Join(texts, "|")
+	if len(hits) != 2 || !strings.Contains(joined, "3 days") || strings.Contains(joined, "5 days") || strings.Contains(joined, "Mail us") {
+		t.Fatalf("stale chunks left after update: %q", texts)
+	}
+
+	if err := in.Remove(ctx, "kb/faq.md"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if hits, _ := store.Search(ctx, make([]float32, 27), 10); len(hits) != 0 {
+		t.Errorf("remove left %d chunks", len(hits))
+	}
+}
+
+func TestIngester_IngestFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("One fact. Another fact."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewInMemoryVectorStore()
+	res, err := New(store, &countingEmbedder{}, Config{}).IngestFiles(context.Background(), path)
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	if res.Documents != 1 || res.Chunks != 1 {
+		t.Errorf("result %+v", res)
+	}
+	if _, err := New(store, &countingEmbedder{}, Config{}).IngestFiles(context.Background(), filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestIngester_IngestFilesWithCustomLoader(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manual.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.7 not parsed in-tree"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pdfLoader := LoaderFunc(func(r io.Reader) (Document, error) {
+		return Document{Text: "Text from the plugged-in PDF loader."}, nil
+	})
+	store := memory.NewInMemoryVectorStore()
+	in := New(store, &countingEmbedder{}, Config{Loaders: map[string]Loader{FormatPDF: pdfLoader}})
+	if _, err := in.IngestFiles(context.Background(), path); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	hits, _ := store.Search(context.Background(), make([]float32, 27), 10)
+	if len(hits) != 1 || hits[0].Text != "Text from the plugged-in PDF loader." || hits[0].Meta[MetaFormat] != FormatPDF {
+		t.Errorf("hits = %+v", hits)
+	}
+}
+
+func TestIngester_BatchEmbedderWithCache(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryVectorStore()
+	emb := &countingEmbedder{}
+	in := New(store, memory.NewCachingEmbedder(emb, 0), Config{BatchSize: 2})
+	doc := Document{Source: "kb/faq.md", Format: FormatMarkdown,
+		Text: "# Refunds\nRefunds take 5 days.\n# Shipping\nShipping is free.\n# Contact\nMail us.\n"}
+	if _, err := in.Ingest(ctx, doc); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	doc.Text = strings.Replace(doc.Text, "Mail us.", "Call us.", 1)
+	res, err := in.Ingest(ctx, doc)
+	if err != nil {
+		t.Fatalf("re-ingest: %v", err)
+	}
+	if res.Chunks != 3 || emb.calls.Load() != 4 {
+		t.Errorf("want only the edited chunk re-embedded (4 embeds), got %d for %+v", emb.calls.Load(), res)
+	}
+}
@@ -0,0 +1,342 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Document formats, recorded in Document.Format and chunk metadata.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+)
+
+// Document is a loaded source ready to be chunked and indexed.
+type Document struct {
+	// Source identifies the document (a path or URL). Its chunks are stored
+	// under it, so re-ingesting the same source replaces them.
+	Source string
+	Format string
+	Text   string
+	// Meta is copied onto every chunk, e.g. {"title": ..., "team": ...}.
+	Meta map[string]any
+}
+
+// Loader extracts the text of one document format. HTML is converted to
+// Markdown-style text (headings as "#" lines) so the MarkdownChunker can split
+// it by section.
+type Loader interface {
+	Load(r io.Reader) (Document, error)
+}
+
+// LoaderFunc adapts a function to a Loader, e.g. one wrapping a PDF library.
+type LoaderFunc func(r io.Reader) (Document, error)
+
+// Load implements Loader.
+func (f LoaderFunc) Load(r io.Reader) (Document, error) { return f(r) }
+
+// LoaderFor returns the built-in loader for format.
+func LoaderFor(format string) (Loader, error) {
+	switch format {
+	case FormatText:
+		return TextLoader{}, nil
+	case FormatMarkdown:
+		return MarkdownLoader{}, nil
+	case FormatHTML:
+		return HTMLLoader{}, nil
+	case FormatPDF:
+		return PDFLoader{}, nil
+	}
+	return nil, fmt.Errorf("ingest: unsupported format %q", format)
+}
+
+// FormatOf guesses a document format from a path or URL extension; anything
+// unrecognized is plain text.
+func FormatOf(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".mdx":
+		return FormatMarkdown
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML
+	case ".pdf":
+		return FormatPDF
+	}
+	return FormatText
+}
+
+// Load reads a document of the given format from r and records source on it.
+// An empty format is guessed from source.
+func Load(r io.Reader, source, format string) (Document, error) {
+	return load(r, source, format, nil)
+}
+
+// load is Load, preferring the loaders in overrides to the built-in ones.
+func load(r io.Reader, source, format string, overrides map[string]Loader) (Document, error) {
+	if format == "" {
+		format = FormatOf(source)
+	}
+	loader, ok := overrides[format]
+	if !ok {
+		var err error
+		if loader, err = LoaderFor(format); err != nil {
+			return Document{}, err
+		}
+	}
+	doc, err := loader.Load(r)
+	if err != nil {
+		return Document{}, fmt.Errorf("ingest: loading %s: %w", source, err)
+	}
+	doc.Source, doc.Format = source, format
+	return doc, nil
+}
+
+// LoadFile reads a file, picking the loader from its extension.
+func LoadFile(path string) (Document, error) {
+	return loadFile(path, nil)
+}
+
+func loadFile(path string, overrides map[string]Loader) (Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, err
+	}
+	defer f.Close()
+	return load(f, path, "", overrides)
+}
+
+// ---- text and markdown ----
+
+// TextLoader loads UTF-8 plain text, normalizing line endings.
+type TextLoader struct{}
+
+// Load implements Loader.
+func (TextLoader) Load(r io.Reader) (Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, err
+	}
+	if !utf8.Valid(data) {
+		return Document{}, fmt.Errorf("text is not valid UTF-8")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return Document{Text: text}, nil
+}
+
+// MarkdownLoader loads Markdown as is, so headings are kept for the
+// MarkdownChunker. A leading YAML front matter block is dropped; its "title"
+// line, if any, becomes the document title.
+type MarkdownLoader struct{}
+
+// Load implements Loader.
+func (MarkdownLoader) Load(r io.Reader) (Document, error) {
+	doc, err := TextLoader{}.Load(r)
+	if err != nil {
+		return doc, err
+	}
+	if !strings.HasPrefix(doc.Text, "---\n") {
+		return doc, nil
+	}
+	end := strings.Index(doc.Text[4:], "\n---")
+	if end < 0 {
+		return doc, nil
+	}
+	front := doc.Text[4 : 4+end]
+	rest := doc.Text[4+end+4:]
+	doc.Text = strings.TrimLeft(strings.TrimPrefix(rest, "-"), "\n")
+	for _, line := range strings.Split(front, "\n") {
+		if title, ok := strings.CutPrefix(line, "title:"); ok {
+			doc.Meta = map[string]any{"title": strings.Trim(strings.TrimSpace(title), `"'`)}
+		}
+	}
+	return doc, nil
+}
+
+// ---- html ----
+
+// HTMLLoader extracts the readable text of an HTML page. Scripts, styles and
+// other non-content elements are skipped, headings become Markdown "#" lines,
+// list items become "- " lines and <pre> blocks keep their whitespace. The
+// <title> is recorded as the document title.
+type HTMLLoader struct{}
+
+// Load implements Loader.
+func (HTMLLoader) Load(r io.Reader) (Document, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return Document{}, err
+	}
+	w := &htmlText{}
+	w.walk(root)
+	doc := Document{Text: w.String()}
+	if w.title != "" {
+		doc.Meta = map[string]any{"title": w.title}
+	}
+	return doc, nil
+}
+
+type htmlText struct {
+	b     strings.Builder
+	title string
+	pre   int
+}
+
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Object: true, atom.Canvas: true,
+	atom.Button: true, atom.Select: true, atom.Form: true,
+}
+
+var htmlBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Nav: true,
+	atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Tr: true, atom.Blockquote: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Hr: true, atom.Address: true, atom.Details: true,
+	atom.Summary: true,
+}
+
+var htmlHeadings = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+func (w *htmlText) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if htmlSkipped[n.DataAtom] {
+			return
+		}
+		switch n.DataAtom {
+		case atom.Title:
+			if w.title == "" {
+				w.title = strings.Join(strings.Fields(textOf(n)), " ")
+			}
+			return
+		case atom.Head:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.DataAtom == atom.Title {
+					w.walk(c)
+				}
+			}
+			return
+		case atom.Br:
+			w.newlines(1)
+			return
+		case atom.Img:
+			for _, a := range n.Attr {
+				if a.Key == "alt" && strings.TrimSpace(a.Val) != "" {
+					w.text(" " + a.Val + " ")
+				}
+			}
+			return
+		}
+		if level, ok := htmlHeadings[n.DataAtom]; ok {
+			w.newlines(2)
+			w.b.WriteString(strings.Repeat("#", level) + " " + strings.Join(strings.Fields(textOf(n)), " "))
+			w.newlines(2)
+			return
+		}
+		switch {
+		case n.DataAtom == atom.Li:
+			w.newlines(1)
+			w.b.WriteString("- ")
+		case n.DataAtom == atom.Pre:
+			w.newlines(2)
+			w.pre++
+			defer func() { w.pre--; w.newlines(2) }()
+		case n.DataAtom == atom.Td || n.DataAtom == atom.Th:
+			w.text(" ")
+		case htmlBlocks[n.DataAtom]:
+			w.newlines(2)
+			defer w.newlines(2)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// text appends inline text, collapsing whitespace outside <pre>.
+func (w *htmlText) text(s string) {
+	if w.pre > 0 {
+		w.b.WriteString(s)
+		return
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			w.space()
+		}
+		return
+	}
+	if s[0] == ' ' || s[0] == '\n' || s[0] == '\t' || s[0] == '\r' {
+		w.space()
+	}
+	w.b.WriteString(strings.Join(fields, " "))
+	if last := s[len(s)-1]; last == ' ' || last == '\n' || last == '\t' || last == '\r' {
+		w.space()
+	}
+}
+
+func (w *htmlText) space() {
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") && !strings.HasSuffix(s, "- ") {
+		w.b.WriteByte(' ')
+	}
+}
+
+// newlines ends the current line and ensures n line breaks before what
+// follows.
+func (w *htmlText) newlines(n int) {
+	s := w.b.String()
+	if s == "" {
+		return
+	}
+	trimmed := strings.TrimRight(s, " ")
+	have := len(trimmed) - len(strings.TrimRight(trimmed, "\n"))
+	if len(trimmed) != len(s) {
+		w.b.Reset()
+		w.b.WriteString(trimmed)
+	}
+	for ; have < n; have++ {
+		w.b.WriteByte('\n')
+	}
+}
+
+func (w *htmlText) String() string {
+	return strings.TrimSpace(w.b.String()) + "\n"
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		if n.Type == html.ElementNode && htmlSkipped[n.DataAtom] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
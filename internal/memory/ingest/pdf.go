@@ -0,0 +1,1005 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDFLoader extracts the text of a PDF page by page, with no dependencies.
+// It reads classic and compressed (object stream) files, FlateDecode,
+// ASCIIHex and ASCII85 streams, and maps glyphs to text through each font's
+// ToUnicode CMap, falling back to Latin-1 for simple fonts. Encrypted PDFs and
+// scanned pages (images without a text layer) yield no text.
+//
+// It exists so ingestion works without pulling a PDF library into every
+// build, and caps decompressed sizes, object nesting and CMap sizes so a
+// hostile file cannot exhaust memory. It is not a complete PDF reader: for
+// complex or untrusted documents, wrap a maintained PDF library in a Loader
+// and set it as Config.Loaders[FormatPDF].
+type PDFLoader struct{}
+
+// Load implements Loader. The page count is recorded as Meta["pages"].
+func (PDFLoader) Load(r io.Reader) (Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, err
+	}
+	f, err := parsePDF(data)
+	if err != nil {
+		return Document{}, err
+	}
+	pages := f.pages()
+	texts := make([]string, 0, len(pages))
+	for _, p := range pages {
+		if t := strings.TrimSpace(f.pageText(p)); t != "" {
+			texts = append(texts, t)
+		}
+	}
+	return Document{
+		Text: cleanPDFText(strings.Join(texts, "\n\n")),
+		Meta: map[string]any{"pages": len(pages)},
+	}, nil
+}
+
+// ---- objects ----
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfDict    map[pdfName]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// ---- lexer and parser ----
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+var errPDFEOF = errors.New("pdf: unexpected end of data")
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the next primitive token: numbers (int64 or float64), names,
+// strings, keywords, or the structural keywords "[", "]", "<<" and ">>".
+func (l *pdfLexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errPDFEOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(decodeNameEscapes(string(l.data[start:l.pos]))), nil
+	case c == '(':
+		return l.literalString()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		return l.hexString()
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return pdfKeyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.data) && (l.data[l.pos] == '.' || (l.data[l.pos] >= '0' && l.data[l.pos] <= '9')) {
+			l.pos++
+		}
+		s := string(l.data[start:l.pos])
+		if !strings.Contains(s, ".") {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		f, _ := strconv.ParseFloat(s, 64)
+		return f, nil
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++ // stray delimiter
+	}
+	return pdfKeyword(l.data[start:l.pos]), nil
+}
+
+func decodeNameEscapes(s string) string {
+	if !strings.Contains(s, "#") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func (l *pdfLexer) literalString() (string, error) {
+	l.pos++ // (
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return string(b), nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return "", errPDFEOF
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return "", errPDFEOF
+}
+
+func (l *pdfLexer) hexString() (string, error) {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	if l.pos >= len(l.data) {
+		return "", errPDFEOF
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return "", fmt.Errorf("pdf: bad hex string: %w", err)
+	}
+	return string(out), nil
+}
+
+// object parses one complete object, resolving "n g R" references, arrays,
+// dictionaries and (when followed by the stream keyword) stream bodies.
+func (l *pdfLexer) object() (any, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	return l.objectFrom(tok, 0)
+}
+
+func (l *pdfLexer) objectFrom(tok any, depth int) (any, error) {
+	if depth > 64 {
+		return nil, errors.New("pdf: objects nested too deeply")
+	}
+	switch t := tok.(type) {
+	case int64:
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(int64); ok {
+				if kw, err := l.token(); err == nil && kw == pdfKeyword("R") {
+					return pdfRef{num: int(t), gen: int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	case pdfKeyword:
+		switch t {
+		case "[":
+			var arr []any
+			for {
+				next, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				if next == pdfKeyword("]") {
+					return arr, nil
+				}
+				v, err := l.objectFrom(next, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				next, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				if next == pdfKeyword(">>") {
+					break
+				}
+				key, ok := next.(pdfName)
+				if !ok {
+					continue // tolerate junk keys
+				}
+				vt, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := l.objectFrom(vt, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			}
+			return l.maybeStream(dict), nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return tok, nil
+}
+
+// maybeStream attaches the stream body when dict is followed by "stream".
+func (l *pdfLexer) maybeStream(dict pdfDict) any {
+	save := l.pos
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		l.pos = save
+		return dict
+	}
+	l.pos += len("stream")
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+	if n, ok := dict["Length"].(int64); ok && n >= 0 && start+int(n) <= len(l.data) {
+		end := start + int(n)
+		rest := bytes.TrimLeft(l.data[end:min(end+32, len(l.data))], " \r\n\t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			l.pos = end
+			l.skipSpace()
+			l.pos += len("endstream")
+			return pdfStream{dict: dict, raw: l.data[start:end]}
+		}
+	}
+	// Indirect or wrong /Length: find the end marker instead.
+	i := bytes.Index(l.data[start:], []byte("endstream"))
+	if i < 0 {
+		l.pos = len(l.data)
+		return pdfStream{dict: dict, raw: l.data[start:]}
+	}
+	end := start + i
+	l.pos = end + len("endstream")
+	raw := l.data[start:end]
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return pdfStream{dict: dict, raw: raw}
+}
+
+// ---- file ----
+
+type pdfFile struct {
+	objects map[int]any
+	root    pdfRef
+}
+
+var (
+	pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfRootRef   = regexp.MustCompile(`/Root\s+(\d+)\s+(\d+)\s+R`)
+	pdfEncrypt   = regexp.MustCompile(`/Encrypt\s*(\d+\s+\d+\s+R|<<)`)
+)
+
+// parsePDF indexes every object in the file by scanning for "n g obj"
+// headers rather than trusting the xref table, which is often damaged;
+// later definitions (incremental updates) win.
+func parsePDF(data []byte) (*pdfFile, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, errors.New("pdf: missing %PDF header")
+	}
+	if pdfEncrypt.Match(data) {
+		return nil, errors.New("pdf: encrypted documents are not supported")
+	}
+	f := &pdfFile{objects: make(map[int]any)}
+	l := &pdfLexer{data: data}
+	for pos := 0; pos < len(data); {
+		m := pdfObjHeader.FindSubmatchIndex(data[pos:])
+		if m == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+m[2] : pos+m[3]]))
+		l.pos = pos + m[1]
+		obj, err := l.object()
+		if err != nil {
+			pos += m[1]
+			continue
+		}
+		f.objects[num] = obj
+		pos = l.pos
+	}
+	f.expandObjectStreams()
+	if ms := pdfRootRef.FindAllSubmatch(data, -1); len(ms) > 0 {
+		last := ms[len(ms)-1]
+		num, _ := strconv.Atoi(string(last[1]))
+		gen, _ := strconv.Atoi(string(last[2]))
+		f.root = pdfRef{num: num, gen: gen}
+	}
+	return f, nil
+}
+
+// expandObjectStreams adds the objects packed in /Type /ObjStm streams that
+// are not also defined directly.
+func (f *pdfFile) expandObjectStreams() {
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		s, ok := f.objects[num].(pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := f.decode(s)
+		if err != nil {
+			continue
+		}
+		n, _ := s.dict["N"].(int64)
+		first, _ := s.dict["First"].(int64)
+		if first <= 0 || int(first) > len(data) {
+			continue
+		}
+		header := &pdfLexer{data: data[:first]}
+		for i := int64(0); i < n; i++ {
+			objNum, err1 := header.token()
+			offset, err2 := header.token()
+			on, ok1 := objNum.(int64)
+			off, ok2 := offset.(int64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if _, exists := f.objects[int(on)]; exists {
+				continue
+			}
+			body := &pdfLexer{data: data, pos: int(first + off)}
+			if body.pos >= len(data) {
+				continue
+			}
+			if obj, err := body.object(); err == nil {
+				f.objects[int(on)] = obj
+			}
+		}
+	}
+}
+
+func (f *pdfFile) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[ref.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(v any) pdfDict {
+	switch d := f.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+func (f *pdfFile) array(v any) []any {
+	a, _ := f.resolve(v).([]any)
+	return a
+}
+
+// ---- stream filters ----
+
+func (f *pdfFile) decode(s pdfStream) ([]byte, error) {
+	data := s.raw
+	filters := f.resolve(s.dict["Filter"])
+	params := f.resolve(s.dict["DecodeParms"])
+	var names []any
+	var parms []any
+	switch v := filters.(type) {
+	case pdfName:
+		names, parms = []any{v}, []any{params}
+	case []any:
+		names = v
+		parms, _ = params.([]any)
+	}
+	for i, n := range names {
+		var p pdfDict
+		if i < len(parms) {
+			p = f.dict(parms[i])
+		}
+		var err error
+		switch f.resolve(n) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflate(data)
+			if err == nil {
+				data, err = unpredict(data, p)
+			}
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			var l pdfLexer
+			l.data = append(append([]byte("<"), bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">"))...), '>')
+			var s string
+			s, err = l.hexString()
+			data = []byte(s)
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("pdf: unsupported filter %v", n)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// maxInflated caps the decoded size of one stream, so a small file cannot
+// expand into gigabytes.
+const maxInflated = 64 << 20
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	out, err := io.ReadAll(io.LimitReader(zr, maxInflated))
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil // keep what decoded from truncated streams
+}
+
+// unpredict reverses PNG predictors (Predictor >= 10) applied before Flate.
+func unpredict(data []byte, p pdfDict) ([]byte, error) {
+	predictor, _ := p["Predictor"].(int64)
+	if predictor < 10 {
+		return data, nil
+	}
+	colors, bpc, columns := int64(1), int64(8), int64(1)
+	if v, ok := p["Colors"].(int64); ok {
+		colors = v
+	}
+	if v, ok := p["BitsPerComponent"].(int64); ok {
+		bpc = v
+	}
+	if v, ok := p["Columns"].(int64); ok {
+		columns = v
+	}
+	if colors <= 0 || colors > 32 || bpc <= 0 || bpc > 16 || columns <= 0 || columns > int64(len(data)) {
+		return nil, errors.New("pdf: bad predictor parameters")
+	}
+	bpp := max(int(colors*bpc/8), 1)
+	rowLen := int((colors*bpc*columns + 7) / 8)
+	if rowLen > len(data) {
+		return nil, errors.New("pdf: bad predictor parameters")
+	}
+	prev := make([]byte, rowLen)
+	var out []byte
+	for len(data) >= rowLen+1 {
+		kind, row := data[0], append([]byte(nil), data[1:rowLen+1]...)
+		data = data[rowLen+1:]
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch kind {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// ---- pages ----
+
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages lists the pages in order by walking the page tree from the catalog,
+// falling back to every /Type /Page object when the tree is unusable. Each
+// object is visited once, so cyclic or repeated kids cannot blow up the walk.
+func (f *pdfFile) pages() []pdfPage {
+	var out []pdfPage
+	visited := make(map[int]bool)
+	var walk func(node any, inherited pdfDict, depth int)
+	walk = func(node any, inherited pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		d := f.dict(node)
+		if d == nil || depth > 64 {
+			return
+		}
+		res := inherited
+		if r := f.dict(d["Resources"]); r != nil {
+			res = r
+		}
+		switch d["Type"] {
+		case pdfName("Pages"):
+			for _, kid := range f.array(d["Kids"]) {
+				walk(kid, res, depth+1)
+			}
+		case pdfName("Page"):
+			out = append(out, pdfPage{dict: d, resources: res})
+		}
+	}
+	if catalog := f.dict(f.root); catalog != nil {
+		walk(catalog["Pages"], nil, 0)
+	}
+	if len(out) > 0 {
+		return out
+	}
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if d := f.dict(f.objects[num]); d != nil && d["Type"] == pdfName("Page") {
+			out = append(out, pdfPage{dict: d, resources: f.dict(d["Resources"])})
+		}
+	}
+	return out
+}
+
+func (f *pdfFile) pageText(p pdfPage) string {
+	var content []byte
+	contents := f.resolve(p.dict["Contents"])
+	streams := []any{contents}
+	if arr, ok := contents.([]any); ok {
+		streams = arr
+	}
+	for _, c := range streams {
+		if s, ok := f.resolve(c).(pdfStream); ok {
+			if data, err := f.decode(s); err == nil {
+				content = append(content, data...)
+				content = append(content, '\n')
+			}
+		}
+	}
+	w := &pdfTextWriter{}
+	f.runContent(content, p.resources, w, 0)
+	return w.b.String()
+}
+
+// ---- fonts ----
+
+type pdfFont struct {
+	codeLen int               // bytes per character code
+	toUni   map[uint32]string // from the ToUnicode CMap
+	simple  bool              // single-byte font: fall back to Latin-1
+}
+
+func (f *pdfFile) font(resources pdfDict, name pdfName, cache map[pdfName]*pdfFont) *pdfFont {
+	if font, ok := cache[name]; ok {
+		return font
+	}
+	font := &pdfFont{codeLen: 1, simple: true}
+	if d := f.dict(f.dict(resources["Font"])[name]); d != nil {
+		if d["Subtype"] == pdfName("Type0") {
+			font.codeLen, font.simple = 2, false
+		}
+		if s, ok := f.resolve(d["ToUnicode"]).(pdfStream); ok {
+			if data, err := f.decode(s); err == nil {
+				font.toUni, font.codeLen = parseToUnicode(data, font.codeLen)
+			}
+		}
+	}
+	cache[name] = font
+	return font
+}
+
+// maxCMapEntries caps the mappings read from one ToUnicode CMap.
+const maxCMapEntries = 1 << 17
+
+// parseToUnicode reads the bfchar and bfrange mappings of a ToUnicode CMap.
+func parseToUnicode(data []byte, codeLen int) (map[uint32]string, int) {
+	m := make(map[uint32]string)
+	l := &pdfLexer{data: data}
+	var operands []any
+	for {
+		tok, err := l.token()
+		if err != nil {
+			break
+		}
+		kw, ok := tok.(pdfKeyword)
+		if !ok || kw == "[" || kw == "<<" {
+			v, err := l.objectFrom(tok, 0)
+			if err != nil {
+				break
+			}
+			operands = append(operands, v)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			if len(operands) >= 1 {
+				if s, ok := operands[0].(string); ok && len(s) > 0 {
+					codeLen = len(s)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(string)
+				dst, ok2 := operands[i+1].(string)
+				if ok1 && ok2 {
+					m[codeOf(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(string)
+				hi, ok2 := operands[i+1].(string)
+				if !ok1 || !ok2 || codeOf(hi) < codeOf(lo) || codeOf(hi)-codeOf(lo) > 0xffff {
+					continue
+				}
+				start, end := codeOf(lo), codeOf(hi)
+				if len(m)+int(end-start) > maxCMapEntries {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case string:
+					units := utf16.Decode(utf16Units(dst))
+					if len(units) == 0 {
+						continue
+					}
+					for code := start; code <= end; code++ {
+						r := append([]rune(nil), units...)
+						r[len(r)-1] += rune(code - start)
+						m[code] = string(r)
+					}
+				case []any:
+					for j, d := range dst {
+						if s, ok := d.(string); ok && start+uint32(j) <= end {
+							m[start+uint32(j)] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return m, codeLen
+}
+
+func codeOf(s string) uint32 {
+	var c uint32
+	for i := 0; i < len(s); i++ {
+		c = c<<8 | uint32(s[i])
+	}
+	return c
+}
+
+func utf16Units(s string) []uint16 {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return units
+}
+
+func utf16BE(s string) string {
+	return string(utf16.Decode(utf16Units(s)))
+}
+
+func (font *pdfFont) decode(s string) string {
+	var b strings.Builder
+	n := max(font.codeLen, 1)
+	for i := 0; i+n <= len(s); i += n {
+		code := codeOf(s[i : i+n])
+		if t, ok := font.toUni[code]; ok {
+			b.WriteString(t)
+		} else if font.simple {
+			b.WriteRune(rune(code))
+		}
+	}
+	return b.String()
+}
+
+// ---- content streams ----
+
+type pdfTextWriter struct {
+	b     strings.Builder
+	forms int // form XObjects run so far on this page
+}
+
+// maxFormRuns caps the form XObjects run per page, bounding documents whose
+// forms invoke each other many times over.
+const maxFormRuns = 1000
+
+func (w *pdfTextWriter) newline() {
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		w.b.WriteByte('\n')
+	}
+}
+
+func (w *pdfTextWriter) space() {
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+		w.b.WriteByte(' ')
+	}
+}
+
+func (w *pdfTextWriter) write(s string) {
+	if s != "" {
+		w.b.WriteString(s)
+	}
+}
+
+// runContent interprets the text operators of a content stream, recursing
+// into form XObjects.
+func (f *pdfFile) runContent(content []byte, resources pdfDict, w *pdfTextWriter, depth int) {
+	if depth > 8 {
+		return
+	}
+	fonts := make(map[pdfName]*pdfFont)
+	font := &pdfFont{codeLen: 1, simple: true}
+	var lastY float64
+	var operands []any
+	l := &pdfLexer{data: content}
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return
+		}
+		kw, ok := tok.(pdfKeyword)
+		if !ok || kw == "[" || kw == "<<" {
+			v, err := l.objectFrom(tok, 0)
+			if err != nil {
+				return
+			}
+			operands = append(operands, v)
+			continue
+		}
+		switch kw {
+		case "Tf":
+			if len(operands) >= 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = f.font(resources, name, fonts)
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if pdfNumber(operands[1]) != 0 {
+					w.newline()
+				} else {
+					w.space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				if y := pdfNumber(operands[5]); y != lastY {
+					w.newline()
+					lastY = y
+				} else {
+					w.space()
+				}
+			}
+		case "T*":
+			w.newline()
+		case "Tj":
+			if len(operands) >= 1 {
+				if s, ok := operands[0].(string); ok {
+					w.write(font.decode(s))
+				}
+			}
+		case "'", "\"":
+			w.newline()
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(string); ok {
+					w.write(font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				arr, _ := operands[0].([]any)
+				for _, item := range arr {
+					switch v := item.(type) {
+					case string:
+						w.write(font.decode(v))
+					case int64, float64:
+						if pdfNumber(v) < -200 {
+							w.space()
+						}
+					}
+				}
+			}
+		case "ET":
+			w.space()
+		case "Do":
+			if len(operands) >= 1 {
+				name, _ := operands[0].(pdfName)
+				xobj, ok := f.resolve(f.dict(resources["XObject"])[name]).(pdfStream)
+				if ok && xobj.dict["Subtype"] == pdfName("Form") && w.forms < maxFormRuns {
+					w.forms++
+					if data, err := f.decode(xobj); err == nil {
+						res := resources
+						if r := f.dict(xobj.dict["Resources"]); r != nil {
+							res = r
+						}
+						f.runContent(data, res, w, depth+1)
+					}
+				}
+			}
+		case "ID":
+			// Inline image data: skip to the EI operator.
+			if i := bytes.Index(content[l.pos:], []byte("EI")); i >= 0 {
+				l.pos += i + 2
+			} else {
+				return
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func pdfNumber(v any) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+var pdfExtraNewlines = regexp.MustCompile(`\n{3,}`)
+
+func cleanPDFText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(pdfExtraNewlines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadWithin loads data as a PDF and fails the test if that takes longer than
+// a second; malformed input must fail fast, never hang.
+func loadWithin(t *testing.T, data []byte) (Document, error) {
+	t.Helper()
+	type result struct {
+		doc Document
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		doc, err := (PDFLoader{}).Load(bytes.NewReader(data))
+		done <- result{doc, err}
+	}()
+	select {
+	case r := <-done:
+		return r.doc, r.err
+	case <-time.After(time.Second):
+		t.Fatal("PDF load did not finish")
+		return Document{}, nil
+	}
+}
+
+func TestPDFLoader_MalformedInput(t *testing.T) {
+	valid := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		flateStream("", "BT /F1 12 Tf (Hello) Tj ET"),
+	})
+	cases := map[string][]byte{
+		"header only":      []byte("%PDF-1.7\n"),
+		"unterminated obj": []byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages [ (abc"),
+		"reference cycle": buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"3 0 R",
+			"2 0 R",
+		}),
+		"page tree cycle": buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [2 0 R 2 0 R 2 0 R 2 0 R] >>",
+		}),
+		"recursive form": buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] >>",
+			"<< /Type /Page /Resources << /XObject << /X 4 0 R >> >> /Contents 4 0 R >>",
+			"<< /Subtype /Form /Length 40 >>\nstream\n" + strings.Repeat("/X Do ", 6) + "(loop) Tj\nendstream",
+		}),
+		"huge predictor columns": buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] >>",
+			"<< /Type /Page /Contents 4 0 R >>",
+			flateStream("/DecodeParms << /Predictor 12 /Columns 9999999999999 /Colors 99999 >>", "\x00BT (x) Tj ET"),
+		}),
+		"bad flate": []byte("%PDF-1.4\n1 0 obj << /Filter /FlateDecode /Length 4 >>\nstream\nabcd\nendstream\nendobj\n"),
+		"bad object stream": buildPDF([]string{
+			"<< /Type /ObjStm /N 99999999 /First 3 /Length 6 >>\nstream\n1 0 1 9999 <<\nendstream",
+		}),
+		"unsupported filter": buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] >>",
+			"<< /Type /Page /Contents 4 0 R >>",
+			"<< /Filter /JBIG2Decode /Length 3 >>\nstream\nabc\nendstream",
+		}),
+	}
+	for cut := 16; cut < len(valid); cut += len(valid) / 7 {
+		cases[fmt.Sprintf("truncated at %d", cut)] = valid[:cut]
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _ = loadWithin(t, data)
+		})
+	}
+
+	doc, err := loadWithin(t, cases["recursive form"])
+	if err != nil || !strings.Contains(doc.Text, "loop") {
+		t.Errorf("recursive form: want its text kept, got %q, %v", doc.Text, err)
+	}
+	if _, err := loadWithin(t, []byte("%PDF-1.4\n<< /Encrypt 5 0 R >>")); err == nil {
+		t.Error("expected an error for an encrypted PDF")
+	}
+}
+
+func FuzzPDF(f *testing.F) {
+	f.Add(buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 4 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /ToUnicode 6 0 R >>",
+		flateStream("", "BT /F1 12 Tf 72 720 Td <00010002> Tj 0 -14 Td [(a) -300 (b)] TJ ET"),
+		"<< /Length 60 >>\nstream\nbegincodespacerange <0000> <FFFF> endcodespacerange 1 beginbfrange <0001> <0002> <0041> endbfrange\nendstream",
+	}))
+	f.Add(buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] >>",
+		"<< /Type /Page /Contents 4 0 R >>",
+		"<< /Filter [/ASCIIHexDecode /ASCII85Decode] /Length 8 >>\nstream\n3c7e3e>\nendstream",
+		flateStream("/DecodeParms << /Predictor 12 /Columns 4 >>", "\x02abcd\x02efgh"),
+	}))
+	f.Add([]byte("%PDF-1.4\n1 0 obj (unterminated\\"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := (PDFLoader{}).Load(bytes.NewReader(data))
+		if err == nil && doc.Meta["pages"] == nil {
+			t.Errorf("successful load without a page count")
+		}
+	})
+}
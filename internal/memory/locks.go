@@ -0,0 +1,63 @@
+package memory
+
+import "sync"
+
+// keyedMutex hands out one mutex per key (a session or user ID). Entries are
+// reference-counted and dropped once no goroutine holds or waits on them, so
+// the map does not grow with every session ever seen.
+type keyedMutex struct {
+	mu sync.Mutex
+	m  map[string]*refMutex
+}
+
+type refMutex struct {
+	sync.Mutex
+	refs int
+}
+
+func (k *keyedMutex) acquire(key string) *refMutex {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.m == nil {
+		k.m = make(map[string]*refMutex)
+	}
+	e, ok := k.m[key]
+	if !ok {
+		e = &refMutex{}
+		k.m[key] = e
+	}
+	e.refs++
+	return e
+}
+
+func (k *keyedMutex) release(key string, e *refMutex) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if e.refs--; e.refs == 0 {
+		delete(k.m, key)
+	}
+}
+
+// Lock blocks until key is free and returns the matching unlock function.
+func (k *keyedMutex) Lock(key string) func() {
+	e := k.acquire(key)
+	e.Lock()
+	return func() {
+		e.Unlock()
+		k.release(key, e)
+	}
+}
+
+// TryLock locks key only if it is free. ok is false when another goroutine
+// holds it.
+func (k *keyedMutex) TryLock(key string) (unlock func(), ok bool) {
+	e := k.acquire(key)
+	if !e.TryLock() {
+		k.release(key, e)
+		return nil, false
+	}
+	return func() {
+		e.Unlock()
+		k.release(key, e)
+	}, true
+}
@@ -6,17 +6,65 @@
 package memory
 
 import (
+	"container/heap"
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // Turn is a single conversational exchange.
 type Turn struct {
-	Human string
-	AI    string
+	Human string `json:"human"`
+	AI    string `json:"ai"`
+	// ToolCalls are the tools the agent ran while answering, in order.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall records one tool execution within a turn.
+type ToolCall struct {
+	Name  string `json:"name"`
+	Input string `json:"input"`
+	// Output is the tool's result as shown to the model, truncated to
+	// MaxToolOutput characters.
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// MaxToolOutput is the number of characters of a tool's output kept in a
+// ToolCall.
+const MaxToolOutput = 2000
+
+// NewToolCall builds a ToolCall, truncating output to MaxToolOutput
+// characters and recording err's message.
+func NewToolCall(name, input, output string, duration time.Duration, err error) ToolCall {
+	call := ToolCall{Name: name, Input: input, Output: truncate(output, MaxToolOutput), Duration: duration}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	return call
+}
+
+// String renders the call as one "Tool call: name(input) -> output" line.
+func (c ToolCall) String() string {
+	result := c.Output
+	if c.Error != "" {
+		result = "error: " + c.Error
+	}
+	return fmt.Sprintf("Tool call: %s(%s) -> %s", c.Name, strings.TrimSpace(c.Input), strings.Join(strings.Fields(result), " "))
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
 }
 
 // Summarizer condenses prior context into a compact, high-fidelity summary.
@@ -31,18 +79,108 @@ type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 }
 
+// BatchEmbedder is an Embedder that can embed many texts in one call. The
+// returned vectors are in the order of texts.
+type BatchEmbedder interface {
+	Embedder
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
 // Hit is a semantic-search result.
 type Hit struct {
 	ID    string
 	Text  string
 	Score float64
 	Meta  map[string]any
+	// Scores breaks Score down by source (ScoreVector, ScoreKeyword,
+	// ScoreFused, ScoreRerank) for hits from a HybridRetriever; plain store
+	// searches leave it nil.
+	Scores map[string]float64
 }
 
 // VectorStore persists text embeddings and retrieves the nearest neighbours.
+// Entries live in namespaces (the store itself is the default, "" namespace);
+// IDs are unique per namespace and every operation stays within one.
 type VectorStore interface {
 	Add(ctx context.Context, id, text string, vector []float32, meta map[string]any) error
 	Search(ctx context.Context, vector []float32, k int) ([]Hit, error)
+	// SearchFiltered is Search restricted to entries whose metadata matches
+	// filter.
+	SearchFiltered(ctx context.Context, vector []float32, k int, filter Filter) ([]Hit, error)
+	// Delete removes entries by ID; unknown IDs are ignored.
+	Delete(ctx context.Context, ids ...string) error
+	// DeleteByFilter removes every entry whose metadata matches filter. An
+	// empty filter clears the namespace.
+	DeleteByFilter(ctx context.Context, filter Filter) error
+	// Namespace returns a view of the same store scoped to name, e.g. one per
+	// tenant.
+	Namespace(name string) VectorStore
+}
+
+// VectorLookup is implemented by vector stores that can report which of a set
+// of IDs they already hold, so callers can skip re-embedding stored entries.
+// Every bundled store implements it.
+type VectorLookup interface {
+	Existing(ctx context.Context, ids ...string) (map[string]bool, error)
+}
+
+// ChatMemory persists a session's conversation transcript. The MongoDB and
+// Redis stores in sibling packages implement it; the agent accepts any
+// ChatMemory, so transcripts can live wherever suits the deployment.
+type ChatMemory interface {
+	AddConversationToMemory(sessionId, prompt, aiMessage string) error
+	RetrieveMemoryWithK(sessionId string, k int64) (string, error)
+	// RetrieveTurns returns the full, chronological (oldest-first) turn
+	// history for a session; used by the compactor.
+	RetrieveTurns(sessionId string) ([]Turn, error)
+}
+
+// TurnMemory is implemented by chat memories that can store a whole Turn,
+// including its tool calls. RetrieveTurns returns the stored tool calls.
+type TurnMemory interface {
+	AddTurnToMemory(sessionId string, turn Turn) error
+}
+
+// SaveTurn stores turn in m, with its tool calls when m is a TurnMemory.
+func SaveTurn(m ChatMemory, sessionId string, turn Turn) error {
+	if tm, ok := m.(TurnMemory); ok {
+		return tm.AddTurnToMemory(sessionId, turn)
+	}
+	return m.AddConversationToMemory(sessionId, turn.Human, turn.AI)
+}
+
+// RecentTurnsMemory is implemented by chat memories that can load just the
+// last k turns of a session instead of the whole transcript.
+type RecentTurnsMemory interface {
+	RetrieveRecentTurns(sessionId string, k int64) ([]Turn, error)
+}
+
+// RecentTurns returns the last k turns of a session, oldest first, reading
+// only those turns when m is a RecentTurnsMemory.
+func RecentTurns(m ChatMemory, sessionId string, k int64) ([]Turn, error) {
+	if rm, ok := m.(RecentTurnsMemory); ok {
+		return rm.RetrieveRecentTurns(sessionId, k)
+	}
+	turns, err := m.RetrieveTurns(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	return turns[max(int64(len(turns))-k, 0):], nil
+}
+
+// ChatMemoryDeleter is implemented by chat memories that can delete a
+// session's whole transcript.
+type ChatMemoryDeleter interface {
+	DeleteConversation(sessionId string) error
+}
+
+// WindowedChatMemory is implemented by chat memories that retain only the most
+// recent turns of a session (e.g. a capped Redis list). RetrieveWindow returns
+// the retained turns together with the absolute index of the first one, so the
+// compactor's coverage index stays correct after old turns are trimmed.
+type WindowedChatMemory interface {
+	ChatMemory
+	RetrieveWindow(sessionId string) (turns []Turn, offset int, err error)
 }
 
 // ---- cosine similarity ----
@@ -74,49 +212,146 @@ type memVector struct {
 	meta map[string]any
 }
 
+// memVectors is the storage shared by an InMemoryVectorStore and its
+// namespace views.
+type memVectors struct {
+	mu         sync.RWMutex
+	namespaces map[string]map[string]memVector
+}
+
 // InMemoryVectorStore is a simple cosine-ranked VectorStore. It is safe for
 // concurrent use and is intended for tests and small/local deployments; use the
 // MongoDB Atlas vector store for production-scale retrieval.
 type InMemoryVectorStore struct {
-	mu      sync.RWMutex
-	vectors []memVector
+	data      *memVectors
+	namespace string
 }
 
 // NewInMemoryVectorStore returns an empty in-memory vector store.
-func NewInMemoryVectorStore() *InMemoryVectorStore { return &InMemoryVectorStore{} }
+func NewInMemoryVectorStore() *InMemoryVectorStore {
+	return &InMemoryVectorStore{data: &memVectors{namespaces: make(map[string]map[string]memVector)}}
+}
+
+// Namespace returns a view of the store scoped to name.
+func (s *InMemoryVectorStore) Namespace(name string) VectorStore {
+	return &InMemoryVectorStore{data: s.data, namespace: name}
+}
 
 // Add stores (or replaces, by id) an embedded text entry.
 func (s *InMemoryVectorStore) Add(_ context.Context, id, text string, vector []float32, meta map[string]any) error {
 	if len(vector) == 0 {
 		return errors.New("memory: empty vector")
 	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	for i := range s.vectors {
-		if s.vectors[i].id == id {
-			s.vectors[i] = memVector{id: id, text: text, vec: vector, meta: meta}
-			return nil
-		}
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+	ns, ok := s.data.namespaces[s.namespace]
+	if !ok {
+		ns = make(map[string]memVector)
+		s.data.namespaces[s.namespace] = ns
 	}
-	s.vectors = append(s.vectors, memVector{id: id, text: text, vec: vector, meta: meta})
+	ns[id] = memVector{id: id, text: text, vec: vector, meta: meta}
 	return nil
 }
 
 // Search returns the k most cosine-similar entries, highest score first.
-func (s *InMemoryVectorStore) Search(_ context.Context, vector []float32, k int) ([]Hit, error) {
+func (s *InMemoryVectorStore) Search(ctx context.Context, vector []float32, k int) ([]Hit, error) {
+	return s.SearchFiltered(ctx, vector, k, nil)
+}
+
+// SearchFiltered returns the k most cosine-similar entries matching filter.
+func (s *InMemoryVectorStore) SearchFiltered(_ context.Context, vector []float32, k int, filter Filter) ([]Hit, error) {
 	if k <= 0 {
 		k = 5
 	}
-	s.mu.RLock()
-	hits := make([]Hit, 0, len(s.vectors))
-	for _, v := range s.vectors {
-		hits = append(hits, Hit{ID: v.id, Text: v.text, Score: Cosine(vector, v.vec), Meta: v.meta})
+	if err := filter.Validate(); err != nil {
+		return nil, err
 	}
-	s.mu.RUnlock()
-
-	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
-	if len(hits) > k {
-		hits = hits[:k]
+	// Keep only the best k in a min-heap rather than sorting every entry.
+	top := &hitHeap{}
+	s.data.mu.RLock()
+	for _, v := range s.data.namespaces[s.namespace] {
+		if !filter.Match(v.meta) {
+			continue
+		}
+		score := Cosine(vector, v.vec)
+		if top.Len() == k && !hitBetter(Hit{ID: v.id, Score: score}, top.items[0]) {
+			continue
+		}
+		heap.Push(top, Hit{ID: v.id, Text: v.text, Score: score, Meta: v.meta})
+		if top.Len() > k {
+			heap.Pop(top)
+		}
 	}
+	s.data.mu.RUnlock()
+
+	hits := top.items
+	sortHits(hits)
 	return hits, nil
 }
+
+// Existing reports which of ids are stored in the namespace.
+func (s *InMemoryVectorStore) Existing(_ context.Context, ids ...string) (map[string]bool, error) {
+	s.data.mu.RLock()
+	defer s.data.mu.RUnlock()
+	ns := s.data.namespaces[s.namespace]
+	found := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := ns[id]; ok {
+			found[id] = true
+		}
+	}
+	return found, nil
+}
+
+// Delete removes entries by ID.
+func (s *InMemoryVectorStore) Delete(_ context.Context, ids ...string) error {
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+	ns := s.data.namespaces[s.namespace]
+	for _, id := range ids {
+		delete(ns, id)
+	}
+	return nil
+}
+
+// DeleteByFilter removes every entry in the namespace matching filter.
+func (s *InMemoryVectorStore) DeleteByFilter(_ context.Context, filter Filter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	s.data.mu.Lock()
+	defer s.data.mu.Unlock()
+	ns := s.data.namespaces[s.namespace]
+	for id, v := range ns {
+		if filter.Match(v.meta) {
+			delete(ns, id)
+		}
+	}
+	return nil
+}
+
+// hitBetter orders hits by descending score, breaking ties by ID so results
+// are deterministic.
+func hitBetter(a, b Hit) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.ID < b.ID
+}
+
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool { return hitBetter(hits[i], hits[j]) })
+}
+
+// hitHeap is a min-heap with the worst hit on top.
+type hitHeap struct{ items []Hit }
+
+func (h hitHeap) Len() int           { return len(h.items) }
+func (h hitHeap) Less(i, j int) bool { return hitBetter(h.items[j], h.items[i]) }
+func (h hitHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *hitHeap) Push(x any)        { h.items = append(h.items, x.(Hit)) }
+func (h *hitHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
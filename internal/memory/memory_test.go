@@ -68,3 +68,78 @@ func TestInMemoryVectorStore_EmptyVector(t *testing.T) {
 		t.Error("expected error adding empty vector")
 	}
 }
+
+func TestInMemoryVectorStore_FilteredSearchAndDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryVectorStore()
+	_ = s.Add(ctx, "a", "alpha", []float32{1, 0}, map[string]any{"user_id": "u1", "created_at": int64(100)})
+	_ = s.Add(ctx, "b", "beta", []float32{0.9, 0.1}, map[string]any{"user_id": "u2", "created_at": int64(200)})
+	_ = s.Add(ctx, "c", "gamma", []float32{0.8, 0.2}, map[string]any{"user_id": "u1", "created_at": int64(300)})
+
+	hits, err := s.SearchFiltered(ctx, []float32{1, 0}, 5, Filter{Eq("user_id", "u1"), Gte("created_at", 150)})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].ID != "c" {
+		t.Fatalf("want only c, got %+v", hits)
+	}
+
+	if err := s.DeleteByFilter(ctx, Filter{Eq("user_id", "u1")}); err != nil {
+		t.Fatalf("delete by filter: %v", err)
+	}
+	if err := s.Delete(ctx, "b", "missing"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if hits, _ := s.Search(ctx, []float32{1, 0}, 5); len(hits) != 0 {
+		t.Errorf("expected an empty store, got %+v", hits)
+	}
+	if _, err := s.SearchFiltered(ctx, []float32{1, 0}, 5, Filter{{Field: "x", Op: "regex"}}); err == nil {
+		t.Error("expected an error for an unknown operator")
+	}
+}
+
+func TestInMemoryVectorStore_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryVectorStore()
+	t1, t2 := s.Namespace("tenant-1"), s.Namespace("tenant-2")
+	_ = t1.Add(ctx, "x", "one", []float32{1, 0}, nil)
+	_ = t2.Add(ctx, "x", "two", []float32{1, 0}, nil)
+
+	if hits, _ := s.Search(ctx, []float32{1, 0}, 5); len(hits) != 0 {
+		t.Errorf("default namespace should not see tenants, got %+v", hits)
+	}
+	if hits, _ := t1.Search(ctx, []float32{1, 0}, 5); len(hits) != 1 || hits[0].Text != "one" {
+		t.Errorf("tenant-1 view wrong: %+v", hits)
+	}
+	if err := t1.DeleteByFilter(ctx, nil); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if hits, _ := s.Namespace("tenant-2").Search(ctx, []float32{1, 0}, 5); len(hits) != 1 || hits[0].Text != "two" {
+		t.Errorf("clearing tenant-1 touched tenant-2: %+v", hits)
+	}
+}
+
+func TestVectorLookup_Existing(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]VectorStore{
+		"in-memory": NewInMemoryVectorStore(),
+		"hnsw":      NewHNSWVectorStore(HNSWConfig{}),
+	}
+	for name, s := range stores {
+		_ = s.Add(ctx, "a", "one", []float32{1, 0}, nil)
+		_ = s.Add(ctx, "b", "two", []float32{0, 1}, nil)
+		_ = s.Namespace("other").Add(ctx, "c", "three", []float32{1, 1}, nil)
+		_ = s.Delete(ctx, "b")
+
+		got, err := s.(VectorLookup).Existing(ctx, "a", "b", "c", "d")
+		if err != nil {
+			t.Fatalf("%s: existing: %v", name, err)
+		}
+		if len(got) != 1 || !got["a"] {
+			t.Errorf("%s: want only a, got %v", name, got)
+		}
+		if got, _ := s.Namespace("other").(VectorLookup).Existing(ctx, "a", "c"); len(got) != 1 || !got["c"] {
+			t.Errorf("%s: namespace view: want only c, got %v", name, got)
+		}
+	}
+}
@@ -14,19 +14,24 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// ChatMemoryCollectionInterface defines the required methods for managing chat memory operations
-type ChatMemoryCollectionInterface interface {
-	AddConversationToMemory(sessionId, prompt, aiMessage string) error
-	RetrieveMemoryWithK(sessionId string, k int64) (string, error)
-	// RetrieveTurns returns the full, chronological (oldest-first) turn history
-	// for a session; used by the Phase 4 compactor.
-	RetrieveTurns(sessionId string) ([]memory.Turn, error)
-}
+// ChatMemoryCollectionInterface defines the required methods for managing chat
+// memory operations. It is the provider-agnostic memory.ChatMemory contract,
+// kept under its original name for existing callers.
+type ChatMemoryCollectionInterface = memory.ChatMemory
 
 type dataObject struct {
-	UserPrompt       string `bson:"user_prompt" json:"user_prompt"`
-	DarksuitResponse string `bson:"darksuit_response" json:"darksuit_response"`
-	ToolUsed         string `bson:"tool_used,omitempty" json:"tool_used,omitempty"`
+	UserPrompt       string        `bson:"user_prompt" json:"user_prompt"`
+	DarksuitResponse string        `bson:"darksuit_response" json:"darksuit_response"`
+	ToolUsed         string        `bson:"tool_used,omitempty" json:"tool_used,omitempty"`
+	ToolCalls        []toolCallDoc `bson:"tool_calls,omitempty" json:"tool_calls,omitempty"`
+}
+
+type toolCallDoc struct {
+	Name       string `bson:"name" json:"name"`
+	Input      string `bson:"input" json:"input"`
+	Output     string `bson:"output,omitempty" json:"output,omitempty"`
+	Error      string `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64  `bson:"duration_ms" json:"duration_ms"`
 }
 
 type convData struct {
@@ -65,16 +70,35 @@ Args:
 The function trims off a specified string from the ai_message before storing it.
 */
 func (mc *MongoCollection) AddConversationToMemory(sessionId, prompt, aiMessage string) error {
+	return mc.AddTurnToMemory(sessionId, memory.Turn{Human: prompt, AI: aiMessage})
+}
+
+// AddTurnToMemory stores a turn together with its tool calls; tool_used lists
+// the names of the tools called, comma-separated.
+func (mc *MongoCollection) AddTurnToMemory(sessionId string, turn memory.Turn) error {
+	data := dataObject{
+		UserPrompt:       turn.Human,
+		DarksuitResponse: turn.AI,
+	}
+	var names []string
+	for _, call := range turn.ToolCalls {
+		names = append(names, call.Name)
+		data.ToolCalls = append(data.ToolCalls, toolCallDoc{
+			Name:       call.Name,
+			Input:      call.Input,
+			Output:     call.Output,
+			Error:      call.Error,
+			DurationMs: call.Duration.Milliseconds(),
+		})
+	}
+	data.ToolUsed = strings.Join(names, ",")
 
 	// Create a new convHistory struct with the provided data
 	history := convHistory{
 		SessionId: sessionId,
 		History: convData{
 			Type: "ai",
-			Data: dataObject{
-				UserPrompt:       prompt,
-				DarksuitResponse: aiMessage,
-			},
+			Data: data,
 		},
 		TimeStamp: time.Now().UTC().Format(time.RFC3339), // Get the current timestamp in RFC3339 format
 	}
@@ -175,13 +199,58 @@ func (mc *MongoCollection) RetrieveTurns(sessionId string) ([]memory.Turn, error
 		if decErr := cur.Decode(&doc); decErr != nil {
 			return nil, decErr
 		}
-		turns = append(turns, memory.Turn{
-			Human: doc.History.Data.UserPrompt,
-			AI:    doc.History.Data.DarksuitResponse,
-		})
+		turns = append(turns, doc.turn())
 	}
 	if curErr := cur.Err(); curErr != nil {
 		return nil, curErr
 	}
 	return turns, nil
 }
+
+// RetrieveRecentTurns returns the last k turns of a session in chronological
+// order, reading only those k documents.
+func (mc *MongoCollection) RetrieveRecentTurns(sessionId string, k int64) ([]memory.Turn, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	// Descending timestamp => newest first; reversed below.
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "timestamp", Value: -1}}).SetLimit(k)
+	cur, dbErr := mc.collection.Find(context.Background(), bson.M{"sessionId": sessionId}, opts)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	defer cur.Close(context.Background())
+
+	var docs []convHistory
+	if err := cur.All(context.Background(), &docs); err != nil {
+		return nil, err
+	}
+	turns := make([]memory.Turn, len(docs))
+	for i, doc := range docs {
+		turns[len(docs)-1-i] = doc.turn()
+	}
+	return turns, nil
+}
+
+func (doc convHistory) turn() memory.Turn {
+	turn := memory.Turn{
+		Human: doc.History.Data.UserPrompt,
+		AI:    doc.History.Data.DarksuitResponse,
+	}
+	for _, call := range doc.History.Data.ToolCalls {
+		turn.ToolCalls = append(turn.ToolCalls, memory.ToolCall{
+			Name:     call.Name,
+			Input:    call.Input,
+			Output:   call.Output,
+			Error:    call.Error,
+			Duration: time.Duration(call.DurationMs) * time.Millisecond,
+		})
+	}
+	return turn
+}
+
+// DeleteConversation removes every turn stored for a session.
+func (mc *MongoCollection) DeleteConversation(sessionId string) error {
+	_, err := mc.collection.DeleteMany(context.Background(), bson.M{"sessionId": sessionId})
+	return err
+}
@@ -0,0 +1,73 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFactStore is a memory.FactStore keeping one document per user fact.
+// It does not create indexes; create one on {userId: 1, createdAt: 1} so
+// listing a user's facts stays cheap:
+//
+//	db.facts.createIndex({userId: 1, createdAt: 1})
+type MongoFactStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoFactStore wraps a collection used to store user facts.
+func NewMongoFactStore(collection *mongo.Collection) *MongoFactStore {
+	return &MongoFactStore{collection: collection}
+}
+
+type factDoc struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"userId"`
+	Text      string    `bson:"text"`
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// ListFacts returns the user's facts in creation order.
+func (s *MongoFactStore) ListFacts(ctx context.Context, userID string) ([]memory.Fact, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []factDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	facts := make([]memory.Fact, len(docs))
+	for i, d := range docs {
+		facts[i] = memory.Fact{ID: d.ID, UserID: d.UserID, Text: d.Text, CreatedAt: d.CreatedAt, UpdatedAt: d.UpdatedAt}
+	}
+	return facts, nil
+}
+
+// UpsertFact stores (or replaces, by ID) a fact.
+func (s *MongoFactStore) UpsertFact(ctx context.Context, fact memory.Fact) error {
+	doc := factDoc{ID: fact.ID, UserID: fact.UserID, Text: fact.Text, CreatedAt: fact.CreatedAt, UpdatedAt: fact.UpdatedAt}
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": fact.ID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteFact removes one of the user's facts.
+func (s *MongoFactStore) DeleteFact(ctx context.Context, userID, factID string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": factID, "userId": userID})
+	return err
+}
+
+// DeleteFacts removes every fact of a user.
+func (s *MongoFactStore) DeleteFacts(ctx context.Context, userID string) error {
+	_, err := s.collection.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
@@ -0,0 +1,94 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSessionStore is a memory.SessionStore keeping one document per
+// session, keyed by session ID. It does not create indexes; create one on
+// {userId: 1, updatedAt: -1} so listing a user's sessions stays cheap:
+//
+//	db.sessions.createIndex({userId: 1, updatedAt: -1})
+type MongoSessionStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSessionStore wraps a collection used to store session records.
+func NewMongoSessionStore(collection *mongo.Collection) *MongoSessionStore {
+	return &MongoSessionStore{collection: collection}
+}
+
+type sessionDoc struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"userId"`
+	Title     string    `bson:"title"`
+	Tags      []string  `bson:"tags,omitempty"`
+	Turns     int       `bson:"turns"`
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+func (d sessionDoc) session() memory.Session {
+	return memory.Session{ID: d.ID, UserID: d.UserID, Title: d.Title, Tags: d.Tags, Turns: d.Turns, CreatedAt: d.CreatedAt, UpdatedAt: d.UpdatedAt}
+}
+
+// GetSession returns a session by ID, or memory.ErrSessionNotFound.
+func (s *MongoSessionStore) GetSession(ctx context.Context, sessionID string) (memory.Session, error) {
+	var doc sessionDoc
+	err := s.collection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return memory.Session{}, memory.ErrSessionNotFound
+		}
+		return memory.Session{}, err
+	}
+	return doc.session(), nil
+}
+
+// UpsertSession stores (or replaces, by ID) a session.
+func (s *MongoSessionStore) UpsertSession(ctx context.Context, session memory.Session) error {
+	doc := sessionDoc{
+		ID: session.ID, UserID: session.UserID, Title: session.Title, Tags: session.Tags,
+		Turns: session.Turns, CreatedAt: session.CreatedAt, UpdatedAt: session.UpdatedAt,
+	}
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": session.ID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ListSessions returns the user's sessions (every session for an empty
+// userID), most recently updated first.
+func (s *MongoSessionStore) ListSessions(ctx context.Context, userID string) ([]memory.Session, error) {
+	query := bson.M{}
+	if userID != "" {
+		query["userId"] = userID
+	}
+	cursor, err := s.collection.Find(ctx, query,
+		options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []sessionDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	sessions := make([]memory.Session, len(docs))
+	for i, d := range docs {
+		sessions[i] = d.session()
+	}
+	return sessions, nil
+}
+
+// DeleteSession removes a session record.
+func (s *MongoSessionStore) DeleteSession(ctx context.Context, sessionID string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": sessionID})
+	return err
+}
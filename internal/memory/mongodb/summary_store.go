@@ -49,3 +49,9 @@ func (s *MongoSummaryStore) SetSummary(ctx context.Context, sessionID, summary s
 	)
 	return err
 }
+
+// DeleteSummary removes a session's summary.
+func (s *MongoSummaryStore) DeleteSummary(ctx context.Context, sessionID string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"sessionId": sessionID})
+	return err
+}
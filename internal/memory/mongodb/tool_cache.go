@@ -0,0 +1,65 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoResultCache is a tools.ResultCache keeping one document per key.
+// Expired documents are never returned; a TTL index on
+// {expiresAt: 1} with expireAfterSeconds: 0 has MongoDB delete them.
+type MongoResultCache struct {
+	collection *mongo.Collection
+}
+
+// NewMongoResultCache wraps a collection used to store tool results.
+func NewMongoResultCache(collection *mongo.Collection) *MongoResultCache {
+	return &MongoResultCache{collection: collection}
+}
+
+type resultCacheDoc struct {
+	Key       string     `bson:"_id"`
+	Value     []byte     `bson:"value"`
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty"`
+}
+
+// Get returns the unexpired value stored under key.
+func (c *MongoResultCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	filter := bson.M{
+		"_id": key,
+		"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$exists": false}},
+			bson.M{"expiresAt": bson.M{"$gt": time.Now()}},
+		},
+	}
+	var doc resultCacheDoc
+	err := c.collection.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return doc.Value, true, nil
+}
+
+// Set stores (or replaces) value under key for ttl.
+func (c *MongoResultCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	doc := resultCacheDoc{Key: key, Value: value}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		doc.ExpiresAt = &expiresAt
+	}
+	_, err := c.collection.ReplaceOne(ctx, bson.M{"_id": key}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete removes the value stored under key.
+func (c *MongoResultCache) Delete(ctx context.Context, key string) error {
+	_, err := c.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -2,6 +2,7 @@ package mongodb
 
 import (
 	"context"
+	"strings"
 
 	"github.com/darksuit-ai/darksuitai/internal/memory"
 
@@ -16,18 +17,28 @@ import (
 // score.
 //
 // It requires an Atlas Vector Search index on the embedding Path (default
-// "embedding"). Example index definition:
+// "embedding"), with "namespace" and every metadata field used in a Filter
+// ("meta.<field>") declared as filter fields. Example index definition:
 //
 //	{
 //	  "fields": [
-//	    { "type": "vector", "path": "embedding", "numDimensions": 1536, "similarity": "cosine" }
+//	    { "type": "vector", "path": "embedding", "numDimensions": 1536, "similarity": "cosine" },
+//	    { "type": "filter", "path": "namespace" },
+//	    { "type": "filter", "path": "meta.session_id" },
+//	    { "type": "filter", "path": "meta.user_id" }
 //	  ]
 //	}
+//
+// Every document carries its namespace ("" for the default one). Documents
+// written before namespaces existed need it backfilled:
+//
+//	db.collection.updateMany({namespace: {$exists: false}}, {$set: {namespace: ""}})
 type MongoVectorStore struct {
 	collection    *mongo.Collection
 	indexName     string
 	path          string
 	numCandidates int
+	namespace     string
 }
 
 // NewMongoVectorStore wraps a collection and the name of its Atlas Vector Search
@@ -41,16 +52,107 @@ func NewMongoVectorStore(collection *mongo.Collection, indexName string) *MongoV
 	}
 }
 
+// Namespace returns a view of the store scoped to name.
+func (s *MongoVectorStore) Namespace(name string) memory.VectorStore {
+	view := *s
+	view.namespace = name
+	return &view
+}
+
+// namespaceSep joins a namespace and an entry ID into the document _id. Entries
+// in the default namespace keep their plain ID as _id.
+const namespaceSep = "\x1f"
+
+func (s *MongoVectorStore) docID(id string) string {
+	if s.namespace == "" {
+		return id
+	}
+	return s.namespace + namespaceSep + id
+}
+
 // Add upserts an embedded text entry keyed by id.
 func (s *MongoVectorStore) Add(ctx context.Context, id, text string, vector []float32, meta map[string]any) error {
 	_, err := s.collection.UpdateOne(ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": bson.M{"text": text, s.path: vector, "meta": meta}},
+		bson.M{"_id": s.docID(id)},
+		bson.M{"$set": bson.M{"text": text, s.path: vector, "meta": meta, "namespace": s.namespace}},
 		options.Update().SetUpsert(true),
 	)
 	return err
 }
 
+// Existing reports which of ids are stored in the namespace, reading only the
+// matching _id values.
+func (s *MongoVectorStore) Existing(ctx context.Context, ids ...string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(ids) == 0 {
+		return found, nil
+	}
+	keys := make([]string, len(ids))
+	byKey := make(map[string]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.docID(id)
+		byKey[keys[i]] = id
+	}
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": keys}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		found[byKey[d.ID]] = true
+	}
+	return found, nil
+}
+
+// Delete removes entries by ID.
+func (s *MongoVectorStore) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.docID(id)
+	}
+	_, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	return err
+}
+
+// DeleteByFilter removes every entry in the namespace matching filter.
+func (s *MongoVectorStore) DeleteByFilter(ctx context.Context, filter memory.Filter) error {
+	query, err := s.query(filter)
+	if err != nil {
+		return err
+	}
+	_, err = s.collection.DeleteMany(ctx, query)
+	return err
+}
+
+// query translates filter into a MongoDB query scoped to the namespace. The
+// same operators are valid in a $vectorSearch pre-filter.
+func (s *MongoVectorStore) query(filter memory.Filter) (bson.M, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	and := bson.A{bson.M{"namespace": bson.M{"$eq": s.namespace}}}
+	for _, c := range filter {
+		var value any = c.Value
+		if c.Op == memory.FilterIn || c.Op == memory.FilterNin {
+			value = memory.FilterValues(c.Value)
+		}
+		and = append(and, bson.M{"meta." + c.Field: bson.M{"$" + c.Op: value}})
+	}
+	if len(and) == 1 {
+		return and[0].(bson.M), nil
+	}
+	return bson.M{"$and": and}, nil
+}
+
 type vectorHitDoc struct {
 	ID    string         `bson:"_id"`
 	Text  string         `bson:"text"`
@@ -60,17 +162,32 @@ type vectorHitDoc struct {
 
 // Search returns the k nearest neighbours to vector via Atlas $vectorSearch.
 func (s *MongoVectorStore) Search(ctx context.Context, vector []float32, k int) ([]memory.Hit, error) {
+	return s.SearchFiltered(ctx, vector, k, nil)
+}
+
+// SearchFiltered returns the k nearest neighbours matching filter, applied as
+// a $vectorSearch pre-filter.
+func (s *MongoVectorStore) SearchFiltered(ctx context.Context, vector []float32, k int, filter memory.Filter) ([]memory.Hit, error) {
 	if k <= 0 {
 		k = 5
 	}
+	query, err := s.query(filter)
+	if err != nil {
+		return nil, err
+	}
+	numCandidates := s.numCandidates
+	if numCandidates < k {
+		numCandidates = k
+	}
 	pipeline := []bson.M{
 		{
 			"$vectorSearch": bson.M{
 				"index":         s.indexName,
 				"path":          s.path,
 				"queryVector":   vector,
-				"numCandidates": s.numCandidates,
+				"numCandidates": numCandidates,
 				"limit":         k,
+				"filter":        query,
 			},
 		},
 		{
@@ -95,7 +212,7 @@ func (s *MongoVectorStore) Search(ctx context.Context, vector []float32, k int)
 		if decErr := cur.Decode(&doc); decErr != nil {
 			return nil, decErr
 		}
-		hits = append(hits, memory.Hit{ID: doc.ID, Text: doc.Text, Score: doc.Score, Meta: doc.Meta})
+		hits = append(hits, memory.Hit{ID: strings.TrimPrefix(doc.ID, s.docID("")), Text: doc.Text, Score: doc.Score, Meta: doc.Meta})
 	}
 	if curErr := cur.Err(); curErr != nil {
 		return nil, curErr
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Recall scopes: which past turns a question may recall.
+const (
+	// RecallScopeSession recalls only turns from the current session.
+	RecallScopeSession = "session"
+	// RecallScopeUser recalls turns from every session of the same user.
+	RecallScopeUser = "user"
+)
+
+// RecallConfig tunes semantic recall of past turns.
+type RecallConfig struct {
+	// TopK is the number of past turns injected per question. Defaults to 3.
+	TopK int
+	// Scope is RecallScopeSession (default) or RecallScopeUser. User scope
+	// requires the agent to have a user ID; without one it falls back to the
+	// session.
+	Scope string
+	// MinScore drops hits whose similarity is below it. Zero keeps every hit.
+	MinScore float64
+}
+
+// Recaller implements the "semantic recall" half of context engineering: each
+// completed turn is embedded into a VectorStore, and before answering a new
+// question the most relevant older turns are retrieved and injected next to
+// the (compacted) chat history.
+type Recaller struct {
+	store    VectorStore
+	embedder Embedder
+	cfg      RecallConfig
+}
+
+// NewRecaller builds a Recaller, applying default config values.
+func NewRecaller(store VectorStore, embedder Embedder, cfg RecallConfig) *Recaller {
+	if cfg.TopK <= 0 {
+		cfg.TopK = 3
+	}
+	if cfg.Scope != RecallScopeUser {
+		cfg.Scope = RecallScopeSession
+	}
+	return &Recaller{store: store, embedder: embedder, cfg: cfg}
+}
+
+// Remember embeds a completed turn and stores it, tagged with its session and
+// user so it can be scoped at recall time. Turns are keyed by session and
+// content, so saving the same exchange twice does not duplicate it.
+func (r *Recaller) Remember(ctx context.Context, sessionID, userID string, turn Turn) error {
+	if sessionID == "" {
+		return errors.New("memory: recall requires a session ID")
+	}
+	text := RenderTurns([]Turn{turn})
+	vector, err := r.embedder.Embed(ctx, text)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(text))
+	id := sessionID + ":" + hex.EncodeToString(sum[:8])
+	meta := map[string]any{
+		"session_id": sessionID,
+		"created_at": time.Now().UTC().Unix(),
+	}
+	if userID != "" {
+		meta["user_id"] = userID
+	}
+	return r.store.Add(ctx, id, text, vector, meta)
+}
+
+// Recall returns up to TopK past turns relevant to query, most similar first.
+// Turns whose text already appears in exclude (typically the chat history that
+// is injected verbatim) are skipped, so only older context is recalled.
+func (r *Recaller) Recall(ctx context.Context, sessionID, userID, query, exclude string) ([]Hit, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	vector, err := r.embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	field, value := "session_id", sessionID
+	if r.cfg.Scope == RecallScopeUser && userID != "" {
+		field, value = "user_id", userID
+	}
+
+	// Over-fetch so that turns dropped below (already in the prompt) do not
+	// leave the result short.
+	candidates, err := r.store.SearchFiltered(ctx, vector, r.cfg.TopK*2, Filter{Eq(field, value)})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, 0, r.cfg.TopK)
+	for _, h := range candidates {
+		if len(hits) == r.cfg.TopK {
+			break
+		}
+		if h.Score < r.cfg.MinScore {
+			continue
+		}
+		if exclude != "" && strings.Contains(exclude, h.Text) {
+			continue
+		}
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
+
+// ForgetSession deletes every remembered turn of a session.
+func (r *Recaller) ForgetSession(ctx context.Context, sessionID string) error {
+	return r.store.DeleteByFilter(ctx, Filter{Eq("session_id", sessionID)})
+}
+
+// ForgetUser deletes every remembered turn of a user, across sessions.
+func (r *Recaller) ForgetUser(ctx context.Context, userID string) error {
+	return r.store.DeleteByFilter(ctx, Filter{Eq("user_id", userID)})
+}
+
+// RecallContext is Recall rendered as a block ready to inject into a prompt.
+// It returns "" when nothing relevant was found.
+func (r *Recaller) RecallContext(ctx context.Context, sessionID, userID, query, exclude string) (string, error) {
+	hits, err := r.Recall(ctx, sessionID, userID, query, exclude)
+	if err != nil || len(hits) == 0 {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("Relevant earlier conversation:\n")
+	for _, h := range hits {
+		b.WriteString(h.Text)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// keywordEmbedder embeds text as counts of a fixed vocabulary, so similarity is
+// driven by shared keywords and tests stay deterministic.
+type keywordEmbedder struct{ vocab []string }
+
+func (e keywordEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	text = strings.ToLower(text)
+	v := make([]float32, len(e.vocab)+1)
+	for i, w := range e.vocab {
+		v[i] = float32(strings.Count(text, w))
+	}
+	v[len(e.vocab)] = 0.01 // never a zero vector
+	return v, nil
+}
+
+var testVocab = keywordEmbedder{vocab: []string{"invoice", "refund", "password", "shipping"}}
+
+func TestRecaller_ScopesToSession(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecaller(NewInMemoryVectorStore(), testVocab, RecallConfig{TopK: 2})
+	_ = r.Remember(ctx, "s1", "u1", Turn{Human: "where is my invoice?", AI: "invoice sent"})
+	_ = r.Remember(ctx, "s2", "u1", Turn{Human: "invoice for March?", AI: "invoice attached"})
+
+	hits, err := r.Recall(ctx, "s1", "u1", "invoice", "")
+	if err != nil {
+		t.Fatalf("recall: %v", err)
+	}
+	if len(hits) != 1 || !strings.Contains(hits[0].Text, "where is my invoice") {
+		t.Fatalf("want only the s1 turn, got %+v", hits)
+	}
+}
+
+func TestRecaller_UserScopeSpansSessions(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecaller(NewInMemoryVectorStore(), testVocab, RecallConfig{TopK: 5, Scope: RecallScopeUser})
+	_ = r.Remember(ctx, "s1", "u1", Turn{Human: "refund please", AI: "refund issued"})
+	_ = r.Remember(ctx, "s2", "u1", Turn{Human: "refund status?", AI: "refund pending"})
+	_ = r.Remember(ctx, "s3", "u2", Turn{Human: "refund?", AI: "refund done"})
+
+	hits, _ := r.Recall(ctx, "s2", "u1", "refund", "")
+	if len(hits) != 2 {
+		t.Fatalf("want 2 hits across u1's sessions, got %d", len(hits))
+	}
+	for _, h := range hits {
+		if h.Meta["user_id"] != "u1" {
+			t.Errorf("hit from another user leaked: %+v", h)
+		}
+	}
+}
+
+func TestRecaller_ExcludesTurnsAlreadyInPrompt(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecaller(NewInMemoryVectorStore(), testVocab, RecallConfig{TopK: 5})
+	old := Turn{Human: "reset my password", AI: "password reset link sent"}
+	recent := Turn{Human: "password again?", AI: "password link resent"}
+	_ = r.Remember(ctx, "s1", "", old)
+	_ = r.Remember(ctx, "s1", "", recent)
+
+	out, err := r.RecallContext(ctx, "s1", "", "password", RenderTurns([]Turn{recent}))
+	if err != nil {
+		t.Fatalf("recall: %v", err)
+	}
+	if !strings.Contains(out, "reset my password") {
+		t.Errorf("expected the older turn to be recalled, got:\n%s", out)
+	}
+	if strings.Contains(out, "password again?") {
+		t.Errorf("turn already in the prompt was recalled again:\n%s", out)
+	}
+}
+
+func TestRecaller_MinScoreAndEmptyQuery(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecaller(NewInMemoryVectorStore(), testVocab, RecallConfig{MinScore: 0.5})
+	_ = r.Remember(ctx, "s1", "", Turn{Human: "shipping time?", AI: "2 days shipping"})
+
+	if hits, _ := r.Recall(ctx, "s1", "", "invoice", ""); len(hits) != 0 {
+		t.Errorf("unrelated turn passed MinScore: %+v", hits)
+	}
+	if out, _ := r.RecallContext(ctx, "s1", "", "  ", ""); out != "" {
+		t.Errorf("empty query should recall nothing, got %q", out)
+	}
+}
@@ -0,0 +1,188 @@
+// Package redisdb provides Redis-backed chat memory and summary stores. They
+// implement the same contracts as the MongoDB stores (memory.ChatMemory and
+// memory.SummaryStore) and suit short-lived sessions such as support chats,
+// where transcripts should expire on their own after a period of inactivity.
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config tunes the Redis-backed stores.
+type Config struct {
+	// KeyPrefix namespaces every key written by the stores. Defaults to
+	// "darksuit".
+	KeyPrefix string
+	// TTL is how long an idle session is kept; every write refreshes it. Zero
+	// disables expiry.
+	TTL time.Duration
+	// MaxLength caps the number of turns retained per session; the oldest
+	// turns are trimmed first. Zero keeps every turn.
+	MaxLength int64
+}
+
+func (c Config) prefix() string {
+	if c.KeyPrefix == "" {
+		return "darksuit"
+	}
+	return c.KeyPrefix
+}
+
+// chatKey and trimmedKey share the {sessionId} hash tag so both land in the
+// same slot on Redis Cluster, which the append script requires.
+func (c Config) chatKey(sessionId string) string {
+	return c.prefix() + ":chat:{" + sessionId + "}"
+}
+
+func (c Config) trimmedKey(sessionId string) string {
+	return c.prefix() + ":chat:{" + sessionId + "}:trimmed"
+}
+
+func (c Config) summaryKey(sessionId string) string {
+	return c.prefix() + ":summary:{" + sessionId + "}"
+}
+
+type turnEntry struct {
+	UserPrompt       string            `json:"user_prompt"`
+	DarksuitResponse string            `json:"darksuit_response"`
+	ToolCalls        []memory.ToolCall `json:"tool_calls,omitempty"`
+	TimeStamp        string            `json:"timestamp"`
+}
+
+// appendScriptSource pushes a turn, trims the list to MaxLength while counting how
+// many turns were dropped, and refreshes the TTL, all atomically.
+//
+//	KEYS[1] chat list, KEYS[2] trimmed counter
+//	ARGV[1] entry, ARGV[2] max length (0 = unbounded), ARGV[3] TTL ms (0 = none)
+const appendScriptSource = `
+local n = redis.call('RPUSH', KEYS[1], ARGV[1])
+local max = tonumber(ARGV[2])
+if max > 0 and n > max then
+	redis.call('LTRIM', KEYS[1], n - max, -1)
+	redis.call('INCRBY', KEYS[2], n - max)
+end
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return n
+`
+
+var appendScript = redis.NewScript(appendScriptSource)
+
+// RedisChatMemory stores each session's turns in a Redis list and implements
+// memory.WindowedChatMemory and memory.TurnMemory.
+type RedisChatMemory struct {
+	client redis.UniversalClient
+	cfg    Config
+}
+
+// NewRedisChatMemory wraps a Redis client (single node, sentinel or cluster).
+func NewRedisChatMemory(client redis.UniversalClient, cfg Config) *RedisChatMemory {
+	return &RedisChatMemory{client: client, cfg: cfg}
+}
+
+// AddConversationToMemory appends a turn to the session, trimming the oldest
+// turns beyond MaxLength and refreshing the session TTL.
+func (r *RedisChatMemory) AddConversationToMemory(sessionId, prompt, aiMessage string) error {
+	return r.AddTurnToMemory(sessionId, memory.Turn{Human: prompt, AI: aiMessage})
+}
+
+// AddTurnToMemory is AddConversationToMemory for a turn with tool calls.
+func (r *RedisChatMemory) AddTurnToMemory(sessionId string, turn memory.Turn) error {
+	entry, err := json.Marshal(turnEntry{
+		UserPrompt:       turn.Human,
+		DarksuitResponse: turn.AI,
+		ToolCalls:        turn.ToolCalls,
+		TimeStamp:        time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	return appendScript.Run(context.Background(), r.client,
+		[]string{r.cfg.chatKey(sessionId), r.cfg.trimmedKey(sessionId)},
+		string(entry), r.cfg.MaxLength, r.cfg.TTL.Milliseconds(),
+	).Err()
+}
+
+// RetrieveMemoryWithK renders the most recent k turns as a chronological
+// "Human: ... / AI: ..." transcript, or "[]" when the session is empty.
+func (r *RedisChatMemory) RetrieveMemoryWithK(sessionId string, k int64) (string, error) {
+	turns, err := r.RetrieveRecentTurns(sessionId, k)
+	if err != nil {
+		return "", err
+	}
+	return memory.RenderTurns(turns), nil
+}
+
+// RetrieveRecentTurns returns the most recent k turns, oldest first.
+func (r *RedisChatMemory) RetrieveRecentTurns(sessionId string, k int64) ([]memory.Turn, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	raw, err := r.client.LRange(context.Background(), r.cfg.chatKey(sessionId), -k, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeTurns(raw)
+}
+
+// RetrieveTurns returns the retained turns for a session, oldest first.
+func (r *RedisChatMemory) RetrieveTurns(sessionId string) ([]memory.Turn, error) {
+	turns, _, err := r.RetrieveWindow(sessionId)
+	return turns, err
+}
+
+// RetrieveWindow returns the retained turns together with the number of older
+// turns already trimmed from the session.
+func (r *RedisChatMemory) RetrieveWindow(sessionId string) ([]memory.Turn, int, error) {
+	ctx := context.Background()
+	var (
+		rangeCmd   *redis.StringSliceCmd
+		trimmedCmd *redis.StringCmd
+	)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		rangeCmd = pipe.LRange(ctx, r.cfg.chatKey(sessionId), 0, -1)
+		trimmedCmd = pipe.Get(ctx, r.cfg.trimmedKey(sessionId))
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+	turns, err := decodeTurns(rangeCmd.Val())
+	if err != nil {
+		return nil, 0, err
+	}
+	offset := 0
+	if v := trimmedCmd.Val(); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			return nil, 0, err
+		}
+	}
+	return turns, offset, nil
+}
+
+// DeleteConversation removes a session's turns.
+func (r *RedisChatMemory) DeleteConversation(sessionId string) error {
+	return r.client.Del(context.Background(), r.cfg.chatKey(sessionId), r.cfg.trimmedKey(sessionId)).Err()
+}
+
+func decodeTurns(raw []string) ([]memory.Turn, error) {
+	turns := make([]memory.Turn, 0, len(raw))
+	for _, item := range raw {
+		var entry turnEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			return nil, err
+		}
+		turns = append(turns, memory.Turn{Human: entry.UserPrompt, AI: entry.DarksuitResponse, ToolCalls: entry.ToolCalls})
+	}
+	return turns, nil
+}
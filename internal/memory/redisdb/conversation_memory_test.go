@@ -0,0 +1,132 @@
+package redisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+func TestRedisChatMemory_AppendTrimsAndCountsOffset(t *testing.T) {
+	stub, client := newStubRedis(t)
+	m := NewRedisChatMemory(client, Config{MaxLength: 3, TTL: time.Minute})
+
+	for _, prompt := range []string{"one", "two", "three", "four", "five"} {
+		if err := m.AddConversationToMemory("s1", prompt, "re: "+prompt); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if stub.evals != 5 {
+		t.Errorf("want every append to run the script, got %d evals", stub.evals)
+	}
+
+	turns, offset, err := m.RetrieveWindow("s1")
+	if err != nil {
+		t.Fatalf("window: %v", err)
+	}
+	if offset != 2 {
+		t.Errorf("want 2 trimmed turns, got %d", offset)
+	}
+	if len(turns) != 3 || turns[0].Human != "three" || turns[2].AI != "re: five" {
+		t.Errorf("unexpected retained turns: %+v", turns)
+	}
+}
+
+func TestRedisChatMemory_WindowWithoutTrimming(t *testing.T) {
+	_, client := newStubRedis(t)
+	m := NewRedisChatMemory(client, Config{})
+
+	turns, offset, err := m.RetrieveWindow("empty")
+	if err != nil || len(turns) != 0 || offset != 0 {
+		t.Fatalf("empty session: turns=%v offset=%d err=%v", turns, offset, err)
+	}
+
+	turn := memory.Turn{Human: "weather?", AI: "Sunny.", ToolCalls: []memory.ToolCall{{Name: "weather", Input: "Paris", Output: "sunny"}}}
+	if err := m.AddTurnToMemory("s1", turn); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	turns, offset, err = m.RetrieveWindow("s1")
+	if err != nil || offset != 0 || len(turns) != 1 {
+		t.Fatalf("turns=%v offset=%d err=%v", turns, offset, err)
+	}
+	if got := turns[0].ToolCalls; len(got) != 1 || got[0].Output != "sunny" {
+		t.Errorf("tool calls not round-tripped: %+v", got)
+	}
+}
+
+func TestRedisChatMemory_RefreshesTTL(t *testing.T) {
+	stub, client := newStubRedis(t)
+	cfg := Config{KeyPrefix: "app", MaxLength: 1, TTL: 90 * time.Second}
+	m := NewRedisChatMemory(client, cfg)
+
+	_ = m.AddConversationToMemory("s1", "hi", "hello")
+	_ = m.AddConversationToMemory("s1", "again", "hello again")
+	for _, key := range []string{cfg.chatKey("s1"), cfg.trimmedKey("s1")} {
+		if got := stub.ttls[key]; got != 90000 {
+			t.Errorf("%s: want TTL 90000ms, got %d", key, got)
+		}
+	}
+
+	noTTL := NewRedisChatMemory(client, Config{KeyPrefix: "other"})
+	_ = noTTL.AddConversationToMemory("s1", "hi", "hello")
+	if _, ok := stub.ttls[Config{KeyPrefix: "other"}.chatKey("s1")]; ok {
+		t.Errorf("zero TTL should not set an expiry")
+	}
+}
+
+func TestRedisChatMemory_RetrieveMemoryWithKAndDelete(t *testing.T) {
+	_, client := newStubRedis(t)
+	m := NewRedisChatMemory(client, Config{})
+	for _, prompt := range []string{"a", "b", "c"} {
+		_ = m.AddConversationToMemory("s1", prompt, prompt+"!")
+	}
+
+	got, err := m.RetrieveMemoryWithK("s1", 2)
+	if err != nil {
+		t.Fatalf("retrieve: %v", err)
+	}
+	want := memory.RenderTurns([]memory.Turn{{Human: "b", AI: "b!"}, {Human: "c", AI: "c!"}})
+	if got != want {
+		t.Errorf("want last two turns %q, got %q", want, got)
+	}
+
+	recent, err := m.RetrieveRecentTurns("s1", 2)
+	if err != nil || len(recent) != 2 || recent[0].Human != "b" || recent[1].Human != "c" {
+		t.Errorf("want turns b and c, got %+v, %v", recent, err)
+	}
+
+	if err := m.DeleteConversation("s1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, _ := m.RetrieveMemoryWithK("s1", 2); got != "[]" {
+		t.Errorf("deleted session should render as [], got %q", got)
+	}
+}
+
+func TestRedisSummaryStore_RoundTripAndTTL(t *testing.T) {
+	stub, client := newStubRedis(t)
+	cfg := Config{TTL: time.Hour}
+	s := NewRedisSummaryStore(client, cfg)
+	ctx := context.Background()
+
+	if summary, upTo, err := s.GetSummary(ctx, "s1"); err != nil || summary != "" || upTo != 0 {
+		t.Fatalf("missing summary: %q %d %v", summary, upTo, err)
+	}
+	if err := s.SetSummary(ctx, "s1", "User wants a refund.", 12); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	summary, upTo, err := s.GetSummary(ctx, "s1")
+	if err != nil || summary != "User wants a refund." || upTo != 12 {
+		t.Errorf("got %q %d %v", summary, upTo, err)
+	}
+	if got := stub.ttls[cfg.summaryKey("s1")]; got != time.Hour.Milliseconds() {
+		t.Errorf("want summary TTL refreshed, got %d", got)
+	}
+	if err := s.DeleteSummary(ctx, "s1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if summary, _, _ := s.GetSummary(ctx, "s1"); summary != "" {
+		t.Errorf("summary survived delete: %q", summary)
+	}
+}
@@ -0,0 +1,209 @@
+package redisdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// stubRedis is a minimal RESP2 server covering the commands the stores use.
+// There is no Lua interpreter, so EVAL accepts only appendScript and runs a Go
+// port of it; keep the two in step when the script changes.
+type stubRedis struct {
+	mu     sync.Mutex
+	lists  map[string][]string
+	values map[string]string
+	hashes map[string]map[string]string
+	ttls   map[string]int64
+	evals  int
+}
+
+func newStubRedis(t *testing.T) (*stubRedis, *redis.Client) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &stubRedis{
+		lists:  map[string][]string{},
+		values: map[string]string{},
+		hashes: map[string]map[string]string{},
+		ttls:   map[string]int64{},
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIndentity: true})
+	t.Cleanup(func() {
+		client.Close()
+		ln.Close()
+	})
+	return s, client
+}
+
+func (s *stubRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "MULTI":
+			inMulti, queued = true, nil
+			w.WriteString("+OK\r\n")
+		case cmd == "EXEC":
+			inMulti = false
+			fmt.Fprintf(w, "*%d\r\n", len(queued))
+			for _, q := range queued {
+				w.WriteString(s.exec(q))
+			}
+		case inMulti:
+			queued = append(queued, args)
+			w.WriteString("+QUEUED\r\n")
+		default:
+			w.WriteString(s.exec(args))
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (s *stubRedis) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "EVALSHA":
+		return "-NOSCRIPT No matching script.\r\n"
+	case "EVAL":
+		if args[1] != appendScriptSource {
+			return "-ERR unexpected script\r\n"
+		}
+		s.evals++
+		keys := args[3:5]
+		limit, _ := strconv.Atoi(args[6])
+		ttl, _ := strconv.ParseInt(args[7], 10, 64)
+		list := append(s.lists[keys[0]], args[5])
+		n := len(list)
+		if limit > 0 && n > limit {
+			list = list[n-limit:]
+			trimmed, _ := strconv.Atoi(s.values[keys[1]])
+			s.values[keys[1]] = strconv.Itoa(trimmed + n - limit)
+		}
+		s.lists[keys[0]] = list
+		if ttl > 0 {
+			s.ttls[keys[0]], s.ttls[keys[1]] = ttl, ttl
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	case "LRANGE":
+		list := s.lists[args[1]]
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		if start < 0 {
+			start = max(len(list)+start, 0)
+		}
+		if stop < 0 {
+			stop = len(list) + stop
+		}
+		stop = min(stop, len(list)-1)
+		if start > stop {
+			return "*0\r\n"
+		}
+		return bulkArray(list[start : stop+1])
+	case "GET":
+		v, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "HSET":
+		h := s.hashes[args[1]]
+		if h == nil {
+			h = map[string]string{}
+			s.hashes[args[1]] = h
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			h[args[i]] = args[i+1]
+		}
+		return ":" + strconv.Itoa((len(args)-2)/2) + "\r\n"
+	case "HGETALL":
+		var flat []string
+		for k, v := range s.hashes[args[1]] {
+			flat = append(flat, k, v)
+		}
+		return bulkArray(flat)
+	case "PEXPIRE":
+		ttl, _ := strconv.ParseInt(args[2], 10, 64)
+		s.ttls[args[1]] = ttl
+		return ":1\r\n"
+	case "DEL":
+		for _, k := range args[1:] {
+			delete(s.lists, k)
+			delete(s.values, k)
+			delete(s.hashes, k)
+			delete(s.ttls, k)
+		}
+		return ":" + strconv.Itoa(len(args)-1) + "\r\n"
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func bulk(v string) string {
+	return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+}
+
+func bulkArray(items []string) string {
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(items)) + "\r\n")
+	for _, item := range items {
+		b.WriteString(bulk(item))
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package redisdb
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSummaryStore persists a session's rolling conversation summary in a
+// Redis hash and implements memory.SummaryStore. Summaries share the chat
+// memory's TTL so they expire together with the transcript they describe.
+type RedisSummaryStore struct {
+	client redis.UniversalClient
+	cfg    Config
+}
+
+// NewRedisSummaryStore wraps a Redis client used to store rolling summaries.
+func NewRedisSummaryStore(client redis.UniversalClient, cfg Config) *RedisSummaryStore {
+	return &RedisSummaryStore{client: client, cfg: cfg}
+}
+
+// GetSummary returns the stored summary and coverage for a session. A missing
+// session yields ("", 0, nil).
+func (s *RedisSummaryStore) GetSummary(ctx context.Context, sessionID string) (string, int, error) {
+	fields, err := s.client.HGetAll(ctx, s.cfg.summaryKey(sessionID)).Result()
+	if err != nil {
+		return "", 0, err
+	}
+	if len(fields) == 0 {
+		return "", 0, nil
+	}
+	upTo, err := strconv.Atoi(fields["compactedUpTo"])
+	if err != nil {
+		return "", 0, err
+	}
+	return fields["summary"], upTo, nil
+}
+
+// SetSummary stores the rolling summary and coverage for a session and
+// refreshes its TTL.
+func (s *RedisSummaryStore) SetSummary(ctx context.Context, sessionID, summary string, compactedUpTo int) error {
+	key := s.cfg.summaryKey(sessionID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "summary", summary, "compactedUpTo", compactedUpTo)
+		if s.cfg.TTL > 0 {
+			pipe.PExpire(ctx, key, s.cfg.TTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// DeleteSummary removes a session's summary.
+func (s *RedisSummaryStore) DeleteSummary(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, s.cfg.summaryKey(sessionID)).Err()
+}
@@ -0,0 +1,382 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrSessionNotFound is returned when a session ID is unknown.
+var ErrSessionNotFound = errors.New("memory: session not found")
+
+// Session describes one conversation: who it belongs to, what it is about and
+// when it was last active. The transcript itself stays in the ChatMemory.
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Title     string    `json:"title"`
+	Tags      []string  `json:"tags,omitempty"`
+	Turns     int       `json:"turns"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionStore persists session records.
+type SessionStore interface {
+	// GetSession returns ErrSessionNotFound for an unknown ID.
+	GetSession(ctx context.Context, sessionID string) (Session, error)
+	UpsertSession(ctx context.Context, session Session) error
+	// ListSessions returns the user's sessions, most recently updated first.
+	// An empty userID lists every session.
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	DeleteSession(ctx context.Context, sessionID string) error
+}
+
+// SessionConfig wires a SessionManager to the stores holding each session's
+// data, so transcripts can be read and whole sessions deleted.
+type SessionConfig struct {
+	// ChatMemory holds the transcripts. Transcript and Export need it, and
+	// Delete removes the transcript when it implements ChatMemoryDeleter.
+	ChatMemory ChatMemory
+	// Summaries, when set, holds the sessions' rolling summaries: Export
+	// includes the summary and Delete removes it (if the store implements
+	// SummaryDeleter).
+	Summaries SummaryStore
+	// Recaller, when set, has its remembered turns of a session deleted with
+	// the session.
+	Recaller *Recaller
+	// Titler, when set, is asked for a short title after a session's first
+	// turn. Without it (or when it fails) the title is the start of the first
+	// question.
+	Titler CompletionFunc
+}
+
+// SessionManager keeps the session records of a ChatMemory: Touch is called
+// after every saved turn (the agent does this when a SessionManager is set),
+// and List, Transcript, Rename, Tag, Delete and Export serve a conversation
+// list UI.
+type SessionManager struct {
+	store    SessionStore
+	cfg      SessionConfig
+	sessions keyedMutex
+	now      func() time.Time
+}
+
+// NewSessionManager builds a SessionManager over store.
+func NewSessionManager(store SessionStore, cfg SessionConfig) *SessionManager {
+	return &SessionManager{store: store, cfg: cfg, now: func() time.Time { return time.Now().UTC() }}
+}
+
+const sessionTitleSystemPrompt = `You name conversations for a chat history list.
+Reply with ONLY a short title (at most 6 words) describing what the user wants, with no quotes and no trailing punctuation.`
+
+// Touch records a completed turn of sessionID: it creates the session on its
+// first turn (titling it from that turn), bumps UpdatedAt and the turn count,
+// and fills in userID if the session has none yet.
+//
+// The first turn is recorded with the start of the question as its title.
+// The Titler is then asked for a better one without holding the session's
+// lock, so later turns are not held up by the model call, and its title is
+// stored only if the session still has the placeholder, e.g. was not renamed
+// in the meantime.
+func (m *SessionManager) Touch(ctx context.Context, sessionID, userID string, turn Turn) (Session, error) {
+	if sessionID == "" {
+		return Session{}, errors.New("memory: session ID is required")
+	}
+	s, titled, err := m.record(ctx, sessionID, userID, turn)
+	if err != nil || !titled || m.cfg.Titler == nil {
+		return s, err
+	}
+	title := m.title(ctx, turn)
+	if title == "" || title == s.Title {
+		return s, nil
+	}
+
+	unlock := m.sessions.Lock(sessionID)
+	defer unlock()
+	current, err := m.store.GetSession(ctx, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return s, nil
+	} else if err != nil {
+		return s, err
+	}
+	if current.Title != s.Title {
+		return current, nil
+	}
+	current.Title = title
+	if err := m.store.UpsertSession(ctx, current); err != nil {
+		return current, err
+	}
+	return current, nil
+}
+
+// record updates the session for a turn under its lock, reporting whether it
+// gave the session its placeholder title.
+func (m *SessionManager) record(ctx context.Context, sessionID, userID string, turn Turn) (Session, bool, error) {
+	unlock := m.sessions.Lock(sessionID)
+	defer unlock()
+
+	now := m.now()
+	s, err := m.store.GetSession(ctx, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		s = Session{ID: sessionID, CreatedAt: now}
+	} else if err != nil {
+		return Session{}, false, err
+	}
+	if s.UserID == "" {
+		s.UserID = userID
+	}
+	titled := false
+	if s.Title == "" {
+		s.Title, titled = cleanTitle(turn.Human), true
+	}
+	s.Turns++
+	s.UpdatedAt = now
+	if err := m.store.UpsertSession(ctx, s); err != nil {
+		return Session{}, false, err
+	}
+	return s, titled, nil
+}
+
+// title asks the Titler for a title; it returns "" when the Titler fails.
+func (m *SessionManager) title(ctx context.Context, turn Turn) string {
+	reply, err := m.cfg.Titler(ctx, sessionTitleSystemPrompt, RenderTurns([]Turn{turn}))
+	if err != nil {
+		return ""
+	}
+	return cleanTitle(reply)
+}
+
+// cleanTitle reduces s to its first line, without quotes, capped at 60
+// characters on a word boundary.
+func cleanTitle(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(strings.Trim(s, "\"'`*# "))
+	const maxLen = 60
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	cut := string([]rune(s)[:maxLen])
+	if i := strings.LastIndexByte(cut, ' '); i > maxLen/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+// Get returns a session's record.
+func (m *SessionManager) Get(ctx context.Context, sessionID string) (Session, error) {
+	return m.store.GetSession(ctx, sessionID)
+}
+
+// List returns the user's sessions, most recently updated first. With tags,
+// only sessions carrying all of them are returned.
+func (m *SessionManager) List(ctx context.Context, userID string, tags ...string) ([]Session, error) {
+	sessions, err := m.store.ListSessions(ctx, userID)
+	if err != nil || len(tags) == 0 {
+		return sessions, err
+	}
+	kept := sessions[:0]
+	for _, s := range sessions {
+		if hasTags(s.Tags, tags) {
+			kept = append(kept, s)
+		}
+	}
+	return kept, nil
+}
+
+func hasTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			found = found || h == w
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Transcript returns a session's full conversation, oldest turn first.
+func (m *SessionManager) Transcript(ctx context.Context, sessionID string) ([]Turn, error) {
+	if _, err := m.store.GetSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	if m.cfg.ChatMemory == nil {
+		return nil, errors.New("memory: session manager has no chat memory")
+	}
+	return m.cfg.ChatMemory.RetrieveTurns(sessionID)
+}
+
+// Rename sets a session's title.
+func (m *SessionManager) Rename(ctx context.Context, sessionID, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errors.New("memory: session title is empty")
+	}
+	return m.update(ctx, sessionID, func(s *Session) { s.Title = title })
+}
+
+// Tag replaces a session's tags.
+func (m *SessionManager) Tag(ctx context.Context, sessionID string, tags ...string) error {
+	return m.update(ctx, sessionID, func(s *Session) {
+		s.Tags = nil
+		seen := make(map[string]bool, len(tags))
+		for _, t := range tags {
+			if t = strings.TrimSpace(t); t != "" && !seen[t] {
+				seen[t] = true
+				s.Tags = append(s.Tags, t)
+			}
+		}
+	})
+}
+
+func (m *SessionManager) update(ctx context.Context, sessionID string, change func(*Session)) error {
+	unlock := m.sessions.Lock(sessionID)
+	defer unlock()
+	s, err := m.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	change(&s)
+	return m.store.UpsertSession(ctx, s)
+}
+
+// Delete removes a session together with its transcript, rolling summary and
+// remembered turns. It fails without deleting anything if a configured
+// ChatMemory or summary store cannot delete sessions.
+func (m *SessionManager) Delete(ctx context.Context, sessionID string) error {
+	transcripts, ok := m.cfg.ChatMemory.(ChatMemoryDeleter)
+	if m.cfg.ChatMemory != nil && !ok {
+		return fmt.Errorf("memory: %T cannot delete conversations", m.cfg.ChatMemory)
+	}
+	summaries, ok := m.cfg.Summaries.(SummaryDeleter)
+	if m.cfg.Summaries != nil && !ok {
+		return fmt.Errorf("memory: %T cannot delete summaries", m.cfg.Summaries)
+	}
+
+	unlock := m.sessions.Lock(sessionID)
+	defer unlock()
+	if transcripts != nil {
+		if err := transcripts.DeleteConversation(sessionID); err != nil {
+			return err
+		}
+	}
+	if summaries != nil {
+		if err := summaries.DeleteSummary(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+	if m.cfg.Recaller != nil {
+		if err := m.cfg.Recaller.ForgetSession(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+	return m.store.DeleteSession(ctx, sessionID)
+}
+
+// SessionExport is the JSON document written by SessionManager.Export.
+type SessionExport struct {
+	Session Session `json:"session"`
+	Summary string  `json:"summary,omitempty"`
+	Turns   []Turn  `json:"turns"`
+}
+
+// Export writes a session, its rolling summary and its full transcript to w
+// as indented JSON.
+func (m *SessionManager) Export(ctx context.Context, sessionID string, w io.Writer) error {
+	s, err := m.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	turns, err := m.Transcript(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	export := SessionExport{Session: s, Turns: turns}
+	if export.Turns == nil {
+		export.Turns = []Turn{}
+	}
+	if m.cfg.Summaries != nil {
+		if export.Summary, _, err = m.cfg.Summaries.GetSummary(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// ---- in-memory session store ----
+
+// InMemorySessionStore is a process-local SessionStore for tests and
+// single-node use. Production deployments should use the MongoDB-backed store.
+type InMemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// NewInMemorySessionStore returns an empty in-memory session store.
+func NewInMemorySessionStore() *InMemorySessionStore {
+	return &InMemorySessionStore{sessions: make(map[string]Session)}
+}
+
+// GetSession returns a session by ID.
+func (s *InMemorySessionStore) GetSession(_ context.Context, sessionID string) (Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+	session.Tags = append([]string(nil), session.Tags...)
+	return session, nil
+}
+
+// UpsertSession stores (or replaces, by ID) a session.
+func (s *InMemorySessionStore) UpsertSession(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session.Tags = append([]string(nil), session.Tags...)
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// ListSessions returns the user's sessions, most recently updated first.
+func (s *InMemorySessionStore) ListSessions(_ context.Context, userID string) ([]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Session
+	for _, session := range s.sessions {
+		if userID == "" || session.UserID == userID {
+			session.Tags = append([]string(nil), session.Tags...)
+			out = append(out, session)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].UpdatedAt.Equal(out[j].UpdatedAt) {
+			return out[i].UpdatedAt.After(out[j].UpdatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// DeleteSession removes a session; deleting an unknown session is not an
+// error.
+func (s *InMemorySessionStore) DeleteSession(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sessionChats is a ChatMemoryDeleter keeping turns per session.
+type sessionChats map[string][]Turn
+
+func (m sessionChats) AddConversationToMemory(sessionId, prompt, aiMessage string) error {
+	m[sessionId] = append(m[sessionId], Turn{Human: prompt, AI: aiMessage})
+	return nil
+}
+func (m sessionChats) RetrieveMemoryWithK(string, int64) (string, error) { return "", nil }
+func (m sessionChats) RetrieveTurns(sessionId string) ([]Turn, error)    { return m[sessionId], nil }
+func (m sessionChats) DeleteConversation(sessionId string) error {
+	delete(m, sessionId)
+	return nil
+}
+
+func newTestSessions(titler CompletionFunc) (*SessionManager, sessionChats, *InMemorySummaryStore, *Recaller) {
+	chats := sessionChats{}
+	summaries := NewInMemorySummaryStore()
+	recaller := NewRecaller(NewInMemoryVectorStore(), testVocab, RecallConfig{})
+	m := NewSessionManager(NewInMemorySessionStore(), SessionConfig{
+		ChatMemory: chats, Summaries: summaries, Recaller: recaller, Titler: titler,
+	})
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { clock = clock.Add(time.Minute); return clock }
+	return m, chats, summaries, recaller
+}
+
+// save records a turn the way the agent does.
+func save(t *testing.T, m *SessionManager, chats sessionChats, sessionID, userID string, turn Turn) {
+	t.Helper()
+	_ = chats.AddConversationToMemory(sessionID, turn.Human, turn.AI)
+	if _, err := m.Touch(context.Background(), sessionID, userID, turn); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+}
+
+func TestSessionManager_TouchTitlesAndLists(t *testing.T) {
+	ctx := context.Background()
+	var prompts []string
+	titler := func(_ context.Context, _, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "\"Refund for order 1234\"\n", nil
+	}
+	m, chats, _, _ := newTestSessions(titler)
+
+	save(t, m, chats, "s1", "u1", Turn{Human: "I want a refund for order 1234", AI: "Sure."})
+	save(t, m, chats, "s2", "u1", Turn{Human: "Password reset?", AI: "Use the link."})
+	save(t, m, chats, "s1", "u1", Turn{Human: "How long will it take?", AI: "5 days."})
+	save(t, m, chats, "s3", "u2", Turn{Human: "Hi", AI: "Hello"})
+
+	if len(prompts) != 3 || !strings.Contains(prompts[0], "order 1234") {
+		t.Errorf("want one title request per new session, got %q", prompts)
+	}
+	s1, err := m.Get(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1.Title != "Refund for order 1234" || s1.Turns != 2 || s1.UserID != "u1" || !s1.UpdatedAt.After(s1.CreatedAt) {
+		t.Errorf("s1 = %+v", s1)
+	}
+
+	list, err := m.List(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "s1" || list[1].ID != "s2" {
+		t.Errorf("want s1 (most recent) then s2, got %+v", list)
+	}
+	if all, _ := m.List(ctx, ""); len(all) != 3 {
+		t.Errorf("empty user should list every session, got %d", len(all))
+	}
+
+	if err := m.Rename(ctx, "s2", "  Password help "); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Tag(ctx, "s2", "support", "auth", "support"); err != nil {
+		t.Fatal(err)
+	}
+	tagged, _ := m.List(ctx, "u1", "auth")
+	if len(tagged) != 1 || tagged[0].Title != "Password help" || len(tagged[0].Tags) != 2 {
+		t.Errorf("rename/tag: %+v", tagged)
+	}
+	if err := m.Rename(ctx, "missing", "x"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("rename of unknown session: %v", err)
+	}
+}
+
+func TestSessionManager_TitleFallback(t *testing.T) {
+	failing := func(context.Context, string, string) (string, error) { return "", errors.New("down") }
+	for _, titler := range []CompletionFunc{nil, failing} {
+		m, chats, _, _ := newTestSessions(titler)
+		question := "Can you explain how the warranty works for drills bought in another country last year?"
+		save(t, m, chats, "s", "", Turn{Human: question, AI: "Yes."})
+		s, _ := m.Get(context.Background(), "s")
+		if !strings.HasPrefix(s.Title, "Can you explain how the warranty works") || !strings.HasSuffix(s.Title, "…") || len([]rune(s.Title)) > 61 {
+			t.Errorf("fallback title = %q", s.Title)
+		}
+	}
+}
+
+func TestSessionManager_TitlesOutsideTheLock(t *testing.T) {
+	ctx := context.Background()
+	asked, release := make(chan struct{}), make(chan struct{})
+	titler := func(context.Context, string, string) (string, error) {
+		close(asked)
+		<-release
+		return "Generated title", nil
+	}
+	m, chats, _, _ := newTestSessions(titler)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		save(t, m, chats, "s", "u1", Turn{Human: "First question", AI: "First answer"})
+	}()
+	<-asked
+
+	// The session is usable while its title is generated.
+	save(t, m, chats, "s", "u1", Turn{Human: "Second question", AI: "Second answer"})
+	if s, _ := m.Get(ctx, "s"); s.Title != "First question" || s.Turns != 2 {
+		t.Errorf("while titling: %+v", s)
+	}
+	close(release)
+	<-done
+	if s, _ := m.Get(ctx, "s"); s.Title != "Generated title" || s.Turns != 2 {
+		t.Errorf("after titling: %+v", s)
+	}
+
+	// A rename made while the title is generated wins.
+	asked, release = make(chan struct{}), make(chan struct{})
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		save(t, m, chats, "r", "u1", Turn{Human: "Another chat", AI: "Sure."})
+	}()
+	<-asked
+	if err := m.Rename(ctx, "r", "Chosen by the user"); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	<-done
+	if s, _ := m.Get(ctx, "r"); s.Title != "Chosen by the user" {
+		t.Errorf("rename overwritten: %q", s.Title)
+	}
+}
+
+func TestSessionManager_ExportAndDelete(t *testing.T) {
+	ctx := context.Background()
+	m, chats, summaries, recaller := newTestSessions(nil)
+	save(t, m, chats, "s1", "u1", Turn{Human: "refund please", AI: "done"})
+	save(t, m, chats, "s1", "u1", Turn{Human: "thanks", AI: "welcome"})
+	_ = summaries.SetSummary(ctx, "s1", "User got a refund.", 1)
+	_ = recaller.Remember(ctx, "s1", "u1", Turn{Human: "refund please", AI: "done"})
+
+	var b strings.Builder
+	if err := m.Export(ctx, "s1", &b); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var export SessionExport
+	if err := json.Unmarshal([]byte(b.String()), &export); err != nil {
+		t.Fatalf("export is not JSON: %v\n%s", err, b.String())
+	}
+	if export.Session.ID != "s1" || export.Summary != "User got a refund." || len(export.Turns) != 2 || export.Turns[1].Human != "thanks" {
+		t.Errorf("export = %+v", export)
+	}
+	if !strings.Contains(b.String(), `"human": "refund please"`) {
+		t.Errorf("turns should use lower-case JSON keys:\n%s", b.String())
+	}
+
+	if err := m.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := m.Get(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("session record survived: %v", err)
+	}
+	if _, ok := chats["s1"]; ok {
+		t.Error("transcript survived")
+	}
+	if summary, _, _ := summaries.GetSummary(ctx, "s1"); summary != "" {
+		t.Error("summary survived")
+	}
+	if hits, _ := recaller.Recall(ctx, "s1", "u1", "refund", ""); len(hits) != 0 {
+		t.Errorf("recalled turns survived: %+v", hits)
+	}
+	if _, err := m.Transcript(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("transcript of deleted session: %v", err)
+	}
+
+	// A chat memory that cannot delete blocks deletion before anything is lost.
+	strict := NewSessionManager(NewInMemorySessionStore(), SessionConfig{ChatMemory: &sliceMemory{}})
+	if err := strict.Delete(ctx, "s1"); err == nil {
+		t.Error("want an error for a chat memory without DeleteConversation")
+	}
+}
@@ -0,0 +1,375 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+)
+
+// Vector store snapshots use a compact little-endian binary format:
+//
+//	magic   "DSVS"
+//	version uint16 (currently 1)
+//	flags   uint16 (reserved, 0)
+//	uint32 namespace count, then per namespace:
+//	    string name, uint32 entry count, then per entry:
+//	        string id, string text,
+//	        uint32 dimensions, dimensions × float32,
+//	        string metadata (JSON; empty when there is none)
+//	crc32   uint32, IEEE checksum of every preceding byte
+//
+// Strings are a uint32 byte length followed by the bytes. Metadata round-trips
+// through JSON, so numbers load back as float64 (filters compare numbers by
+// value, so Eq("n", 1) still matches).
+
+const (
+	snapshotMagic   = "DSVS"
+	snapshotVersion = 1
+
+	// Sanity limits so a corrupt length cannot trigger a huge allocation
+	// before the checksum is verified.
+	maxSnapshotString = 1 << 30
+	maxSnapshotDims   = 1 << 20
+)
+
+// ErrCorruptSnapshot is returned (wrapped) by Load when a snapshot is
+// truncated, has the wrong magic or version, or fails checksum validation.
+var ErrCorruptSnapshot = errors.New("memory: corrupt vector store snapshot")
+
+// Snapshotter is implemented by vector stores that can persist their whole
+// contents (every namespace) to a stream and restore them.
+type Snapshotter interface {
+	Save(w io.Writer) error
+	Load(r io.Reader) error
+}
+
+var (
+	_ Snapshotter = (*InMemoryVectorStore)(nil)
+	_ Snapshotter = (*HNSWVectorStore)(nil)
+)
+
+// snapshotEntry is one stored vector as written to a snapshot.
+type snapshotEntry struct {
+	id   string
+	text string
+	vec  []float32
+	meta map[string]any
+}
+
+// ---- writing ----
+
+type snapshotWriter struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+	out io.Writer
+	buf [8]byte
+	err error
+}
+
+func newSnapshotWriter(w io.Writer) *snapshotWriter {
+	bw := bufio.NewWriter(w)
+	crc := crc32.NewIEEE()
+	sw := &snapshotWriter{w: bw, crc: crc, out: io.MultiWriter(bw, crc)}
+	sw.bytes([]byte(snapshotMagic))
+	sw.uint16(snapshotVersion)
+	sw.uint16(0)
+	return sw
+}
+
+func (sw *snapshotWriter) bytes(b []byte) {
+	if sw.err == nil {
+		_, sw.err = sw.out.Write(b)
+	}
+}
+
+func (sw *snapshotWriter) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(sw.buf[:2], v)
+	sw.bytes(sw.buf[:2])
+}
+
+func (sw *snapshotWriter) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(sw.buf[:4], v)
+	sw.bytes(sw.buf[:4])
+}
+
+func (sw *snapshotWriter) string(s string) {
+	sw.uint32(uint32(len(s)))
+	sw.bytes([]byte(s))
+}
+
+func (sw *snapshotWriter) namespace(name string, count int) {
+	sw.string(name)
+	sw.uint32(uint32(count))
+}
+
+func (sw *snapshotWriter) entry(e snapshotEntry) {
+	sw.string(e.id)
+	sw.string(e.text)
+	sw.uint32(uint32(len(e.vec)))
+	for _, x := range e.vec {
+		sw.uint32(math.Float32bits(x))
+	}
+	var meta []byte
+	if len(e.meta) > 0 {
+		var err error
+		if meta, err = json.Marshal(e.meta); err != nil && sw.err == nil {
+			sw.err = fmt.Errorf("memory: encoding metadata of %q: %w", e.id, err)
+		}
+	}
+	sw.string(string(meta))
+}
+
+// finish appends the checksum and flushes.
+func (sw *snapshotWriter) finish() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	binary.LittleEndian.PutUint32(sw.buf[:4], sw.crc.Sum32())
+	if _, err := sw.w.Write(sw.buf[:4]); err != nil {
+		return err
+	}
+	return sw.w.Flush()
+}
+
+// writeSnapshot writes namespaces (name → entries) in sorted order so equal
+// stores produce identical snapshots.
+func writeSnapshot(w io.Writer, namespaces map[string][]snapshotEntry) error {
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sw := newSnapshotWriter(w)
+	sw.uint32(uint32(len(names)))
+	for _, name := range names {
+		entries := namespaces[name]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+		sw.namespace(name, len(entries))
+		for _, e := range entries {
+			sw.entry(e)
+		}
+	}
+	return sw.finish()
+}
+
+// ---- reading ----
+
+type snapshotReader struct {
+	r   io.Reader
+	buf [8]byte
+}
+
+func (sr *snapshotReader) full(b []byte) error {
+	if _, err := io.ReadFull(sr.r, b); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+	}
+	return nil
+}
+
+func (sr *snapshotReader) uint16() (uint16, error) {
+	err := sr.full(sr.buf[:2])
+	return binary.LittleEndian.Uint16(sr.buf[:2]), err
+}
+
+func (sr *snapshotReader) uint32() (uint32, error) {
+	err := sr.full(sr.buf[:4])
+	return binary.LittleEndian.Uint32(sr.buf[:4]), err
+}
+
+func (sr *snapshotReader) string() (string, error) {
+	n, err := sr.uint32()
+	if err != nil {
+		return "", err
+	}
+	if n > maxSnapshotString {
+		return "", fmt.Errorf("%w: string length %d", ErrCorruptSnapshot, n)
+	}
+	b := make([]byte, n)
+	if err := sr.full(b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (sr *snapshotReader) entry() (snapshotEntry, error) {
+	var e snapshotEntry
+	var err error
+	if e.id, err = sr.string(); err != nil {
+		return e, err
+	}
+	if e.text, err = sr.string(); err != nil {
+		return e, err
+	}
+	dims, err := sr.uint32()
+	if err != nil {
+		return e, err
+	}
+	if dims == 0 || dims > maxSnapshotDims {
+		return e, fmt.Errorf("%w: %d dimensions", ErrCorruptSnapshot, dims)
+	}
+	raw := make([]byte, 4*dims)
+	if err := sr.full(raw); err != nil {
+		return e, err
+	}
+	e.vec = make([]float32, dims)
+	for i := range e.vec {
+		e.vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:]))
+	}
+	meta, err := sr.string()
+	if err != nil {
+		return e, err
+	}
+	if meta != "" {
+		if err := json.Unmarshal([]byte(meta), &e.meta); err != nil {
+			return e, fmt.Errorf("%w: metadata of %q: %v", ErrCorruptSnapshot, e.id, err)
+		}
+	}
+	return e, nil
+}
+
+// readSnapshot decodes a snapshot written by writeSnapshot. Nothing is
+// returned unless the whole snapshot decodes and its checksum matches.
+func readSnapshot(r io.Reader) (map[string][]snapshotEntry, error) {
+	crc := crc32.NewIEEE()
+	br := bufio.NewReader(r)
+	sr := &snapshotReader{r: io.TeeReader(br, crc)}
+
+	magic := make([]byte, len(snapshotMagic))
+	if err := sr.full(magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != snapshotMagic {
+		return nil, fmt.Errorf("%w: not a vector store snapshot", ErrCorruptSnapshot)
+	}
+	version, err := sr.uint16()
+	if err != nil {
+		return nil, err
+	}
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCorruptSnapshot, version)
+	}
+	if _, err := sr.uint16(); err != nil { // flags
+		return nil, err
+	}
+
+	count, err := sr.uint32()
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make(map[string][]snapshotEntry)
+	for ; count > 0; count-- {
+		name, err := sr.string()
+		if err != nil {
+			return nil, err
+		}
+		n, err := sr.uint32()
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]snapshotEntry, 0, min(int(n), 1<<16))
+		for ; n > 0; n-- {
+			e, err := sr.entry()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+		namespaces[name] = entries
+	}
+
+	want := crc.Sum32()
+	var trailer [4]byte
+	if _, err := io.ReadFull(br, trailer[:]); err != nil {
+		return nil, fmt.Errorf("%w: missing checksum", ErrCorruptSnapshot)
+	}
+	if got := binary.LittleEndian.Uint32(trailer[:]); got != want {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptSnapshot)
+	}
+	return namespaces, nil
+}
+
+// ---- store methods ----
+
+// Save writes a snapshot of every namespace of the store to w.
+func (s *InMemoryVectorStore) Save(w io.Writer) error {
+	s.data.mu.RLock()
+	namespaces := make(map[string][]snapshotEntry, len(s.data.namespaces))
+	for name, ns := range s.data.namespaces {
+		entries := make([]snapshotEntry, 0, len(ns))
+		for _, v := range ns {
+			entries = append(entries, snapshotEntry{id: v.id, text: v.text, vec: v.vec, meta: v.meta})
+		}
+		namespaces[name] = entries
+	}
+	s.data.mu.RUnlock()
+	return writeSnapshot(w, namespaces)
+}
+
+// Load replaces the contents of the store (every namespace) with a snapshot
+// read from r. On error the store is left unchanged.
+func (s *InMemoryVectorStore) Load(r io.Reader) error {
+	snapshot, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	namespaces := make(map[string]map[string]memVector, len(snapshot))
+	for name, entries := range snapshot {
+		ns := make(map[string]memVector, len(entries))
+		for _, e := range entries {
+			ns[e.id] = memVector{id: e.id, text: e.text, vec: e.vec, meta: e.meta}
+		}
+		namespaces[name] = ns
+	}
+	s.data.mu.Lock()
+	s.data.namespaces = namespaces
+	s.data.mu.Unlock()
+	return nil
+}
+
+// Save writes a snapshot of every namespace of the store to w. The graph
+// itself is not stored; Load rebuilds it. Vectors are saved unit-normalized.
+func (s *HNSWVectorStore) Save(w io.Writer) error {
+	x := s.index
+	x.mu.RLock()
+	namespaces := make(map[string][]snapshotEntry, len(x.graphs))
+	for name, g := range x.graphs {
+		entries := make([]snapshotEntry, 0, len(g.ids))
+		for _, i := range g.ids {
+			n := g.nodes[i]
+			entries = append(entries, snapshotEntry{id: n.id, text: n.text, vec: n.vec, meta: n.meta})
+		}
+		namespaces[name] = entries
+	}
+	x.mu.RUnlock()
+	return writeSnapshot(w, namespaces)
+}
+
+// Load replaces the contents of the store (every namespace) with a snapshot
+// read from r, rebuilding the graphs. On error the store is left unchanged.
+func (s *HNSWVectorStore) Load(r io.Reader) error {
+	snapshot, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	x := s.index
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	graphs := make(map[string]*hnswGraph, len(snapshot))
+	for name, entries := range snapshot {
+		g := &hnswGraph{ids: make(map[string]int32, len(entries))}
+		for _, e := range entries {
+			x.insert(g, &hnswNode{id: e.id, text: e.text, vec: normalize(e.vec), meta: e.meta})
+		}
+		graphs[name] = g
+	}
+	x.graphs = graphs
+	return nil
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := NewInMemoryVectorStore()
+	_ = src.Add(ctx, "a", "alpha", []float32{1, 0, 0}, map[string]any{"session_id": "s1", "n": 1})
+	_ = src.Add(ctx, "b", "beta", []float32{0, 1, 0}, nil)
+	_ = src.Namespace("docs").Add(ctx, "c", "gamma", []float32{0, 0, 1}, map[string]any{"tags": []any{"x"}})
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	var again bytes.Buffer
+	_ = src.Save(&again)
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("saving the same store twice produced different snapshots")
+	}
+
+	dst := NewInMemoryVectorStore()
+	_ = dst.Add(ctx, "stale", "dropped on load", []float32{1, 1, 1}, nil)
+	if err := dst.Load(&buf); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	hits, _ := dst.SearchFiltered(ctx, []float32{1, 0, 0}, 5, Filter{Eq("n", 1)})
+	if len(hits) != 1 || hits[0].ID != "a" || hits[0].Text != "alpha" || hits[0].Meta["session_id"] != "s1" {
+		t.Fatalf("default namespace not restored: %+v", hits)
+	}
+	if hits, _ := dst.Search(ctx, []float32{1, 1, 1}, 5); len(hits) != 2 {
+		t.Errorf("want the 2 saved default entries only, got %+v", hits)
+	}
+	hits, _ = dst.Namespace("docs").Search(ctx, []float32{0, 0, 1}, 5)
+	if len(hits) != 1 || hits[0].Text != "gamma" || hits[0].Score < 0.999 {
+		t.Errorf("namespace not restored: %+v", hits)
+	}
+}
+
+func TestSnapshot_HNSW(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(5))
+	src := NewHNSWVectorStore(HNSWConfig{})
+	fillStores(ctx, randomVectors(rng, 300, 16), src)
+	_ = src.Delete(ctx, "doc-0")
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	dst := NewHNSWVectorStore(HNSWConfig{})
+	if err := dst.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if dst.Len() != 299 {
+		t.Fatalf("want 299 entries, got %d", dst.Len())
+	}
+	for _, q := range randomVectors(rng, 10, 16) {
+		want, _ := src.Search(ctx, q, 5)
+		got, _ := dst.Search(ctx, q, 5)
+		if recallAt(want, got) < 0.8 {
+			t.Errorf("restored index disagrees: want %+v, got %+v", want, got)
+		}
+	}
+
+	// HNSW snapshots load into the brute-force store too.
+	flat := NewInMemoryVectorStore()
+	if err := flat.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("load into in-memory store: %v", err)
+	}
+	if hits, _ := flat.Search(ctx, []float32{1}, 300); len(hits) != 299 {
+		t.Errorf("want 299 entries, got %d", len(hits))
+	}
+}
+
+func TestSnapshot_Corruption(t *testing.T) {
+	ctx := context.Background()
+	src := NewInMemoryVectorStore()
+	_ = src.Add(ctx, "a", "alpha", []float32{1, 2, 3}, map[string]any{"k": "v"})
+	var buf bytes.Buffer
+	_ = src.Save(&buf)
+	good := buf.Bytes()
+
+	flip := func(i int) []byte {
+		b := append([]byte(nil), good...)
+		b[i] ^= 0xff
+		return b
+	}
+	cases := map[string][]byte{
+		"empty":       nil,
+		"bad magic":   flip(0),
+		"bad version": flip(4),
+		"flipped":     flip(len(good) / 2),
+		"checksum":    flip(len(good) - 1),
+		"truncated":   good[:len(good)-6],
+		"no checksum": good[:len(good)-4],
+	}
+	for name, data := range cases {
+		dst := NewInMemoryVectorStore()
+		_ = dst.Add(ctx, "keep", "kept", []float32{1}, nil)
+		err := dst.Load(bytes.NewReader(data))
+		if !errors.Is(err, ErrCorruptSnapshot) {
+			t.Errorf("%s: want ErrCorruptSnapshot, got %v", name, err)
+		}
+		if hits, _ := dst.Search(ctx, []float32{1}, 5); len(hits) != 1 || hits[0].ID != "keep" {
+			t.Errorf("%s: failed load modified the store: %+v", name, hits)
+		}
+	}
+}
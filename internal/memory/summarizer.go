@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"strings"
+)
+
+// SummarizerSystemPrompt is the instruction every Summarizer implementation
+// sends with SummarizerPrompt, so compaction behaves the same on any provider.
+const SummarizerSystemPrompt = `You maintain a running summary of a conversation between a Human and an AI assistant.
+You will be given the PRIOR SUMMARY (may be empty) and a batch of NEW TURNS.
+Produce an updated summary that preserves all decision-relevant facts, names, numbers, decisions, open questions, and user preferences.
+Be concise and high-fidelity. Output ONLY the updated summary text, with no preamble.`
+
+// SummarizerPrompt renders the prior summary and the turns to fold into it as
+// the user prompt of a summarization request.
+func SummarizerPrompt(priorSummary string, turns []Turn) string {
+	var b strings.Builder
+	b.WriteString("PRIOR SUMMARY:\n")
+	if strings.TrimSpace(priorSummary) == "" {
+		b.WriteString("(none)\n")
+	} else {
+		b.WriteString(priorSummary)
+		b.WriteString("\n")
+	}
+	b.WriteString("\nNEW TURNS:\n")
+	for _, t := range turns {
+		if t.Human != "" {
+			b.WriteString("Human: ")
+			b.WriteString(t.Human)
+			b.WriteString("\n")
+		}
+		if t.AI != "" {
+			b.WriteString("AI: ")
+			b.WriteString(t.AI)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+type completionSummarizer struct {
+	complete CompletionFunc
+}
+
+// NewSummarizer returns a Summarizer that drives any language model through
+// complete, using the same prompts as the provider-specific summarizers.
+func NewSummarizer(complete CompletionFunc) Summarizer {
+	return &completionSummarizer{complete: complete}
+}
+
+func (s *completionSummarizer) Summarize(ctx context.Context, priorSummary string, turns []Turn) (string, error) {
+	out, err := s.complete(ctx, SummarizerSystemPrompt, SummarizerPrompt(priorSummary, turns))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
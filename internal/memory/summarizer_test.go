@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSummarizerPrompt(t *testing.T) {
+	got := SummarizerPrompt("", []Turn{{Human: "hi", AI: "hello"}, {AI: "still there?"}})
+	want := "PRIOR SUMMARY:\n(none)\n\nNEW TURNS:\nHuman: hi\nAI: hello\nAI: still there?\n"
+	if got != want {
+		t.Errorf("prompt mismatch:\ngot  %q\nwant %q", got, want)
+	}
+	if got := SummarizerPrompt("user wants a refund", nil); !strings.HasPrefix(got, "PRIOR SUMMARY:\nuser wants a refund\n") {
+		t.Errorf("prior summary not rendered: %q", got)
+	}
+}
+
+func TestNewSummarizer_UsesSharedPrompts(t *testing.T) {
+	var gotSystem, gotPrompt string
+	s := NewSummarizer(func(_ context.Context, system, prompt string) (string, error) {
+		gotSystem, gotPrompt = system, prompt
+		return "  updated summary\n", nil
+	})
+	out, err := s.Summarize(context.Background(), "old", []Turn{{Human: "q", AI: "a"}})
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if out != "updated summary" {
+		t.Errorf("expected trimmed summary, got %q", out)
+	}
+	if gotSystem != SummarizerSystemPrompt || gotPrompt != SummarizerPrompt("old", []Turn{{Human: "q", AI: "a"}}) {
+		t.Errorf("unexpected prompts: system=%q prompt=%q", gotSystem, gotPrompt)
+	}
+}
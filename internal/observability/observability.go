@@ -27,6 +27,8 @@ import (
 
 // RunInfo describes a single agent invocation.
 type RunInfo struct {
+	// RunID identifies the run; observers generate one when it is empty.
+	RunID     string
 	SessionID string
 	Provider  string // gen_ai.system (e.g. "anthropic")
 	Model     string // gen_ai.request.model
@@ -49,6 +51,15 @@ type ToolCall struct {
 	Output   string
 	IsError  bool
 	Duration time.Duration
+	// CacheHit is set when the result came from the tool's result cache.
+	CacheHit bool
+}
+
+// ToolProgress is a progress event reported by a running tool.
+type ToolProgress struct {
+	Name    string
+	Message string
+	Data    map[string]any
 }
 
 // Observer starts a RunHandle for each agent invocation.
@@ -65,6 +76,24 @@ type RunHandle interface {
 	End(output string, err error)
 }
 
+// ProgressRecorder is implemented by RunHandles that record progress events
+// reported by tools while they run. It is optional, so existing RunHandle
+// implementations keep working.
+type ProgressRecorder interface {
+	ToolProgress(p ToolProgress)
+}
+
+// NewRunID returns a new run ID.
+func NewRunID() string { return newUUID() }
+
+// runID returns the run's ID, generating one when the caller set none.
+func (info RunInfo) runID() string {
+	if info.RunID != "" {
+		return info.RunID
+	}
+	return newUUID()
+}
+
 // ---- no-op ----
 
 // Noop is the default Observer; it records nothing.
@@ -92,7 +121,7 @@ type stdoutHandle struct {
 }
 
 func (Stdout) StartRun(info RunInfo) RunHandle {
-	h := &stdoutHandle{runID: newUUID(), start: time.Now(), info: info}
+	h := &stdoutHandle{runID: info.runID(), start: time.Now(), info: info}
 	h.emit(map[string]any{
 		"event":                "run.start",
 		"run.id":               h.runID,
@@ -132,6 +161,17 @@ func (h *stdoutHandle) ToolEnd(c ToolCall) {
 		"tool.output":      c.Output,
 		"error":            c.IsError,
 		"duration_ms":      c.Duration.Milliseconds(),
+		"tool.cache_hit":   c.CacheHit,
+	})
+}
+
+func (h *stdoutHandle) ToolProgress(p ToolProgress) {
+	h.emit(map[string]any{
+		"event":            "tool.progress",
+		"run.id":           h.runID,
+		"gen_ai.tool.name": p.Name,
+		"message":          p.Message,
+		"data":             p.Data,
 	})
 }
 
@@ -196,7 +236,7 @@ type langsmithHandle struct {
 }
 
 func (l *LangSmith) StartRun(info RunInfo) RunHandle {
-	return &langsmithHandle{cfg: l.cfg, runID: newUUID(), start: time.Now(), info: info}
+	return &langsmithHandle{cfg: l.cfg, runID: info.runID(), start: time.Now(), info: info}
 }
 
 func (h *langsmithHandle) LLMEnd(c LLMCall) {
@@ -222,7 +262,7 @@ func (h *langsmithHandle) End(output string, runErr error) {
 	toolEvents := make([]map[string]any, 0, len(h.tools))
 	for _, t := range h.tools {
 		toolEvents = append(toolEvents, map[string]any{
-			"name": t.Name, "input": t.Input, "output": t.Output, "error": t.IsError,
+			"name": t.Name, "input": t.Input, "output": t.Output, "error": t.IsError, "cache_hit": t.CacheHit,
 		})
 	}
 	inTok, outTok := h.inTok, h.outTok
@@ -2,16 +2,16 @@ package _chat
 
 import (
 	"bytes"
+	"context"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/darksuit-ai/darksuitai/internal/memory/mongodb"
+	"github.com/darksuit-ai/darksuitai/internal/memory"
 	"github.com/darksuit-ai/darksuitai/internal/observability"
 	"github.com/darksuit-ai/darksuitai/internal/utilities"
 	"github.com/darksuit-ai/darksuitai/pkg/agent"
 	"github.com/darksuit-ai/darksuitai/pkg/tools"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 /*
@@ -26,6 +26,10 @@ type callLLMInterface interface {
 
 var wrongToolSelection = []byte(`You tried to use the tool {tool}, but it doesn't exist. You must use any of these available tools: [{name_of_tools}].`)
 
+var invalidToolInput = []byte(`The input you gave the tool {tool} is invalid: {error}. Correct the input and use the tool again.`)
+
+var toolFailure = []byte(`The tool {tool} failed: {error}. Use the tool again, use another tool, or answer without it.`)
+
 // _callLanguageModel is a helper function that calls the LLM with the appropriate prompt.
 // It takes a queryToolResponsePrompt map[string]string as input, which contains either the user's question or the agent's plan.
 // It returns the initial message and the LLM's response.
@@ -41,7 +45,10 @@ func (prePrompt *AgentPreProgram) _callLanguageModel(queryToolResponsePrompt map
 
 	// Check if the "question" key exists in the input map
 	if question, ok := queryToolResponsePrompt["question"]; ok {
+		// Fill the recalled memory last, so past text that happens to
+		// contain "{query}" is not replaced with the question.
 		message = utilities.CustomFormat(prePrompt.BasePrompt, map[string][]byte{"query": question})
+		message = utilities.CustomFormat(message, map[string][]byte{"recalled_memory": queryToolResponsePrompt["recalled_memory"]})
 		llmResponse, llmErr = prePrompt.BaseRunnableCaller(message)
 		if llmErr != nil {
 			return nil, nil, llmErr
@@ -65,26 +72,53 @@ func (prePrompt *AgentPreProgram) _callLanguageModel(queryToolResponsePrompt map
 	return []byte{}, []byte{}, nil
 }
 
-func _getToolReturn(agentTools map[string]tools.BaseTool, toolNames, action, actionInput string, toolMeta map[string]interface{}) (string, any, string, error) {
+func _getToolReturn(toolCtx *tools.ToolContext, agentTools map[string]tools.BaseTool, toolNames, action, actionInput string) (tools.Result, string, error) {
 	// Remove leading or trailing punctuation marks from action
 	action = strings.Trim(action, ".,!?;:'")
 	// Attempt to find the tool in the AllSnapshotTools map
 	tool, found := agentTools[action]
 	if !found {
 		// If the tool is not found, return an error message
-		return string(utilities.CustomFormat(wrongToolSelection, map[string][]byte{"tool": []byte(action), "name_of_tools": []byte(toolNames)})), nil, "", nil
+		return tools.Result{Output: string(utilities.CustomFormat(wrongToolSelection, map[string][]byte{"tool": []byte(action), "name_of_tools": []byte(toolNames)}))}, "", nil
 	}
-	// Execute the tool function with the given input and metadata
-	result, rawToolResponse, toolErr := tool.ToolFunc(actionInput, tool.Name, toolMeta)
+	// Check the input against the tool's schema, letting the model correct it
+	if err := tools.ValidateInput(tool, actionInput); err != nil {
+		return tools.Result{Output: string(utilities.CustomFormat(invalidToolInput, map[string][]byte{"tool": []byte(tool.Name), "error": []byte(err.Error())}))}, tool.Name, nil
+	}
+	// Execute the tool (or answer from its cache) with the given input and
+	// the run's tool context
+	result, toolErr := tool.Execute(toolCtx, actionInput)
 	if toolErr != nil {
-		return "", nil, "", toolErr
+		return tools.Result{}, tool.Name, toolErr
+	}
+	// Return the result and the tool's name
+	return result, tool.Name, nil
+
+}
+
+// reportedToolError returns the observation for a tool error that the tool's
+// Policy sends back to the model; ok is false when the error fails the run.
+func reportedToolError(agentTools map[string]tools.BaseTool, toolName string, err error) (observation string, ok bool) {
+	tool, found := agentTools[toolName]
+	if !found || !tool.Policy.ReportsError(err) {
+		return "", false
 	}
-	// Return the result, raw response, note data, and the tool's intent ID
-	return result, rawToolResponse, tool.Name, nil
+	return string(utilities.CustomFormat(toolFailure, map[string][]byte{"tool": []byte(toolName), "error": []byte(err.Error())})), true
+}
 
+// toolContext is the ToolContext the tools of one run receive.
+func (prePrompt *AgentPreProgram) toolContext(ctx context.Context, sessionId, runID string, span observability.RunHandle) *tools.ToolContext {
+	return &tools.ToolContext{
+		Context:   ctx,
+		SessionID: sessionId,
+		UserID:    prePrompt.UserId,
+		RunID:     runID,
+		Meta:      prePrompt.AdditionalToolsMeta,
+		Span:      span,
+	}
 }
 
-func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessionId string, maxIterations int, verbose bool) (result []byte, toolData any, execErr error) {
+func (prePrompt *AgentPreProgram) Executor(ctx context.Context, queryPrompt map[string][]byte, sessionId string, maxIterations int, verbose bool) (result []byte, toolData any, execErr error) {
 
 	var (
 		wg sync.WaitGroup
@@ -98,6 +132,8 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 
 		// Initialize a list to store the responses from the tools
 		toolResponseList []interface{}
+		// toolCalls records every tool run, saved with the turn
+		toolCalls []memory.ToolCall
 
 		callErr error
 		// Initialize the llm interface
@@ -109,7 +145,9 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 	if obs == nil {
 		obs = observability.Noop{}
 	}
+	runID := observability.NewRunID()
 	runHandle := obs.StartRun(observability.RunInfo{
+		RunID:     runID,
 		SessionID: sessionId,
 		Provider:  prePrompt.Provider,
 		Model:     prePrompt.Model,
@@ -119,6 +157,7 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 	defer func() {
 		runHandle.End(string(result), execErr)
 	}()
+	toolCtx := prePrompt.toolContext(ctx, sessionId, runID, runHandle)
 
 	// Loop guardrails.
 	if maxIterations <= 0 {
@@ -132,6 +171,7 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 	prePrompt.AIIdentity = []byte("\nAI: ")
 
 	clm = prePrompt
+	queryPrompt["recalled_memory"] = prePrompt.recallContext(sessionId, string(queryPrompt["question"]))
 	// Call the LLM with the query prompt and store the initial message and LLM's response
 	initMessage, llmResponse, callErr := clm._callLanguageModel(queryPrompt)
 
@@ -152,21 +192,8 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 		finish = bytes.ReplaceAll(finish, []byte("<answer>"), []byte(""))
 		finish = bytes.ReplaceAll(finish, []byte("</answer>"), []byte(""))
 
-		if prePrompt.ChatMemoryCollection != nil {
-			// Create local copies of variables needed in goroutine
-			memoryCollection := prePrompt.ChatMemoryCollection
-			questionCopy := string(queryPrompt["question"])
-			finishCopy := string(finish)
-			// Save the conversation to memory in a separate goroutine
-			wg.Add(1)
-			go func(collection *mongo.Collection, question, finishText string) {
-				defer wg.Done()
-				var mongoMemory mongodb.ChatMemoryCollectionInterface = mongodb.NewMongoCollection(collection)
-				mongoMemory.AddConversationToMemory(sessionId, question, finishText)
-
-			}(memoryCollection, questionCopy, finishCopy)
-
-		}
+		// Save the conversation to memory in a separate goroutine
+		prePrompt.saveConversation(&wg, sessionId, string(queryPrompt["question"]), string(finish), toolCalls)
 
 		if toolResponseList != nil {
 			return finish, toolResponseList, nil
@@ -190,7 +217,7 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 			actionReady = false
 			stopMsg := []byte("I've reached the maximum number of reasoning steps for this request without a final answer. Here is what I found so far, please refine your question if needed.")
 			runHandle.Error("max_iterations", nil)
-			prePrompt.saveConversation(&wg, sessionId, string(queryPrompt["question"]), string(stopMsg))
+			prePrompt.saveConversation(&wg, sessionId, string(queryPrompt["question"]), string(stopMsg), toolCalls)
 			return stopMsg, toolResponseList, nil
 		}
 
@@ -213,20 +240,8 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 			finish = bytes.ReplaceAll(finish, []byte("<answer>"), []byte(""))
 			finish = bytes.ReplaceAll(finish, []byte("</answer>"), []byte(""))
 
-			if prePrompt.ChatMemoryCollection != nil {
-				// Create local copies of variables needed in goroutine
-				memoryCollection := prePrompt.ChatMemoryCollection
-				questionCopy := string(queryPrompt["question"])
-				finishCopy := string(finish)
-				// Save the conversation to memory in a separate goroutine
-				wg.Add(1)
-				go func(collection *mongo.Collection, question, finishText string) {
-					defer wg.Done()
-					var mongoMemory mongodb.ChatMemoryCollectionInterface = mongodb.NewMongoCollection(collection)
-					mongoMemory.AddConversationToMemory(sessionId, question, finishText)
-
-				}(memoryCollection, questionCopy, finishCopy)
-			}
+			// Save the conversation to memory in a separate goroutine
+			prePrompt.saveConversation(&wg, sessionId, string(queryPrompt["question"]), string(finish), toolCalls)
 
 			if toolResponseList != nil {
 				return finish, toolResponseList, nil
@@ -250,24 +265,38 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 				actionReady = false
 				stopMsg := []byte("I kept trying the same action without making progress, so I stopped. Please refine your question.")
 				runHandle.Error("no_progress", nil)
-				prePrompt.saveConversation(&wg, sessionId, string(queryPrompt["question"]), string(stopMsg))
+				prePrompt.saveConversation(&wg, sessionId, string(queryPrompt["question"]), string(stopMsg), toolCalls)
 				return stopMsg, toolResponseList, nil
 			}
 
 			toolStart := time.Now()
-			toolResponse, rawToolResponse, toolName, err := _getToolReturn(prePrompt.Tools, prePrompt.ToolNames, string(action), string(agentActionTypes.AgentAction["Input"]), prePrompt.AdditionalToolsMeta)
+			toolResult, toolName, err := _getToolReturn(toolCtx, prePrompt.Tools, prePrompt.ToolNames, string(action), string(agentActionTypes.AgentAction["Input"]))
 
+			var toolErr error
 			if err != nil {
-				runHandle.Error("tool_execution", err)
-				return nil, nil, err
+				observation, reported := reportedToolError(prePrompt.Tools, toolName, err)
+				if !reported {
+					runHandle.Error("tool_execution", err)
+					return nil, nil, err
+				}
+				toolResult, toolErr = tools.Result{Output: observation}, err
 			}
+			toolResponse, rawToolResponse := toolResult.Output, toolResult.Raw
 
+			toolDuration := time.Since(toolStart)
 			runHandle.ToolEnd(observability.ToolCall{
 				Name:     toolName,
 				Input:    string(agentActionTypes.AgentAction["Input"]),
 				Output:   toolResponse,
-				Duration: time.Since(toolStart),
+				IsError:  toolErr != nil,
+				Duration: toolDuration,
+				CacheHit: toolResult.CacheHit,
 			})
+			calledName := toolName
+			if calledName == "" {
+				calledName = string(action) // unknown tool; toolResponse says so
+			}
+			toolCalls = append(toolCalls, memory.NewToolCall(calledName, string(agentActionTypes.AgentAction["Input"]), toolResponse, toolDuration, toolErr))
 
 			if verbose {
 				utilities.Printer("Observation: ", toolResponse, "purple")
@@ -306,18 +335,48 @@ func (prePrompt *AgentPreProgram) Executor(queryPrompt map[string][]byte, sessio
 	return nil, nil, nil
 }
 
-// saveConversation persists a question/answer pair to chat memory in the
-// background, mirroring the inline save used on the agent-finish paths. It is a
-// no-op when no memory collection is configured.
-func (prePrompt *AgentPreProgram) saveConversation(wg *sync.WaitGroup, sessionId, question, answer string) {
-	if prePrompt.ChatMemoryCollection == nil {
+// saveConversation persists a question/answer pair and the tool calls made
+// while answering to chat memory in the background and, with semantic recall
+// enabled, embeds it into the recall vector store; with user memory enabled,
+// it also updates the user's facts, and with a session manager, the session
+// record. It is a no-op when none of these is configured.
+func (prePrompt *AgentPreProgram) saveConversation(wg *sync.WaitGroup, sessionId, question, answer string, toolCalls []memory.ToolCall) {
+	if prePrompt.ChatMemory == nil && prePrompt.Recaller == nil && prePrompt.UserMemory == nil && prePrompt.Sessions == nil {
 		return
 	}
-	collection := prePrompt.ChatMemoryCollection
+	chatMemory, recaller, userMemory, userId := prePrompt.ChatMemory, prePrompt.Recaller, prePrompt.UserMemory, prePrompt.UserId
+	compactor, sessions := prePrompt.Compactor, prePrompt.Sessions
 	wg.Add(1)
-	go func(collection *mongo.Collection, q, a string) {
+	go func(turn memory.Turn) {
 		defer wg.Done()
-		var mongoMemory mongodb.ChatMemoryCollectionInterface = mongodb.NewMongoCollection(collection)
-		mongoMemory.AddConversationToMemory(sessionId, q, a)
-	}(collection, question, answer)
+		if chatMemory != nil {
+			memory.SaveTurn(chatMemory, sessionId, turn)
+			if compactor != nil && compactor.Async() && sessionId != "" {
+				compactor.CompactInBackground(sessionId, chatMemory)
+			}
+		}
+		if sessions != nil && sessionId != "" {
+			_, _ = sessions.Touch(context.Background(), sessionId, userId, turn)
+		}
+		if recaller != nil && sessionId != "" {
+			_ = recaller.Remember(context.Background(), sessionId, userId, turn)
+		}
+		if userMemory != nil && userId != "" {
+			_ = userMemory.Observe(context.Background(), userId, turn)
+		}
+	}(memory.Turn{Human: question, AI: answer, ToolCalls: toolCalls})
+}
+
+// recallContext returns older turns relevant to question for the
+// {recalled_memory} prompt slot. Recall is best-effort: on error the agent
+// answers without recalled context rather than failing the request.
+func (prePrompt *AgentPreProgram) recallContext(sessionId, question string) []byte {
+	if prePrompt.Recaller == nil || sessionId == "" {
+		return nil
+	}
+	recalled, err := prePrompt.Recaller.RecallContext(context.Background(), sessionId, prePrompt.UserId, question, string(prePrompt.BasePrompt))
+	if err != nil {
+		return nil
+	}
+	return []byte(recalled)
 }
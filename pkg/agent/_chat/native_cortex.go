@@ -1,13 +1,17 @@
 package _chat
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	ant "github.com/darksuit-ai/darksuitai/internal/llms/anthropic"
-	"github.com/darksuit-ai/darksuitai/internal/memory/mongodb"
+	"github.com/darksuit-ai/darksuitai/internal/memory"
 	"github.com/darksuit-ai/darksuitai/internal/observability"
 	"github.com/darksuit-ai/darksuitai/internal/utilities"
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
 )
 
 // NativeExecutor runs the agent using provider-side (native) structured tool
@@ -18,7 +22,7 @@ import (
 // configured ToolProtocol without any other changes. It currently supports the
 // Anthropic provider (migrated to the official SDK in Phase 1); callers are
 // expected to fall back to Executor for other providers.
-func (prePrompt *AgentPreProgram) NativeExecutor(queryPrompt map[string][]byte, sessionId string, maxIterations int, verbose bool) (result []byte, toolData any, execErr error) {
+func (prePrompt *AgentPreProgram) NativeExecutor(ctx context.Context, queryPrompt map[string][]byte, sessionId string, maxIterations int, verbose bool) (result []byte, toolData any, execErr error) {
 	question := string(queryPrompt["question"])
 
 	// Observability: start a run span and close it on every return path.
@@ -26,7 +30,9 @@ func (prePrompt *AgentPreProgram) NativeExecutor(queryPrompt map[string][]byte,
 	if obs == nil {
 		obs = observability.Noop{}
 	}
+	runID := observability.NewRunID()
 	runHandle := obs.StartRun(observability.RunInfo{
+		RunID:     runID,
 		SessionID: sessionId,
 		Provider:  prePrompt.Provider,
 		Model:     prePrompt.Model,
@@ -36,6 +42,7 @@ func (prePrompt *AgentPreProgram) NativeExecutor(queryPrompt map[string][]byte,
 	defer func() {
 		runHandle.End(string(result), execErr)
 	}()
+	toolCtx := prePrompt.toolContext(ctx, sessionId, runID, runHandle)
 
 	// Translate the registered tools into provider-agnostic specs.
 	specs := make([]ant.ToolSpec, 0, len(prePrompt.Tools))
@@ -50,29 +57,49 @@ func (prePrompt *AgentPreProgram) NativeExecutor(queryPrompt map[string][]byte,
 
 	// Collect raw tool metadata (matching Executor's toolData return shape).
 	var toolResponseList []interface{}
+	// toolCalls records every tool run, saved with the turn.
+	var toolCalls []memory.ToolCall
 
 	exec := func(name, input string) (string, bool) {
 		tool, found := prePrompt.Tools[name]
 		if !found {
-			return fmt.Sprintf("You tried to use the tool %q, but it doesn't exist. You must use any of these available tools: [%s].", name, prePrompt.ToolNames), true
+			msg := fmt.Sprintf("You tried to use the tool %q, but it doesn't exist. You must use any of these available tools: [%s].", name, prePrompt.ToolNames)
+			toolCalls = append(toolCalls, memory.NewToolCall(name, input, "", 0, fmt.Errorf("unknown tool %q", name)))
+			return msg, true
+		}
+		if err := tools.ValidateInput(tool, input); err != nil {
+			msg := fmt.Sprintf("The input you gave the tool %q is invalid: %v. Correct the input and use the tool again.", tool.Name, err)
+			toolCalls = append(toolCalls, memory.NewToolCall(tool.Name, input, "", 0, fmt.Errorf("invalid input: %w", err)))
+			runHandle.ToolEnd(observability.ToolCall{Name: tool.Name, Input: input, Output: msg, IsError: true})
+			return msg, true
 		}
 		start := time.Now()
-		result, rawToolResponse, toolErr := tool.ToolFunc(input, tool.Name, prePrompt.AdditionalToolsMeta)
+		toolResult, toolErr := tool.Execute(toolCtx, input)
+		result, rawToolResponse := toolResult.Output, toolResult.Raw
+		duration := time.Since(start)
+		toolCalls = append(toolCalls, memory.NewToolCall(tool.Name, input, result, duration, toolErr))
 		if toolErr != nil {
-			runHandle.ToolEnd(observability.ToolCall{Name: tool.Name, Input: input, Output: toolErr.Error(), IsError: true, Duration: time.Since(start)})
+			runHandle.ToolEnd(observability.ToolCall{Name: tool.Name, Input: input, Output: toolErr.Error(), IsError: true, Duration: duration})
 			return toolErr.Error(), true
 		}
-		runHandle.ToolEnd(observability.ToolCall{Name: tool.Name, Input: input, Output: result, Duration: time.Since(start)})
+		runHandle.ToolEnd(observability.ToolCall{Name: tool.Name, Input: input, Output: result, Duration: duration, CacheHit: toolResult.CacheHit})
 		toolResponseList = append(toolResponseList, map[string]interface{}{tool.Name: rawToolResponse})
 		return result, false
 	}
 
+	// Native mode carries no chat-history template, so recalled turns are
+	// appended to the system prompt instead.
+	system := string(prePrompt.RawSystemPrompt)
+	if recalled := prePrompt.recallContext(sessionId, question); len(recalled) > 0 {
+		system = strings.TrimSpace(system + "\n\n" + string(recalled))
+	}
+
 	cfg := ant.ToolLoopConfig{
 		APIKey:        string(prePrompt.APIKey),
 		Model:         prePrompt.Model,
 		MaxTokens:     prePrompt.MaxTokens,
 		Temperature:   prePrompt.Temperature,
-		System:        string(prePrompt.RawSystemPrompt),
+		System:        system,
 		MaxIterations: maxIterations,
 		Verbose:       verbose,
 	}
@@ -88,10 +115,9 @@ func (prePrompt *AgentPreProgram) NativeExecutor(queryPrompt map[string][]byte,
 	}
 
 	// Persist the exchange, mirroring Executor's memory behaviour.
-	if prePrompt.ChatMemoryCollection != nil {
-		var mongoMemory mongodb.ChatMemoryCollectionInterface = mongodb.NewMongoCollection(prePrompt.ChatMemoryCollection)
-		_ = mongoMemory.AddConversationToMemory(sessionId, question, finalText)
-	}
+	var wg sync.WaitGroup
+	prePrompt.saveConversation(&wg, sessionId, question, finalText, toolCalls)
+	wg.Wait()
 
 	if toolResponseList != nil {
 		return []byte(finalText), toolResponseList, nil
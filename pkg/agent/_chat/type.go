@@ -1,24 +1,24 @@
 package _chat
 
 import (
+	"github.com/darksuit-ai/darksuitai/internal/memory"
 	"github.com/darksuit-ai/darksuitai/internal/observability"
 	"github.com/darksuit-ai/darksuitai/pkg/tools"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AgentPreProgram struct {
-	BasePrompt           []byte
-	SystemPrompt         []byte
-	Tools                map[string]tools.BaseTool
-	ToolNames            string
-	AdditionalToolsMeta  map[string]interface{}
-	BaseRunnableCaller   func(prompt []byte) (string, error)
-	RunnableCaller       func(promptIterable []byte) (string, error)
-	AIIdentity           []byte
-	ChatMemoryCollection *mongo.Collection
-	MaxIteration         int
-	Verbose              bool
-	SessionId            string
+	BasePrompt          []byte
+	SystemPrompt        []byte
+	Tools               map[string]tools.BaseTool
+	ToolNames           string
+	AdditionalToolsMeta map[string]interface{}
+	BaseRunnableCaller  func(prompt []byte) (string, error)
+	RunnableCaller      func(promptIterable []byte) (string, error)
+	AIIdentity          []byte
+	ChatMemory          memory.ChatMemory
+	MaxIteration        int
+	Verbose             bool
+	SessionId           string
 
 	// Native tool-calling configuration (used when ToolProtocol == "native").
 	ToolProtocol string
@@ -36,4 +36,17 @@ type AgentPreProgram struct {
 	// Observer receives run/LLM/tool telemetry events. When nil, a no-op
 	// observer is used.
 	Observer observability.Observer
+
+	// Recaller, when set, fills the {recalled_memory} prompt slot with older
+	// turns relevant to the question and remembers each completed turn.
+	Recaller *memory.Recaller
+	UserId   string
+	// UserMemory, when set, learns durable facts about UserId from each
+	// completed turn.
+	UserMemory *memory.UserMemory
+	// Compactor is consulted after each saved turn: in async mode it compacts
+	// the session in the background.
+	Compactor *memory.Compactor
+	// Sessions, when set, records each saved turn on the session's record.
+	Sessions *memory.SessionManager
 }
@@ -2,11 +2,11 @@ package _stream
 
 import (
 	"context"
-	"crypto/sha256"
-	"fmt"
 	"strings"
+	"time"
 
-	"github.com/darksuit-ai/darksuitai/internal/memory/mongodb"
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+	"github.com/darksuit-ai/darksuitai/internal/observability"
 	"github.com/darksuit-ai/darksuitai/internal/utilities"
 	"github.com/darksuit-ai/darksuitai/pkg/agent"
 	"github.com/darksuit-ai/darksuitai/pkg/tools"
@@ -30,6 +30,10 @@ const (
 
 var wrongToolSelection = []byte(`You tried to use the tool {tool}, but it doesn't exist. You must use any of these available tools: [{name_of_tools}].`)
 
+var invalidToolInput = []byte(`The input you gave the tool {tool} is invalid: {error}. Correct the input and use the tool again.`)
+
+var toolFailure = []byte(`The tool {tool} failed: {error}. Use the tool again, use another tool, or answer without it.`)
+
 // _callLanguageModel is a method that belongs to the Synapse struct. It takes a map of strings to byte slices
 // as input and returns an LLMResult. The method performs the following steps:
 //
@@ -63,7 +67,10 @@ func (prePrompt *AgentPreProgram) _callLanguageModel(queryToolResponsePrompt map
 
 	// Check if the "question" key exists in the input map
 	if question, ok := queryToolResponsePrompt["question"]; ok {
+		// Fill the recalled memory last, so past text that happens to
+		// contain "{query}" is not replaced with the question.
 		message = utilities.CustomFormat(prePrompt.BasePrompt, map[string][]byte{"query": question})
+		message = utilities.CustomFormat(message, map[string][]byte{"recalled_memory": queryToolResponsePrompt["recalled_memory"]})
         go prePrompt.BaseRunnableCaller(message, llmBaseStream)
 		// Create a new LLMResult instance and return it
 		return LLMResult{Message: message, LLMResponse: llmBaseStream}
@@ -78,37 +85,50 @@ func (prePrompt *AgentPreProgram) _callLanguageModel(queryToolResponsePrompt map
 	return LLMResult{} // Return an empty LLMResult if neither "question" nor "plan" keys are present
 }
 
-func _getToolReturn(agentTools map[string]tools.BaseTool, toolNames, action, actionInput string, toolMeta map[string]interface{}) (string, any, string, error) {
+func _getToolReturn(toolCtx *tools.ToolContext, agentTools map[string]tools.BaseTool, toolNames, action, actionInput string) (tools.Result, string, error) {
 	// Remove leading or trailing punctuation marks from action
 	action = strings.Trim(action, ".,!?;:'")
 	// Attempt to find the tool in the AllSnapshotTools map
 	tool, found := agentTools[action]
 	if !found {
 		// If the tool is not found, return an error message
-		return string(utilities.CustomFormat(wrongToolSelection, map[string][]byte{"tool": []byte(action), "name_of_tools": []byte(toolNames)})), nil, "", nil
+		return tools.Result{Output: string(utilities.CustomFormat(wrongToolSelection, map[string][]byte{"tool": []byte(action), "name_of_tools": []byte(toolNames)}))}, "", nil
+	}
+	// Check the input against the tool's schema, letting the model correct it
+	if err := tools.ValidateInput(tool, actionInput); err != nil {
+		return tools.Result{Output: string(utilities.CustomFormat(invalidToolInput, map[string][]byte{"tool": []byte(tool.Name), "error": []byte(err.Error())}))}, tool.Name, nil
 	}
-	// Execute the tool function with the given input and metadata
-	result, rawToolResponse, toolErr := tool.ToolFunc(actionInput, tool.Name, toolMeta)
+	// Execute the tool (or answer from its cache) with the given input and
+	// the run's tool context
+	result, toolErr := tool.Execute(toolCtx, actionInput)
 	if toolErr != nil {
-		return "", nil, "", toolErr
+		return tools.Result{}, tool.Name, toolErr
 	}
-	// Return the result, raw response, note data, and the tool's intent ID
-	return result, rawToolResponse, tool.Name, nil
+	// Return the result and the tool's name
+	return result, tool.Name, nil
 
 }
 
-func (prePrompt *AgentPreProgram) StreamExecutor(queryPrompt map[string][]byte, writer *StreamWriter, maxIterations int, verbose bool) error {
+func (prePrompt *AgentPreProgram) StreamExecutor(parent context.Context, queryPrompt map[string][]byte, writer *StreamWriter, maxIterations int, verbose bool) error {
 
 	var (
 		initMessage           []byte
 		agentThoughtProcesses []byte
 		llmResponse           []byte
 		toolResponseList      []map[string]interface{}
+		toolCalls             []memory.ToolCall
 		clm                   callLLMInterface
 		actionReady           bool
 	)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
+	toolCtx := &tools.ToolContext{
+		Context:   ctx,
+		SessionID: prePrompt.SessionId,
+		UserID:    prePrompt.UserId,
+		RunID:     observability.NewRunID(),
+		Meta:      prePrompt.AdditionalToolsMeta,
+	}
 	defer writer.Close() // Ensure we close the stream writer when done
 	
 	prePrompt.AIIdentity = []byte("\nAI: ")
@@ -116,6 +136,7 @@ func (prePrompt *AgentPreProgram) StreamExecutor(queryPrompt map[string][]byte,
 	iterationCount := 0
 
 	clm = prePrompt
+	queryPrompt["recalled_memory"] = prePrompt.recallContext(string(queryPrompt["question"]))
 	llmStreamData := clm._callLanguageModel(queryPrompt)
 	initMessage = llmStreamData.Message
 	llmResponse, actionReady = _streamDifferentiator(ctx, writer, llmStreamData)
@@ -132,11 +153,24 @@ func (prePrompt *AgentPreProgram) StreamExecutor(queryPrompt map[string][]byte,
 		if action, exists := agentActionTypes.AgentAction["Action"]; exists {
 
 			// Get tool response
-			toolResponse, rawToolResponse, toolName, err := _getToolReturn(prePrompt.Tools, prePrompt.ToolNames, string(action), string(agentActionTypes.AgentAction["Input"]), prePrompt.AdditionalToolsMeta)
+			toolStart := time.Now()
+			toolResult, toolName, err := _getToolReturn(toolCtx, prePrompt.Tools, prePrompt.ToolNames, string(action), string(agentActionTypes.AgentAction["Input"]))
 
+			var toolErr error
 			if err != nil {
-				return err
+				observation, reported := reportedToolError(prePrompt.Tools, toolName, err)
+				if !reported {
+					return err
+				}
+				toolResult, toolErr = tools.Result{Output: observation}, err
 			}
+			toolResponse, rawToolResponse := toolResult.Output, toolResult.Raw
+
+			calledName := toolName
+			if calledName == "" {
+				calledName = string(action) // unknown tool; toolResponse says so
+			}
+			toolCalls = append(toolCalls, memory.NewToolCall(calledName, string(agentActionTypes.AgentAction["Input"]), toolResponse, time.Since(toolStart), toolErr))
 
 			if verbose {
 				utilities.Printer("Observation: ", toolResponse, "purple")
@@ -180,23 +214,68 @@ func (prePrompt *AgentPreProgram) StreamExecutor(queryPrompt map[string][]byte,
 			break
 		}
 	}
-return nil
+	// Save in the background so the stream closes once the answer is
+	// written: titling, recall and fact extraction may each call a model.
+	// The copy keeps the save on this run's memories if the agent is
+	// programmed again meanwhile.
+	saved := *prePrompt
+	go saved.SaveChatHistory(string(queryPrompt["question"]), streamedAnswer(writer), prePrompt.SessionId, toolCalls)
+	return nil
 }
 
-// hashToolResponse generates a SHA-256 hash of the given toolResponse and returns it as a hexadecimal string.
-func hashToolResponse(toolResponse string) string {
-	// Create a new SHA-256 hash.
-	h := sha256.New()
-	// Write the thought byte slice to the hash.
-	h.Write([]byte(toolResponse))
-	// Compute the final hash and return it as a hexadecimal string.
-	return fmt.Sprintf("%x", h.Sum(nil))
+// streamedAnswer recovers the answer text the writer streamed, without the
+// answer tags, so it can be saved with the turn.
+func streamedAnswer(w *StreamWriter) string {
+	s := w.Builder.String()
+	if i := strings.Index(s, "<answer>"); i >= 0 {
+		s = s[i+len("<answer>"):]
+	}
+	if i := strings.Index(s, "</answer>"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(strings.ReplaceAll(s, `\n`, "\n"))
 }
 
+// reportedToolError returns the observation for a tool error that the tool's
+// Policy sends back to the model; ok is false when the error fails the run.
+func reportedToolError(agentTools map[string]tools.BaseTool, toolName string, err error) (observation string, ok bool) {
+	tool, found := agentTools[toolName]
+	if !found || !tool.Policy.ReportsError(err) {
+		return "", false
+	}
+	return string(utilities.CustomFormat(toolFailure, map[string][]byte{"tool": []byte(toolName), "error": []byte(err.Error())})), true
+}
 
-func (prePrompt *AgentPreProgram) SaveChatHistory(query,finishText,sessionId string){
-	if prePrompt.ChatMemoryCollection != nil {
-		var mongoMemory mongodb.ChatMemoryCollectionInterface = mongodb.NewMongoCollection(prePrompt.ChatMemoryCollection)
-		mongoMemory.AddConversationToMemory(sessionId, query, finishText)
+// SaveChatHistory stores a streamed turn, with the tool calls made while
+// answering it, in chat memory and every other configured memory.
+func (prePrompt *AgentPreProgram) SaveChatHistory(query, finishText, sessionId string, toolCalls []memory.ToolCall) {
+	turn := memory.Turn{Human: query, AI: finishText, ToolCalls: toolCalls}
+	if prePrompt.ChatMemory != nil {
+		memory.SaveTurn(prePrompt.ChatMemory, sessionId, turn)
+		if prePrompt.Compactor != nil && prePrompt.Compactor.Async() && sessionId != "" {
+			prePrompt.Compactor.CompactInBackground(sessionId, prePrompt.ChatMemory)
+		}
+	}
+	if prePrompt.Sessions != nil && sessionId != "" {
+		_, _ = prePrompt.Sessions.Touch(context.Background(), sessionId, prePrompt.UserId, turn)
+	}
+	if prePrompt.Recaller != nil && sessionId != "" {
+		_ = prePrompt.Recaller.Remember(context.Background(), sessionId, prePrompt.UserId, turn)
+	}
+	if prePrompt.UserMemory != nil && prePrompt.UserId != "" {
+		_ = prePrompt.UserMemory.Observe(context.Background(), prePrompt.UserId, turn)
+	}
+}
+
+// recallContext returns older turns relevant to question for the
+// {recalled_memory} prompt slot; recall failures are ignored.
+func (prePrompt *AgentPreProgram) recallContext(question string) []byte {
+	if prePrompt.Recaller == nil || prePrompt.SessionId == "" {
+		return nil
+	}
+	recalled, err := prePrompt.Recaller.RecallContext(context.Background(), prePrompt.SessionId, prePrompt.UserId, question, string(prePrompt.BasePrompt))
+	if err != nil {
+		return nil
 	}
+	return []byte(recalled)
 }
\ No newline at end of file
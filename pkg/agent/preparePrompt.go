@@ -6,16 +6,14 @@ import (
 	"fmt"
 
 	"github.com/darksuit-ai/darksuitai/internal/memory"
-	"github.com/darksuit-ai/darksuitai/internal/memory/mongodb"
 	"github.com/darksuit-ai/darksuitai/internal/prompts"
 	"github.com/darksuit-ai/darksuitai/internal/utilities"
 	"github.com/darksuit-ai/darksuitai/pkg/tools"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // PromptAgentInterface defines the interface for preparing the prompt for the LLM.
 type PromptAgentInterface interface {
-	PreparePrompt(SystemPrompt []byte, ChatInstructionPrompt []byte, agentTools []tools.BaseTool, PromptKeys map[string][]byte, mongoCollection *mongo.Collection, sessionId string, compactor *memory.Compactor) ([]byte, []byte, map[string]tools.BaseTool, string, error)
+	PreparePrompt(SystemPrompt []byte, ChatInstructionPrompt []byte, agentTools []tools.BaseTool, PromptKeys map[string][]byte, chatMemory memory.ChatMemory, sessionId string, compactor *memory.Compactor, recaller *memory.Recaller, toolCallHistory bool) ([]byte, []byte, map[string]tools.BaseTool, string, error)
 }
 
 // PromptAgent is a struct that implements the PromptAgentInterface.
@@ -30,7 +28,7 @@ func NewPromptAgent() PromptAgentInterface {
 // PreparePrompt is a function that implements the MultiModalAgentInterface.
 // It prepares the prompt for the LLM (Language Learning Model) and returns the LLM and the prepared prompt.
 func (a *PromptAgent) PreparePrompt(SystemPrompt []byte, ChatInstructionPrompt []byte, agentTools []tools.BaseTool,
-	PromptKeys map[string][]byte, mongoCollection *mongo.Collection, sessionId string, compactor *memory.Compactor) ([]byte, []byte, map[string]tools.BaseTool, string, error) {
+	PromptKeys map[string][]byte, chatMemory memory.ChatMemory, sessionId string, compactor *memory.Compactor, recaller *memory.Recaller, toolCallHistory bool) ([]byte, []byte, map[string]tools.BaseTool, string, error) {
 
 	var (
 		chatHistory     bytes.Buffer
@@ -75,22 +73,43 @@ func (a *PromptAgent) PreparePrompt(SystemPrompt []byte, ChatInstructionPrompt [
 	promptMap["tool_names"] = []byte(tn)
 	promptMap["tools"] = []byte(tl)
 
-	// Inject chat history when a session and memory store are available. With a
+	// Inject chat history when a session and chat memory are available. With a
 	// compactor configured, use the compacted context (rolling summary + recent
-	// turns); otherwise fall back to the last-K raw transcript.
-	if sessionId != "" && mongoCollection != nil {
-		var mongoMemory mongodb.ChatMemoryCollectionInterface = mongodb.NewMongoCollection(mongoCollection)
+	// turns); otherwise fall back to the last-K raw transcript, listing each
+	// turn's tool calls when toolCallHistory is set.
+	if sessionId != "" && chatMemory != nil {
 		if compactor != nil {
-			if turns, retrieveErr := mongoMemory.RetrieveTurns(sessionId); retrieveErr == nil {
+			if windowed, ok := chatMemory.(memory.WindowedChatMemory); ok {
+				if turns, offset, retrieveErr := windowed.RetrieveWindow(sessionId); retrieveErr == nil {
+					if ctxStr, cErr := compactor.BuildContextWindow(context.Background(), sessionId, turns, offset); cErr == nil {
+						chatHistory.WriteString(ctxStr)
+					}
+				}
+			} else if turns, retrieveErr := chatMemory.RetrieveTurns(sessionId); retrieveErr == nil {
 				if ctxStr, cErr := compactor.BuildContext(context.Background(), sessionId, turns); cErr == nil {
 					chatHistory.WriteString(ctxStr)
 				}
 			}
+		} else if toolCallHistory {
+			if turns, retrieveErr := memory.RecentTurns(chatMemory, sessionId, 6); retrieveErr == nil {
+				chatHistory.WriteString(memory.RenderTurnsWithTools(turns))
+			}
 		} else {
-			if chatData, retrieveErr := mongoMemory.RetrieveMemoryWithK(sessionId, 6); retrieveErr == nil {
+			if chatData, retrieveErr := chatMemory.RetrieveMemoryWithK(sessionId, 6); retrieveErr == nil {
 				chatHistory.WriteString(chatData)
 			}
 		}
+	}
+	// With semantic recall enabled, leave a {recalled_memory} slot next to the
+	// history. It is filled per question by the executor, since the question
+	// is not known until Chat is called.
+	if sessionId != "" && recaller != nil {
+		if chatHistory.Len() > 0 {
+			chatHistory.WriteString("\n\n")
+		}
+		chatHistory.WriteString("{recalled_memory}")
+	}
+	if sessionId != "" && (chatMemory != nil || recaller != nil) {
 		promptMap["chat_history"] = chatHistory.Bytes()
 	}
 
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"github.com/darksuit-ai/darksuitai/internal/memory"
+	"github.com/darksuit-ai/darksuitai/internal/memory/mongodb"
 	"github.com/darksuit-ai/darksuitai/internal/observability"
 	"github.com/darksuit-ai/darksuitai/pkg/tools"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -11,7 +12,6 @@ import (
 type Synapse struct {
 	SystemPrompt          []byte // SysPrompt holds the system prompt for the agent.
 	ChatInstructionPrompt []byte // InstructPrompt holds the instructional prompt for the agent.
-	ToolNodes             []tools.BaseTool
 	PromptKeys            map[string][]byte
 	ModelType             map[string]string
 	MongoDB               *mongo.Collection
@@ -22,6 +22,8 @@ type Synapse struct {
 		StopSequences []string `json:"stop_sequences"`
 	}
 	APIKey []byte
+	// Tools holds the agent's tools and the metadata passed to them.
+	Tools *tools.Registry
 	// ToolProtocol selects the tool-calling protocol: "xml" (default) or
 	// "native" (Anthropic structured tool calling).
 	ToolProtocol string
@@ -30,6 +32,37 @@ type Synapse struct {
 	// Compactor, when set, replaces raw last-K memory retrieval with compacted
 	// context (rolling summary + recent turns) during prompt preparation.
 	Compactor *memory.Compactor
+	// ChatMemory, when set, takes precedence over MongoDB for storing and
+	// retrieving the conversation transcript.
+	ChatMemory memory.ChatMemory
+	// Recaller, when set, adds semantically relevant older turns to the prompt
+	// and remembers each completed turn.
+	Recaller *memory.Recaller
+	// UserMemory, when set, keeps durable facts about the user (identified by
+	// UserId) and adds them to the system prompt.
+	UserMemory *memory.UserMemory
+	// Sessions, when set, keeps a record (user, title, timestamps) of every
+	// session the agent saves turns to.
+	Sessions *memory.SessionManager
+	// ToolCallHistory renders earlier turns' tool calls into the chat
+	// history.
+	ToolCallHistory bool
+	// UserId identifies the end user across sessions; it scopes user-level
+	// recall and long-term user facts.
+	UserId string
+}
+
+// Memory returns the chat memory the agent should use: ChatMemory when set,
+// otherwise a MongoDB-backed memory over MongoDB, or nil when neither is
+// configured.
+func (ai Synapse) Memory() memory.ChatMemory {
+	if ai.ChatMemory != nil {
+		return ai.ChatMemory
+	}
+	if ai.MongoDB != nil {
+		return mongodb.NewMongoCollection(ai.MongoDB)
+	}
+	return nil
 }
 
 // AIResponse represents the structured response of an AI assistant
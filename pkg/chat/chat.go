@@ -23,3 +23,14 @@ func (ai AI) Chat(prompt string) (string, error) {
 	resp, err := llm.StreamCompleteChat(string(ai.APIKey), string(promptTemplate), string(ai.ChatSystemInstruction))
 	return resp, err
 }
+
+// Complete sends prompt with the given system instruction verbatim, without
+// applying the chat instruction template or prompt keys. Memory components
+// (fact extraction, summarization) use it to drive the configured model.
+func (ai AI) Complete(prompt, system string) (string, error) {
+	llm := ai.buildLLM()
+	if llm == nil {
+		return "", fmt.Errorf("no supported provider configured in ModelType")
+	}
+	return llm.StreamCompleteChat(string(ai.APIKey), prompt, system)
+}
@@ -0,0 +1,243 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClientConfig tunes a Client.
+type ClientConfig struct {
+	// Name and Version identify the client to servers. Name defaults to
+	// "darksuitai".
+	Name    string
+	Version string
+	// ToolPrefix is prepended to the names of the server's tools when they
+	// are converted to BaseTools, e.g. "github_", keeping names unique when
+	// several servers are attached to one agent.
+	ToolPrefix string
+	// Timeout bounds each tool call made through a converted BaseTool.
+	// Defaults to 60 seconds.
+	Timeout time.Duration
+}
+
+// Client is a connection to one MCP server. It connects lazily on first use,
+// reconnects when the connection drops (a request that fails because the
+// connection was lost is retried once on the new connection), and re-lists
+// the server's tools after the server announces a change. It is safe for
+// concurrent use.
+type Client struct {
+	transport Transport
+	cfg       ClientConfig
+
+	mu        sync.Mutex
+	connected bool
+	gen       int // incremented on every (re)connect
+	server    InitializeResult
+
+	toolsMu    sync.Mutex
+	tools      []Tool
+	toolsStale atomic.Bool
+}
+
+// NewClient returns a client for the server behind transport. It does not
+// connect until Connect or the first request.
+func NewClient(transport Transport, cfg ClientConfig) *Client {
+	if cfg.Name == "" {
+		cfg.Name = "darksuitai"
+	}
+	if cfg.Version == "" {
+		cfg.Version = "dev"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	c := &Client{transport: transport, cfg: cfg}
+	c.toolsStale.Store(true)
+	return c
+}
+
+// Connect starts the transport and initializes an MCP session. It is a no-op
+// when the client is already connected.
+func (c *Client) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connected {
+		return nil
+	}
+	return c.connectLocked(ctx)
+}
+
+func (c *Client) connectLocked(ctx context.Context) error {
+	if err := c.transport.Start(ctx, c.onNotify); err != nil {
+		return err
+	}
+	raw, err := c.transport.Call(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: c.cfg.Name, Version: c.cfg.Version},
+	})
+	if err != nil {
+		_ = c.transport.Close()
+		return fmt.Errorf("mcp: initialize: %w", err)
+	}
+	var server InitializeResult
+	if err := json.Unmarshal(raw, &server); err != nil {
+		_ = c.transport.Close()
+		return fmt.Errorf("mcp: decoding initialize result: %w", err)
+	}
+	if err := c.transport.Notify(ctx, "notifications/initialized", nil); err != nil {
+		_ = c.transport.Close()
+		return fmt.Errorf("mcp: initialized notification: %w", err)
+	}
+	c.server = server
+	c.connected = true
+	c.gen++
+	// A new session may serve a different tool list.
+	c.toolsStale.Store(true)
+	return nil
+}
+
+// Server returns what the server reported about itself when the session was
+// initialized.
+func (c *Client) Server() InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.server
+}
+
+// Close ends the session. The client reconnects if it is used again.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected {
+		return nil
+	}
+	c.connected = false
+	return c.transport.Close()
+}
+
+func (c *Client) onNotify(method string, _ json.RawMessage) {
+	if method == notifyToolsChanged {
+		c.toolsStale.Store(true)
+	}
+}
+
+// call sends a request, connecting first if needed and reconnecting once if
+// the connection was lost.
+func (c *Client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	gen, err := c.ensureConnected(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.transport.Call(ctx, method, params)
+	if !errors.Is(err, ErrClosed) {
+		return raw, err
+	}
+	if rerr := c.reconnect(ctx, gen); rerr != nil {
+		return nil, fmt.Errorf("%w (reconnect failed: %v)", err, rerr)
+	}
+	return c.transport.Call(ctx, method, params)
+}
+
+func (c *Client) ensureConnected(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected {
+		if err := c.connectLocked(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return c.gen, nil
+}
+
+// reconnect replaces the connection of generation gen; if another caller has
+// already replaced it, the new connection is kept.
+func (c *Client) reconnect(ctx context.Context, gen int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connected && c.gen != gen {
+		return nil
+	}
+	if c.connected {
+		_ = c.transport.Close()
+		c.connected = false
+	}
+	return c.connectLocked(ctx)
+}
+
+// Ping checks that the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.call(ctx, "ping", nil)
+	return err
+}
+
+// ListTools returns the server's tools. The list is cached until the server
+// sends notifications/tools/list_changed or the client reconnects.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	c.toolsMu.Lock()
+	defer c.toolsMu.Unlock()
+	if !c.toolsStale.Load() && c.tools != nil {
+		return c.tools, nil
+	}
+	return c.listToolsLocked(ctx)
+}
+
+// ToolsStale reports whether the cached tool list may be out of date: it has
+// not been listed yet, the server announced a change, or the client
+// reconnected since.
+func (c *Client) ToolsStale() bool {
+	return c.toolsStale.Load()
+}
+
+// RefreshTools fetches the server's tool list, bypassing the cache.
+func (c *Client) RefreshTools(ctx context.Context) ([]Tool, error) {
+	c.toolsMu.Lock()
+	defer c.toolsMu.Unlock()
+	return c.listToolsLocked(ctx)
+}
+
+func (c *Client) listToolsLocked(ctx context.Context) ([]Tool, error) {
+	// Cleared before listing, so a change announced while listing is not lost.
+	c.toolsStale.Store(false)
+	all := []Tool{}
+	cursor := ""
+	for {
+		raw, err := c.call(ctx, "tools/list", listToolsParams{Cursor: cursor})
+		if err != nil {
+			c.toolsStale.Store(true)
+			return nil, fmt.Errorf("mcp: tools/list: %w", err)
+		}
+		var page listToolsResult
+		if err := json.Unmarshal(raw, &page); err != nil {
+			c.toolsStale.Store(true)
+			return nil, fmt.Errorf("mcp: decoding tools/list result: %w", err)
+		}
+		all = append(all, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	c.tools = all
+	return all, nil
+}
+
+// CallTool runs a tool on the server. arguments is a JSON object (nil for
+// none). A failure reported by the tool itself is returned as a result with
+// IsError set, not as an error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	raw, err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: arguments})
+	if err != nil {
+		return nil, fmt.Errorf("mcp: tools/call %s: %w", name, err)
+	}
+	var result CallToolResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("mcp: decoding tools/call result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,307 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// TestMain lets the test binary double as a stub MCP server on stdio.
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_STUB_SERVER") == "1" {
+		serveStdio(newStub())
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// stub is a minimal MCP server: echo, add, fail, crash and grow tools, with
+// tools/list paginated two tools at a time.
+type stub struct {
+	mu    sync.Mutex
+	tools []Tool
+	inits int
+	calls []string
+}
+
+func newStub() *stub {
+	str := map[string]any{"type": "string"}
+	num := map[string]any{"type": "number"}
+	return &stub{tools: []Tool{
+		{Name: "echo", Description: "Echoes text.", InputSchema: map[string]any{"type": "object", "properties": map[string]any{"text": str}, "required": []any{"text"}}},
+		{Name: "add", Description: "Adds two numbers.", InputSchema: map[string]any{"type": "object", "properties": map[string]any{"a": num, "b": num}, "required": []any{"a", "b"}}},
+		{Name: "fail", Description: "Always fails.", InputSchema: map[string]any{"type": "object"}},
+		{Name: "crash", Description: "Kills the server.", InputSchema: map[string]any{"type": "object"}},
+		{Name: "grow", Description: "Adds a tool.", InputSchema: map[string]any{"type": "object"}},
+	}}
+}
+
+// handle answers one message, returning the reply (nil for notifications)
+// and any notifications to send before it.
+func (s *stub) handle(msg *message) (*message, []*message) {
+	if !msg.isRequest() {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := &message{JSONRPC: jsonrpcVersion, ID: msg.ID}
+	result := func(v any) { reply.Result, _ = json.Marshal(v) }
+	var notes []*message
+
+	switch msg.Method {
+	case "initialize":
+		s.inits++
+		result(InitializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: Implementation{Name: "stub", Version: "1"}})
+	case "ping":
+		result(map[string]any{})
+	case "tools/list":
+		var p listToolsParams
+		_ = json.Unmarshal(msg.Params, &p)
+		start := 0
+		fmt.Sscanf(p.Cursor, "%d", &start)
+		end := min(start+2, len(s.tools))
+		page := listToolsResult{Tools: s.tools[start:end]}
+		if end < len(s.tools) {
+			page.NextCursor = fmt.Sprint(end)
+		}
+		result(page)
+	case "tools/call":
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		_ = json.Unmarshal(msg.Params, &p)
+		s.calls = append(s.calls, p.Name)
+		switch p.Name {
+		case "echo":
+			result(CallToolResult{Content: []Content{TextContent(fmt.Sprint(p.Arguments["text"]))}})
+		case "add":
+			a, _ := p.Arguments["a"].(float64)
+			b, _ := p.Arguments["b"].(float64)
+			result(CallToolResult{Content: []Content{TextContent(fmt.Sprint(a + b))}, StructuredContent: map[string]any{"sum": a + b}})
+		case "fail":
+			result(CallToolResult{Content: []Content{TextContent("disk full")}, IsError: true})
+		case "crash":
+			os.Exit(1)
+		case "grow":
+			s.tools = append(s.tools, Tool{Name: "late", InputSchema: map[string]any{"type": "object"}})
+			notes = append(notes, &message{JSONRPC: jsonrpcVersion, Method: notifyToolsChanged})
+			result(CallToolResult{Content: []Content{TextContent("grown")}})
+		default:
+			reply.Error = &RPCError{Code: CodeInvalidParams, Message: "unknown tool " + p.Name}
+		}
+	default:
+		reply.Error = &RPCError{Code: CodeMethodNotFound, Message: msg.Method}
+	}
+	return reply, notes
+}
+
+func serveStdio(s *stub) {
+	out := json.NewEncoder(os.Stdout)
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		var msg message
+		if json.Unmarshal(sc.Bytes(), &msg) != nil {
+			continue
+		}
+		reply, notes := s.handle(&msg)
+		for _, n := range notes {
+			_ = out.Encode(n)
+		}
+		if reply != nil {
+			_ = out.Encode(reply)
+		}
+	}
+}
+
+// httpStub serves a stub over streamable HTTP, answering with an event
+// stream when there are notifications to send. Sessions can be expired to
+// exercise reconnection.
+type httpStub struct {
+	*stub
+	mu       sync.Mutex
+	sessions map[string]bool
+	next     int
+}
+
+func (h *httpStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var msg message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	if msg.Method == "initialize" {
+		h.next++
+		id := fmt.Sprintf("session-%d", h.next)
+		h.sessions[id] = true
+		w.Header().Set(headerSessionID, id)
+	} else if !h.sessions[r.Header.Get(headerSessionID)] {
+		h.mu.Unlock()
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	h.mu.Unlock()
+
+	reply, notes := h.handle(&msg)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if len(notes) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(reply)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, m := range append(notes, reply) {
+		data, _ := json.Marshal(m)
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+	}
+}
+
+func (h *httpStub) expireSessions() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions = map[string]bool{}
+}
+
+func newHTTPStub(t *testing.T) (*httpStub, *Client) {
+	t.Helper()
+	h := &httpStub{stub: newStub(), sessions: map[string]bool{}}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c := NewClient(NewHTTPTransport(srv.URL), ClientConfig{ToolPrefix: "stub_"})
+	t.Cleanup(func() { c.Close() })
+	return h, c
+}
+
+func toolByName(t *testing.T, list []tools.BaseTool, name string) tools.BaseTool {
+	t.Helper()
+	for _, tool := range list {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	t.Fatalf("tool %s not found", name)
+	return tools.BaseTool{}
+}
+
+func TestClient_HTTPTools(t *testing.T) {
+	_, c := newHTTPStub(t)
+	ctx := context.Background()
+	list, err := c.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 5 {
+		t.Fatalf("got %d tools, want 5 (paginated)", len(list))
+	}
+	if got := c.Server().ServerInfo.Name; got != "stub" {
+		t.Errorf("server name = %q", got)
+	}
+
+	add := toolByName(t, list, "stub_add")
+	if add.InputSchema["a"] == nil || strings.Join(add.Required, ",") != "a,b" {
+		t.Errorf("schema not converted: %v %v", add.InputSchema, add.Required)
+	}
+	out, raw, err := add.ToolFunc(`{"a":2,"b":3.5}`, add.Name, nil)
+	if err != nil || out != "5.5" {
+		t.Fatalf("add = %q, %v", out, err)
+	}
+	if res, ok := raw[0].(*CallToolResult); !ok || res.StructuredContent == nil {
+		t.Errorf("raw result = %#v", raw)
+	}
+
+	echo := toolByName(t, list, "stub_echo")
+	if out, _, err := echo.ToolFunc("hello from ReAct", echo.Name, nil); err != nil || out != "hello from ReAct" {
+		t.Errorf("plain-text echo = %q, %v", out, err)
+	}
+
+	fail := toolByName(t, list, "stub_fail")
+	if _, _, err := fail.ToolFunc("", fail.Name, nil); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("tool error = %v", err)
+	}
+}
+
+func TestClient_ToolListRefresh(t *testing.T) {
+	h, c := newHTTPStub(t)
+	ctx := context.Background()
+	list, err := c.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cached: no change announced yet.
+	if again, _ := c.Tools(ctx); len(again) != len(list) {
+		t.Fatalf("cached list changed")
+	}
+	grow := toolByName(t, list, "stub_grow")
+	if c.ToolsStale() {
+		t.Fatal("freshly listed tools reported stale")
+	}
+	if _, _, err := grow.ToolFunc("", grow.Name, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !c.ToolsStale() {
+		t.Fatal("tools/list_changed did not mark the tools stale")
+	}
+	list, err = c.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolByName(t, list, "stub_late")
+
+	// An expired session is replaced transparently.
+	h.expireSessions()
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("ping after expiry: %v", err)
+	}
+	if h.inits != 2 {
+		t.Errorf("initialized %d times, want 2", h.inits)
+	}
+}
+
+func TestClient_StdioReconnect(t *testing.T) {
+	transport := NewStdioTransport(os.Args[0])
+	transport.Env = []string{"MCP_STUB_SERVER=1"}
+	c := NewClient(transport, ClientConfig{})
+	defer c.Close()
+	ctx := context.Background()
+
+	list, err := c.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo := toolByName(t, list, "echo")
+	if out, _, err := echo.ToolFunc(`{"text":"one"}`, echo.Name, nil); err != nil || out != "one" {
+		t.Fatalf("echo = %q, %v", out, err)
+	}
+
+	crash := toolByName(t, list, "crash")
+	if _, _, err := crash.ToolFunc("", crash.Name, nil); err == nil {
+		t.Fatal("crash returned no error")
+	}
+	// The server process is gone; the next call starts a new one.
+	if out, _, err := echo.ToolFunc(`{"text":"two"}`, echo.Name, nil); err != nil || out != "two" {
+		t.Fatalf("echo after crash = %q, %v", out, err)
+	}
+}
+
+func TestClient_RPCError(t *testing.T) {
+	_, c := newHTTPStub(t)
+	_, err := c.CallTool(context.Background(), "missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "MCP-Protocol-Version"
+)
+
+// HTTPTransport talks to an MCP server over the streamable HTTP transport:
+// every message is POSTed to one endpoint, and the server answers with JSON
+// or with a server-sent event stream that may carry notifications before the
+// response.
+type HTTPTransport struct {
+	URL string
+	// Header is added to every request, e.g. for an Authorization token.
+	Header http.Header
+	// Client sends the requests; http.DefaultClient when nil. Per-call
+	// deadlines come from the context.
+	Client *http.Client
+
+	nextID atomic.Int64
+
+	mu        sync.Mutex
+	started   bool
+	notify    func(string, json.RawMessage)
+	sessionID string
+	version   string
+}
+
+// NewHTTPTransport returns a transport for the MCP endpoint at url, e.g.
+// "http://localhost:8080/mcp".
+func NewHTTPTransport(url string) *HTTPTransport {
+	return &HTTPTransport{URL: url}
+}
+
+// Start prepares a new session; the server assigns its ID on initialize.
+func (t *HTTPTransport) Start(_ context.Context, notify func(string, json.RawMessage)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started, t.notify, t.sessionID, t.version = true, notify, "", ""
+	return nil
+}
+
+// Call POSTs a request and waits for its response.
+func (t *HTTPTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	msg, err := newMessage(method, params)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(t.nextID.Add(1), 10)
+	msg.ID = json.RawMessage(id)
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	reply, err := t.readReply(resp, id)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+	if method == "initialize" {
+		var init InitializeResult
+		if json.Unmarshal(reply.Result, &init) == nil {
+			t.mu.Lock()
+			t.version = init.ProtocolVersion
+			t.mu.Unlock()
+		}
+	}
+	return reply.Result, nil
+}
+
+// Notify POSTs a notification.
+func (t *HTTPTransport) Notify(ctx context.Context, method string, params any) error {
+	msg, err := newMessage(method, params)
+	if err != nil {
+		return err
+	}
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Close ends the session on the server.
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.started, t.sessionID = false, ""
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerSessionID, sessionID)
+	if resp, err := t.client().Do(req); err == nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (t *HTTPTransport) client() *http.Client {
+	if t.Client != nil {
+		return t.Client
+	}
+	return http.DefaultClient
+}
+
+// post sends msg and returns the server's successful response. Transport
+// failures, including an expired session, are wrapped in ErrClosed.
+func (t *HTTPTransport) post(ctx context.Context, msg *message) (*http.Response, error) {
+	t.mu.Lock()
+	started, sessionID, version := t.started, t.sessionID, t.version
+	t.mu.Unlock()
+	if !started {
+		return nil, ErrClosed
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set(headerSessionID, sessionID)
+	}
+	if version != "" {
+		req.Header.Set(headerProtocolVersion, version)
+	}
+
+	resp, err := t.client().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: session %s expired", ErrClosed, sessionID)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%w: HTTP %d: %s", ErrClosed, resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	if id := resp.Header.Get(headerSessionID); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// readReply reads the response to request id from a JSON body or an event
+// stream, passing any notifications on the stream to the notify callback.
+func (t *HTTPTransport) readReply(resp *http.Response, id string) (*message, error) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var reply message
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			return nil, fmt.Errorf("%w: decoding response: %v", ErrClosed, err)
+		}
+		return &reply, nil
+	}
+
+	t.mu.Lock()
+	notify := t.notify
+	t.mu.Unlock()
+	var reply *message
+	err := readEvents(resp.Body, func(data []byte) bool {
+		var msg message
+		if json.Unmarshal(data, &msg) != nil {
+			return true
+		}
+		switch {
+		case msg.isResponse() && string(msg.ID) == id:
+			reply = &msg
+			return false
+		case msg.isNotification() && notify != nil:
+			notify(msg.Method, msg.Params)
+		}
+		return true
+	})
+	if reply != nil {
+		return reply, nil
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, fmt.Errorf("%w: event stream ended without a response: %v", ErrClosed, err)
+}
+
+// readEvents calls fn with the data of each server-sent event in r until fn
+// returns false or r ends.
+func readEvents(r io.Reader, fn func(data []byte) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data bytes.Buffer
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if !fn(data.Bytes()) {
+					return nil
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if data.Len() > 0 && !fn(data.Bytes()) {
+		return nil
+	}
+	return sc.Err()
+}
@@ -0,0 +1,134 @@
+// Package mcp connects agents to Model Context Protocol servers. A Client
+// speaks MCP over a Transport (a subprocess's stdio or streamable HTTP), lists
+// the server's tools and turns each into a tools.BaseTool that runs the tool
+// on the server, so existing MCP servers can be mounted on any agent.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision the client asks for when it
+// initializes a session.
+const ProtocolVersion = "2025-06-18"
+
+const jsonrpcVersion = "2.0"
+
+// message is a JSON-RPC 2.0 request, notification or response. Requests carry
+// an ID and a Method, notifications only a Method, responses an ID and either
+// a Result or an Error.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m *message) isResponse() bool     { return m.Method == "" && len(m.ID) > 0 }
+func (m *message) isNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+func (m *message) isRequest() bool      { return m.Method != "" && len(m.ID) > 0 }
+
+// JSON-RPC error codes used by MCP.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// RPCError is an error returned by the MCP server for a request. Unlike
+// transport errors, it never triggers a reconnect.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp: server error %d: %s", e.Code, e.Message)
+}
+
+// Implementation names an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+// InitializeResult is the server's reply to initialize.
+type InitializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	// Instructions optionally tells the client how to use the server.
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// Tool is a tool advertised by an MCP server.
+type Tool struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// InputSchema is the JSON schema of the tool's arguments, an object
+	// schema with "properties" and "required".
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type listToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// CallToolResult is the result of tools/call. IsError marks a failure
+// reported by the tool itself, as opposed to a protocol error.
+type CallToolResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// Content is one item of a tool result: "text", "image", "audio",
+// "resource" or "resource_link".
+type Content struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	Data     string    `json:"data,omitempty"`
+	MimeType string    `json:"mimeType,omitempty"`
+	URI      string    `json:"uri,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Resource *Resource `json:"resource,omitempty"`
+}
+
+// Resource is the embedded resource of a "resource" content item.
+type Resource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// TextContent returns a "text" content item.
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
+
+// Notification methods the client reacts to.
+const notifyToolsChanged = "notifications/tools/list_changed"
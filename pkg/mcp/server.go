@@ -0,0 +1,453 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// ServerConfig describes a Server to the hosts that connect to it.
+type ServerConfig struct {
+	// Name and Version identify the server. Name defaults to "darksuitai".
+	Name    string
+	Version string
+	// Instructions optionally tells hosts how to use the server's tools.
+	Instructions string
+	// Meta is passed to every tool's ToolFunc, as an agent passes its
+	// registry's metadata.
+	Meta map[string]interface{}
+	// SessionIdleTimeout ends an HTTP session that has not been used for
+	// this long; the host must initialize again. It defaults to 30 minutes.
+	SessionIdleTimeout time.Duration
+
+	// AllowedOrigins lists the browser origins (e.g.
+	// "https://app.example.com") allowed to call the HTTP endpoint, or "*"
+	// for any. Requests without an Origin header, which browsers always
+	// send, and requests from localhost origins are allowed; any other
+	// origin is refused, so a web page cannot reach a local server through
+	// DNS rebinding.
+	AllowedOrigins []string
+	// Authorize, when set, is called on every HTTP request and refuses it
+	// (401) when it returns an error. The user ID it returns is passed to
+	// tools as ToolContext.UserID, and a session may only be used by the
+	// user who opened it. Without it the HTTP endpoint is open to anyone
+	// who can reach it: bind it to localhost or put authentication in front
+	// of it. Stdio is not checked, as the host started the process.
+	Authorize func(r *http.Request) (userID string, err error)
+}
+
+// Server publishes BaseTools over MCP, so MCP hosts (IDE assistants, desktop
+// chat apps, other agents) can call them. Serve it over stdio with
+// ServeStdio, or over streamable HTTP by mounting it as an http.Handler. It
+// is safe for concurrent use, and tools can be added while it is serving.
+type Server struct {
+	cfg ServerConfig
+
+	mu    sync.RWMutex
+	tools map[string]tools.BaseTool
+	// listeners are notified when the tool list changes, one per stdio
+	// connection.
+	listeners map[int]func()
+	nextID    int
+
+	sessionsMu sync.Mutex
+	sessions   map[string]httpSession
+}
+
+// httpSession is an HTTP session: who opened it and when it was last used.
+type httpSession struct {
+	userID   string
+	lastUsed time.Time
+}
+
+// NewServer returns a server with no tools.
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.Name == "" {
+		cfg.Name = "darksuitai"
+	}
+	if cfg.Version == "" {
+		cfg.Version = "dev"
+	}
+	if cfg.SessionIdleTimeout <= 0 {
+		cfg.SessionIdleTimeout = 30 * time.Minute
+	}
+	return &Server{
+		cfg:       cfg,
+		tools:     make(map[string]tools.BaseTool),
+		listeners: make(map[int]func()),
+		sessions:  make(map[string]httpSession),
+	}
+}
+
+// AddTools publishes tools, replacing any tool with the same name. Connected
+// stdio hosts are told that the tool list changed.
+func (s *Server) AddTools(ts ...tools.BaseTool) {
+	s.mu.Lock()
+	for _, t := range ts {
+		s.tools[t.Name] = t
+	}
+	s.mu.Unlock()
+	s.toolsChanged()
+}
+
+// RemoveTool stops publishing a tool.
+func (s *Server) RemoveTool(name string) {
+	s.mu.Lock()
+	_, ok := s.tools[name]
+	delete(s.tools, name)
+	s.mu.Unlock()
+	if ok {
+		s.toolsChanged()
+	}
+}
+
+func (s *Server) toolsChanged() {
+	s.mu.RLock()
+	listeners := make([]func(), 0, len(s.listeners))
+	for _, l := range s.listeners {
+		listeners = append(listeners, l)
+	}
+	s.mu.RUnlock()
+	for _, l := range listeners {
+		l()
+	}
+}
+
+// listTools returns the published tools in MCP form, sorted by name.
+func (s *Server) listTools() []Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Tool, 0, len(s.tools))
+	for _, t := range s.tools {
+		out = append(out, toolSpec(t))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// toolSpec converts a BaseTool to its MCP description. Tools without an
+// InputSchema take a single required "input" string, as with native tool
+// calling.
+func toolSpec(t tools.BaseTool) Tool {
+	properties, required := t.InputSchema, t.Required
+	if properties == nil {
+		properties = map[string]any{"input": map[string]any{"type": "string", "description": "The input to the tool."}}
+		required = []string{"input"}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return Tool{Name: t.Name, Description: t.Description, InputSchema: schema}
+}
+
+// callTool runs a published tool. Unknown tools are protocol errors; invalid
+// input and tool failures are reported in the result, for the model to see.
+func (s *Server) callTool(tc *tools.ToolContext, params callToolParams) (*CallToolResult, *RPCError) {
+	s.mu.RLock()
+	tool, ok := s.tools[params.Name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+
+	input := string(params.Arguments)
+	if len(params.Arguments) == 0 || string(params.Arguments) == "null" {
+		input = "{}"
+	}
+	if tool.InputSchema == nil {
+		var args struct {
+			Input *string `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(input), &args); err != nil || args.Input == nil {
+			return errorResult(`invalid input: a string "input" argument is required`), nil
+		}
+		input = *args.Input
+	} else if err := tools.ValidateInput(tool, input); err != nil {
+		return errorResult("invalid input: " + err.Error()), nil
+	}
+
+	result, err := tool.Execute(tc, input)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	return &CallToolResult{Content: []Content{TextContent(result.Output)}}, nil
+}
+
+func errorResult(text string) *CallToolResult {
+	return &CallToolResult{Content: []Content{TextContent(text)}, IsError: true}
+}
+
+// handle answers one message; it returns nil for notifications and
+// responses. Tools run with ctx and the host's session and user IDs.
+func (s *Server) handle(ctx context.Context, sessionID, userID string, msg *message) *message {
+	if !msg.isRequest() {
+		return nil
+	}
+	reply := &message{JSONRPC: jsonrpcVersion, ID: msg.ID}
+	var result any
+	switch msg.Method {
+	case "initialize":
+		var p initializeParams
+		_ = json.Unmarshal(msg.Params, &p)
+		version := p.ProtocolVersion
+		if version == "" {
+			version = ProtocolVersion
+		}
+		result = InitializeResult{
+			ProtocolVersion: version,
+			Capabilities:    map[string]any{"tools": map[string]any{"listChanged": true}},
+			ServerInfo:      Implementation{Name: s.cfg.Name, Version: s.cfg.Version},
+			Instructions:    s.cfg.Instructions,
+		}
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		result = listToolsResult{Tools: s.listTools()}
+	case "tools/call":
+		var p callToolParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil || p.Name == "" {
+			reply.Error = &RPCError{Code: CodeInvalidParams, Message: "tools/call needs a tool name"}
+			return reply
+		}
+		res, rpcErr := s.callTool(&tools.ToolContext{Context: ctx, SessionID: sessionID, UserID: userID, Meta: s.cfg.Meta}, p)
+		if rpcErr != nil {
+			reply.Error = rpcErr
+			return reply
+		}
+		result = res
+	default:
+		reply.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+		return reply
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		reply.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		return reply
+	}
+	reply.Result = raw
+	return reply
+}
+
+// ServeStdio serves one host over newline-delimited JSON-RPC on r and w
+// (normally os.Stdin and os.Stdout) until r ends or ctx is cancelled. The
+// connection is one session, with an ID of its own passed to tools. Tool
+// calls run concurrently. Anything else the process writes to stdout
+// corrupts the stream, so log to stderr.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	var wmu sync.Mutex
+	write := func(msg *message) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return
+		}
+		wmu.Lock()
+		defer wmu.Unlock()
+		_, _ = w.Write(append(data, '\n'))
+	}
+
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = func() { write(&message{JSONRPC: jsonrpcVersion, Method: notifyToolsChanged}) }
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, id)
+		s.mu.Unlock()
+	}()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case line := <-lines:
+			var msg message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				write(&message{JSONRPC: jsonrpcVersion, ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: err.Error()}})
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if reply := s.handle(ctx, sessionID, "", &msg); reply != nil {
+					write(reply)
+				}
+			}()
+		}
+	}
+}
+
+// ServeHTTP implements the streamable HTTP transport: hosts POST JSON-RPC
+// messages and get JSON responses. A session ID is issued on initialize and
+// required afterwards; DELETE ends the session, as does leaving it idle for
+// ServerConfig.SessionIdleTimeout. The server does not open event streams,
+// so GET is refused. Requests are checked against
+// ServerConfig.AllowedOrigins and ServerConfig.Authorize first.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	var userID string
+	if s.cfg.Authorize != nil {
+		var err error
+		if userID, err = s.cfg.Authorize(r); err != nil {
+			http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		sessionID := r.Header.Get(headerSessionID)
+		s.sessionsMu.Lock()
+		if session, ok := s.sessions[sessionID]; ok && session.userID == userID {
+			delete(s.sessions, sessionID)
+		}
+		s.sessionsMu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var msg message
+	if err := json.NewDecoder(io.LimitReader(r.Body, 16<<20)).Decode(&msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, &message{JSONRPC: jsonrpcVersion, ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: err.Error()}})
+		return
+	}
+	sessionID := r.Header.Get(headerSessionID)
+	if msg.Method == "initialize" {
+		id, err := newSessionID()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.sessionsMu.Lock()
+		s.expireSessions()
+		s.sessions[id] = httpSession{userID: userID, lastUsed: time.Now()}
+		s.sessionsMu.Unlock()
+		w.Header().Set(headerSessionID, id)
+		sessionID = id
+	} else {
+		s.sessionsMu.Lock()
+		session, known := s.sessions[sessionID]
+		if known && time.Since(session.lastUsed) > s.cfg.SessionIdleTimeout {
+			delete(s.sessions, sessionID)
+			known = false
+		}
+		// Another user's session is treated as unknown.
+		known = known && session.userID == userID
+		if known {
+			session.lastUsed = time.Now()
+			s.sessions[sessionID] = session
+		}
+		s.sessionsMu.Unlock()
+		if !known {
+			http.Error(w, "unknown or missing "+headerSessionID, http.StatusNotFound)
+			return
+		}
+	}
+
+	reply := s.handle(r.Context(), sessionID, userID, &msg)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, http.StatusOK, reply)
+}
+
+// originAllowed reports whether a request with the given Origin header may
+// use the HTTP endpoint.
+func (s *Server) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// expireSessions forgets idle sessions. It runs on each initialize, so the
+// session table stays bounded by the sessions opened within the idle
+// timeout. The caller holds sessionsMu.
+func (s *Server) expireSessions() {
+	for id, session := range s.sessions {
+		if time.Since(session.lastUsed) > s.cfg.SessionIdleTimeout {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, msg *message) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(msg)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
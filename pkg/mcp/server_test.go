@@ -0,0 +1,287 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+type greetInput struct {
+	Name  string `json:"name" jsonschema:"required"`
+	Times int    `json:"times,omitempty" jsonschema:"minimum=1,maximum=3"`
+}
+
+func newTestServer() *Server {
+	s := NewServer(ServerConfig{Name: "test-server"})
+	s.AddTools(
+		tools.NewTypedTool("greet", "Greets someone.", func(_ context.Context, in greetInput) (string, error) {
+			return strings.TrimSpace(strings.Repeat("hello "+in.Name+" ", max(in.Times, 1))), nil
+		}),
+		tools.BaseTool{
+			Name:        "upper",
+			Description: "Upper-cases text.",
+			ToolFunc: func(input, _ string, _ map[string]interface{}) (string, []interface{}, error) {
+				if input == "" {
+					return "", nil, errors.New("nothing to upper-case")
+				}
+				return strings.ToUpper(input), nil, nil
+			},
+		},
+	)
+	return s
+}
+
+// pipeTransport connects a Client to Server.ServeStdio in-process.
+type pipeTransport struct {
+	server *Server
+	conn   *streamConn
+	stop   func()
+}
+
+func (p *pipeTransport) Start(_ context.Context, notify func(string, json.RawMessage)) error {
+	toServer, clientOut := io.Pipe()
+	clientIn, fromServer := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = p.server.ServeStdio(ctx, toServer, fromServer)
+		fromServer.Close()
+	}()
+	p.conn = newStreamConn(clientIn, clientOut, notify)
+	p.stop = func() { cancel(); clientOut.Close() }
+	return nil
+}
+
+func (p *pipeTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return p.conn.call(ctx, method, params)
+}
+
+func (p *pipeTransport) Notify(_ context.Context, method string, params any) error {
+	return p.conn.notifyServer(method, params)
+}
+
+func (p *pipeTransport) Close() error {
+	p.stop()
+	return nil
+}
+
+func TestServer_HTTP(t *testing.T) {
+	srv := httptest.NewServer(newTestServer())
+	defer srv.Close()
+	c := NewClient(NewHTTPTransport(srv.URL), ClientConfig{})
+	defer c.Close()
+	ctx := context.Background()
+
+	list, err := c.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server().ServerInfo.Name != "test-server" || len(list) != 2 {
+		t.Fatalf("server %v, tools %v", c.Server().ServerInfo, list)
+	}
+
+	greet := toolByName(t, list, "greet")
+	if greet.InputSchema["times"] == nil || strings.Join(greet.Required, ",") != "name" {
+		t.Errorf("greet schema = %v %v", greet.InputSchema, greet.Required)
+	}
+	if out, _, err := greet.ToolFunc(`{"name":"Ada","times":2}`, greet.Name, nil); err != nil || out != "hello Ada hello Ada" {
+		t.Errorf("greet = %q, %v", out, err)
+	}
+
+	// Tools without a schema are published with a single "input" string.
+	upper := toolByName(t, list, "upper")
+	if out, _, err := upper.ToolFunc(`{"input":"shout"}`, upper.Name, nil); err != nil || out != "SHOUT" {
+		t.Errorf("upper = %q, %v", out, err)
+	}
+	if out, _, err := upper.ToolFunc("plain text", upper.Name, nil); err != nil || out != "PLAIN TEXT" {
+		t.Errorf("upper plain text = %q, %v", out, err)
+	}
+
+	// Invalid input and tool failures come back as tool errors.
+	res, err := c.CallTool(ctx, "greet", json.RawMessage(`{"name":"Ada","times":9}`))
+	if err != nil || !res.IsError || !strings.Contains(FormatResult(res), "input.times: must be <= 3") {
+		t.Errorf("invalid input result = %+v, %v", res, err)
+	}
+	res, err = c.CallTool(ctx, "upper", json.RawMessage(`{"input":""}`))
+	if err != nil || !res.IsError || FormatResult(res) != "nothing to upper-case" {
+		t.Errorf("tool failure result = %+v, %v", res, err)
+	}
+	var rpcErr *RPCError
+	if _, err := c.CallTool(ctx, "missing", nil); !errors.As(err, &rpcErr) {
+		t.Errorf("unknown tool error = %v", err)
+	}
+}
+
+func TestServer_HTTPRequiresSession(t *testing.T) {
+	srv := httptest.NewServer(newTestServer())
+	defer srv.Close()
+	transport := NewHTTPTransport(srv.URL)
+	if err := transport.Start(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.Call(context.Background(), "tools/list", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("request without a session: %v", err)
+	}
+}
+
+func TestServer_HTTPExpiresIdleSessions(t *testing.T) {
+	s := newTestServer()
+	s.cfg.SessionIdleTimeout = 20 * time.Millisecond
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	ctx := context.Background()
+
+	first := NewClient(NewHTTPTransport(srv.URL), ClientConfig{})
+	defer first.Close()
+	if _, err := first.RefreshTools(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+
+	second := NewClient(NewHTTPTransport(srv.URL), ClientConfig{})
+	defer second.Close()
+	if _, err := second.RefreshTools(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.sessionsMu.Lock()
+	open := len(s.sessions)
+	s.sessionsMu.Unlock()
+	if open != 1 {
+		t.Errorf("want the idle session expired on initialize, %d sessions open", open)
+	}
+
+	// The expired host is refused and initializes a new session.
+	if _, err := first.RefreshTools(ctx); err != nil {
+		t.Errorf("call after the session expired: %v", err)
+	}
+}
+
+// whoami is a tool reporting the session and user it runs for.
+var whoami = tools.NewContextTool("whoami", "Reports the caller.", func(tc *tools.ToolContext, _ string) (string, []interface{}, error) {
+	return tc.SessionID + "/" + tc.UserID, nil, nil
+})
+
+func TestServer_HTTPChecksOrigin(t *testing.T) {
+	s := newTestServer()
+	s.cfg.AllowedOrigins = []string{"https://app.example.com"}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	for origin, allowed := range map[string]bool{
+		"":                                     true,
+		"http://localhost:6274":                true,
+		"https://app.example.com":              true,
+		"http://evil.example":                  false,
+		"https://app.example.com.evil.example": false,
+	} {
+		transport := NewHTTPTransport(srv.URL)
+		if origin != "" {
+			transport.Header = http.Header{"Origin": {origin}}
+		}
+		c := NewClient(transport, ClientConfig{})
+		_, err := c.RefreshTools(context.Background())
+		if allowed != (err == nil) {
+			t.Errorf("origin %q: %v", origin, err)
+		}
+		c.Close()
+	}
+}
+
+func TestServer_HTTPAuthorize(t *testing.T) {
+	s := newTestServer()
+	s.AddTools(whoami)
+	s.cfg.Authorize = func(r *http.Request) (string, error) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer alice-token":
+			return "alice", nil
+		case "Bearer bob-token":
+			return "bob", nil
+		}
+		return "", errors.New("missing or unknown token")
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	ctx := context.Background()
+
+	anonymous := NewClient(NewHTTPTransport(srv.URL), ClientConfig{})
+	defer anonymous.Close()
+	if _, err := anonymous.RefreshTools(ctx); err == nil {
+		t.Error("request without a token accepted")
+	}
+
+	alice := NewHTTPTransport(srv.URL)
+	alice.Header = http.Header{"Authorization": {"Bearer alice-token"}}
+	c := NewClient(alice, ClientConfig{})
+	defer c.Close()
+	res, err := c.CallTool(ctx, "whoami", json.RawMessage(`{"input":""}`))
+	if err != nil || !strings.HasSuffix(FormatResult(res), "/alice") {
+		t.Fatalf("whoami = %+v, %v", res, err)
+	}
+
+	// Bob cannot use Alice's session.
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	req.Header.Set("Authorization", "Bearer bob-token")
+	req.Header.Set(headerSessionID, alice.sessionID)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("another user's session: HTTP %d", rec.Code)
+	}
+}
+
+func TestServer_StdioSessionPerConnection(t *testing.T) {
+	s := newTestServer()
+	s.AddTools(whoami)
+	ctx := context.Background()
+	var seen []string
+	for i := 0; i < 2; i++ {
+		c := NewClient(&pipeTransport{server: s}, ClientConfig{})
+		res, err := c.CallTool(ctx, "whoami", json.RawMessage(`{"input":""}`))
+		c.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, FormatResult(res))
+	}
+	if seen[0] == "/" || seen[0] == seen[1] {
+		t.Errorf("want a distinct session per stdio connection, got %q", seen)
+	}
+}
+
+func TestServer_StdioToolListChanged(t *testing.T) {
+	s := newTestServer()
+	c := NewClient(&pipeTransport{server: s}, ClientConfig{})
+	defer c.Close()
+	ctx := context.Background()
+
+	list, err := c.Tools(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("tools = %v, %v", list, err)
+	}
+	s.AddTools(tools.BaseTool{Name: "noop", Description: "Does nothing.", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		return "ok", nil, nil
+	}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		list, err = c.Tools(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	noop := toolByName(t, list, "noop")
+	if out, _, err := noop.ToolFunc("now", noop.Name, nil); err != nil || out != "ok" {
+		t.Errorf("noop = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// StdioTransport runs an MCP server as a subprocess and talks to it over its
+// stdin and stdout. Each Start launches a fresh process.
+type StdioTransport struct {
+	Command string
+	Args    []string
+	// Env is added to the current process's environment.
+	Env []string
+	// Dir is the working directory; the current one when empty.
+	Dir string
+	// Stderr receives the server's log output; it is discarded when nil.
+	Stderr io.Writer
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	conn  *streamConn
+}
+
+// NewStdioTransport returns a transport that runs command with args, e.g.
+// NewStdioTransport("npx", "-y", "@modelcontextprotocol/server-filesystem", "/data").
+func NewStdioTransport(command string, args ...string) *StdioTransport {
+	return &StdioTransport{Command: command, Args: args}
+}
+
+// Start launches the server process.
+func (t *StdioTransport) Start(_ context.Context, notify func(string, json.RawMessage)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cmd != nil {
+		return fmt.Errorf("mcp: %s is already running", t.Command)
+	}
+	cmd := exec.Command(t.Command, t.Args...)
+	cmd.Dir = t.Dir
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+	cmd.Stderr = t.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("mcp: starting %s: %w", t.Command, err)
+	}
+	t.cmd, t.stdin = cmd, stdin
+	t.conn = newStreamConn(stdout, stdin, notify)
+	return nil
+}
+
+func (t *StdioTransport) current() (*streamConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil, ErrClosed
+	}
+	return t.conn, nil
+}
+
+// Call sends a request to the server.
+func (t *StdioTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	conn, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	return conn.call(ctx, method, params)
+}
+
+// Notify sends a notification to the server.
+func (t *StdioTransport) Notify(_ context.Context, method string, params any) error {
+	conn, err := t.current()
+	if err != nil {
+		return err
+	}
+	return conn.notifyServer(method, params)
+}
+
+// Close closes the server's stdin, giving it two seconds to exit before it
+// is killed.
+func (t *StdioTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cmd == nil {
+		return nil
+	}
+	_ = t.stdin.Close()
+	select {
+	case <-t.conn.done:
+	case <-time.After(2 * time.Second):
+		_ = t.cmd.Process.Kill()
+	}
+	_ = t.cmd.Wait()
+	t.cmd, t.stdin, t.conn = nil, nil, nil
+	return nil
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// Tools returns the server's tools as BaseTools that can be added to an
+// agent. Each tool's InputSchema is taken from the MCP schema, and its
+// Handler runs the tool on the server with tools/call (bounded by the run's
+// context and ClientConfig.Timeout). Plain-text input, as written in ReAct mode, is
+// accepted for tools with a single string argument.
+//
+// The tool's text output goes to the model and the *CallToolResult is
+// returned as its raw []interface{} result. A failure reported by the server
+// tool (IsError) is returned as the tool's error.
+func (c *Client) Tools(ctx context.Context) ([]tools.BaseTool, error) {
+	list, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tools.BaseTool, 0, len(list))
+	for _, t := range list {
+		out = append(out, c.baseTool(t))
+	}
+	return out, nil
+}
+
+func (c *Client) baseTool(t Tool) tools.BaseTool {
+	properties, _ := t.InputSchema["properties"].(map[string]any)
+	if properties == nil {
+		// Non-nil, so the tool receives JSON arguments rather than the
+		// default single "input" string.
+		properties = map[string]any{}
+	}
+	var required []string
+	if list, ok := t.InputSchema["required"].([]any); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required = append(required, name)
+			}
+		}
+	}
+	description := t.Description
+	if description == "" {
+		description = t.Title
+	}
+
+	var tool tools.BaseTool
+	serverName := t.Name
+	tool = tools.NewContextTool(c.cfg.ToolPrefix+t.Name, description, func(tc *tools.ToolContext, input string) (string, []interface{}, error) {
+		args, err := tools.InputArguments(tool, input)
+		if err != nil {
+			return "", nil, err
+		}
+		ctx, cancel := context.WithTimeout(tc, c.cfg.Timeout)
+		defer cancel()
+		result, err := c.CallTool(ctx, serverName, args)
+		if err != nil {
+			return "", nil, err
+		}
+		text := FormatResult(result)
+		if result.IsError {
+			return "", nil, fmt.Errorf("%s failed: %s", tool.Name, text)
+		}
+		return text, []interface{}{result}, nil
+	})
+	tool.InputSchema = properties
+	tool.Required = required
+	return tool
+}
+
+// FormatResult renders a tool result as text for a model: text content as
+// is, embedded text resources inline, and other content (images, audio,
+// binary resources, links) as short placeholders. A result with only
+// structured content is rendered as its JSON.
+func FormatResult(result *CallToolResult) string {
+	parts := make([]string, 0, len(result.Content))
+	for _, c := range result.Content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "image", "audio":
+			parts = append(parts, fmt.Sprintf("[%s: %s, %d bytes base64]", c.Type, c.MimeType, len(c.Data)))
+		case "resource":
+			switch {
+			case c.Resource == nil:
+			case c.Resource.Text != "":
+				parts = append(parts, c.Resource.Text)
+			default:
+				parts = append(parts, fmt.Sprintf("[resource: %s]", c.Resource.URI))
+			}
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("[resource link: %s]", c.URI))
+		}
+	}
+	if len(parts) == 0 && result.StructuredContent != nil {
+		if b, err := json.Marshal(result.StructuredContent); err == nil {
+			return string(b)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
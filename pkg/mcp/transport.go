@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned for requests on a transport that is not started or
+// whose connection to the server has been lost.
+var ErrClosed = errors.New("mcp: connection closed")
+
+// Transport carries JSON-RPC messages between a Client and one MCP server.
+// StdioTransport and HTTPTransport are the two transports defined by MCP.
+type Transport interface {
+	// Start connects to the server; notifications it sends are passed to
+	// notify. A transport can be started again after Close, which is how the
+	// client reconnects.
+	Start(ctx context.Context, notify func(method string, params json.RawMessage)) error
+	// Call sends a request and returns its result. Errors reported by the
+	// server are *RPCError; any other error means the connection failed.
+	Call(ctx context.Context, method string, params any) (json.RawMessage, error)
+	// Notify sends a notification.
+	Notify(ctx context.Context, method string, params any) error
+	Close() error
+}
+
+// streamConn runs JSON-RPC over a pair of newline-delimited JSON streams, as
+// used by the stdio transport.
+type streamConn struct {
+	w      io.Writer
+	wmu    sync.Mutex
+	nextID atomic.Int64
+	notify func(string, json.RawMessage)
+
+	mu      sync.Mutex
+	pending map[string]chan *message
+	err     error
+	done    chan struct{}
+}
+
+func newStreamConn(r io.Reader, w io.Writer, notify func(string, json.RawMessage)) *streamConn {
+	c := &streamConn{w: w, notify: notify, pending: make(map[string]chan *message), done: make(chan struct{})}
+	go c.read(r)
+	return c
+}
+
+func (c *streamConn) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	msg, err := newMessage(method, params)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(c.nextID.Add(1), 10)
+	msg.ID = json.RawMessage(id)
+	reply := make(chan *message, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(msg); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-c.done:
+		return nil, c.closedErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *streamConn) notifyServer(method string, params any) error {
+	msg, err := newMessage(method, params)
+	if err != nil {
+		return err
+	}
+	return c.send(msg)
+}
+
+func (c *streamConn) send(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := c.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+// read dispatches incoming messages until r ends, then fails every pending
+// call.
+func (c *streamConn) read(r io.Reader) {
+	br := bufio.NewReader(r)
+	var readErr error
+	for {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var msg message
+			if json.Unmarshal(line, &msg) == nil {
+				c.dispatch(&msg)
+			}
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+	}
+	c.mu.Lock()
+	if readErr == io.EOF {
+		c.err = ErrClosed
+	} else {
+		c.err = fmt.Errorf("%w: %v", ErrClosed, readErr)
+	}
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *streamConn) dispatch(msg *message) {
+	switch {
+	case msg.isResponse():
+		c.mu.Lock()
+		reply, ok := c.pending[string(msg.ID)]
+		c.mu.Unlock()
+		if ok {
+			reply <- msg
+		}
+	case msg.isNotification():
+		if c.notify != nil {
+			c.notify(msg.Method, msg.Params)
+		}
+	case msg.isRequest():
+		go func() { _ = c.send(answerServerRequest(msg)) }()
+	}
+}
+
+func (c *streamConn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// answerServerRequest replies to a request sent by the server. The client
+// offers no capabilities, so only ping is answered.
+func answerServerRequest(req *message) *message {
+	resp := &message{JSONRPC: jsonrpcVersion, ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage("{}")
+	} else {
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return resp
+}
+
+func newMessage(method string, params any) (*message, error) {
+	msg := &message{JSONRPC: jsonrpcVersion, Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("mcp: encoding %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	return msg, nil
+}
@@ -10,6 +10,16 @@ type BaseTool struct {
 	Description string   // Description of the tool
 	ToolFunc    ToolFunc // Function of the tool
 
+	// Handler, when set, is the context-aware implementation of the tool and
+	// is used instead of ToolFunc by agents (see Run and NewContextTool).
+	Handler ToolHandler
+	// Policy, when set, adds a timeout, retries, a circuit breaker and error
+	// reporting to the tool's runs.
+	Policy *Policy
+	// Cache, when set, answers repeated calls with the same input from a
+	// result cache (see Execute).
+	Cache *CacheConfig
+
 	// InputSchema holds the JSON-schema "properties" object describing the
 	// tool's structured input, used by native (provider-side) tool calling.
 	//
@@ -20,16 +30,30 @@ type BaseTool struct {
 	//
 	// When set, the raw JSON arguments produced by the model are passed to
 	// ToolFunc as a JSON string, and the tool author is responsible for
-	// unmarshalling them.
+	// unmarshalling them. The agent checks every input against the schema
+	// first (see ValidateInput) and reports violations back to the model.
 	InputSchema map[string]any // nil => default single "input" string schema
 	// Required lists the required property names for a custom InputSchema.
 	Required []string
 }
 
+// ToolNodes is the process-wide tool list new agents start with.
+//
+// Deprecated: it is shared by every agent in the process. Register tools on
+// an agent's own Registry instead.
 var ToolNodes = []BaseTool{}
 
+// ToolNodesMeta is the process-wide tool metadata new agents start with.
+//
+// Deprecated: it is shared by every agent in the process. Use
+// Registry.SetMeta instead.
 var ToolNodesMeta = make(map[string]interface{})
 
+// GoogleTool searches Google through Serper, with the key in the
+// SERPER_API_KEY environment variable.
+//
+// Deprecated: use search.NewTool, which supports other providers and search
+// options.
 var GoogleTool = BaseTool{
 	Name: "Google Search",
 	Description: `This tool is useful ONLY in the following circumstances:
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache stores encoded tool results under a key until they expire.
+// Implementations must be safe for concurrent use; NewInMemoryResultCache
+// and the MongoDB-backed store are provided.
+type ResultCache interface {
+	// Get returns the value stored under key, and false when there is none
+	// or it has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl; a ttl of zero keeps it until it
+	// is overwritten.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CacheScope decides who shares a tool's cached results.
+type CacheScope string
+
+const (
+	// CacheGlobal shares results across sessions and users (the default).
+	CacheGlobal CacheScope = ""
+	// CacheSession shares results within a session.
+	CacheSession CacheScope = "session"
+	// CacheUser shares results across one user's sessions.
+	CacheUser CacheScope = "user"
+)
+
+// CacheConfig turns on result caching for a tool. Results are keyed by the
+// tool name and a hash of the normalized input: JSON input with its keys
+// sorted and whitespace removed, text input trimmed with runs of whitespace
+// collapsed. Only successful results are cached, and the raw results of a
+// cache hit are their JSON decoding.
+type CacheConfig struct {
+	// Store holds the results. Caching is off when it is nil.
+	Store ResultCache
+	// TTL is how long a result is kept; zero keeps it until overwritten.
+	TTL time.Duration
+	// Scope limits who shares results; the default is CacheGlobal. Use
+	// CacheSession or CacheUser for tools whose results depend on who asks.
+	// A run without a session or user ID to scope by is not cached.
+	Scope CacheScope
+}
+
+// WithCache returns a copy of the tool that caches its results as c says.
+func (t BaseTool) WithCache(c CacheConfig) BaseTool {
+	t.Cache = &c
+	return t
+}
+
+// Result is the outcome of one tool run.
+type Result struct {
+	Output string
+	Raw    []interface{}
+	// CacheHit is set when the result came from the tool's cache.
+	CacheHit bool
+}
+
+// cachedResult is a Result as encoded in a ResultCache.
+type cachedResult struct {
+	Output string        `json:"output"`
+	Raw    []interface{} `json:"raw,omitempty"`
+}
+
+// CacheKey returns the cache key of a run of the named tool with input, for
+// the given scope and caller.
+func CacheKey(toolName, input string, scope CacheScope, tc *ToolContext) string {
+	h := sha256.New()
+	h.Write([]byte(normalizeInput(input)))
+	key := toolName + ":" + hex.EncodeToString(h.Sum(nil))
+	if tc == nil {
+		tc = &ToolContext{}
+	}
+	switch scope {
+	case CacheSession:
+		key = "session:" + tc.SessionID + ":" + key
+	case CacheUser:
+		key = "user:" + tc.UserID + ":" + key
+	}
+	return key
+}
+
+// scopeKnown reports whether tc identifies the caller that scope keys on, so
+// an anonymous run cannot read or fill another anonymous caller's results.
+func scopeKnown(scope CacheScope, tc *ToolContext) bool {
+	switch scope {
+	case CacheSession:
+		return tc != nil && tc.SessionID != ""
+	case CacheUser:
+		return tc != nil && tc.UserID != ""
+	}
+	return true
+}
+
+// normalizeInput makes inputs that mean the same thing hash the same.
+func normalizeInput(input string) string {
+	trimmed := strings.TrimSpace(input)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if v, err := decodeArgs(trimmed); err == nil {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			// Maps encode with sorted keys.
+			if err := enc.Encode(v); err == nil {
+				return strings.TrimSpace(buf.String())
+			}
+		}
+	}
+	return strings.Join(strings.Fields(trimmed), " ")
+}
+
+// Execute runs the tool like Run, answering from its cache when it has one
+// and recording successful results in it. Cache failures are logged and
+// otherwise ignored: the tool runs as if uncached.
+func (t BaseTool) Execute(tc *ToolContext, input string) (Result, error) {
+	if t.Cache == nil || t.Cache.Store == nil || !scopeKnown(t.Cache.Scope, tc) {
+		output, raw, err := t.Run(tc, input)
+		return Result{Output: output, Raw: raw}, err
+	}
+	// prepared only supplies the context and logger for the cache calls;
+	// Run prepares tc itself.
+	prepared := tc.forTool(t.Name)
+	key := CacheKey(t.Name, input, t.Cache.Scope, prepared)
+	if data, ok, err := t.Cache.Store.Get(prepared, key); err != nil {
+		prepared.Logger.Warn("reading tool result cache", "error", err)
+	} else if ok {
+		var cached cachedResult
+		if err := json.Unmarshal(data, &cached); err == nil {
+			return Result{Output: cached.Output, Raw: cached.Raw, CacheHit: true}, nil
+		}
+	}
+
+	output, raw, err := t.Run(tc, input)
+	if err != nil {
+		return Result{Output: output, Raw: raw}, err
+	}
+	data, encErr := json.Marshal(cachedResult{Output: output, Raw: raw})
+	if encErr != nil {
+		// Raw results that are not JSON-encodable are cached without them.
+		data, _ = json.Marshal(cachedResult{Output: output})
+	}
+	if err := t.Cache.Store.Set(prepared, key, data, t.Cache.TTL); err != nil {
+		prepared.Logger.Warn("writing tool result cache", "error", err)
+	}
+	return Result{Output: output, Raw: raw}, nil
+}
+
+// InMemoryResultCache is a process-local ResultCache. Expired entries are
+// dropped when they are read and when the cache is swept on Set.
+type InMemoryResultCache struct {
+	mu        sync.Mutex
+	entries   map[string]cacheEntry
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time // zero for no expiry
+}
+
+// NewInMemoryResultCache returns an empty in-memory result cache.
+func NewInMemoryResultCache() *InMemoryResultCache {
+	return &InMemoryResultCache{entries: make(map[string]cacheEntry)}
+}
+
+// Get returns the unexpired value stored under key.
+func (c *InMemoryResultCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set stores value under key for ttl.
+func (c *InMemoryResultCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+	e := cacheEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = e
+	if now.Sub(c.lastSweep) > time.Minute {
+		for k, e := range c.entries {
+			if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	return nil
+}
+
+// Delete removes the value stored under key.
+func (c *InMemoryResultCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func countingTool(calls *int) BaseTool {
+	return BaseTool{Name: "search", ToolFunc: func(input string, _ string, _ map[string]interface{}) (string, []interface{}, error) {
+		*calls++
+		return "results for " + input, []interface{}{map[string]interface{}{"hits": 3}}, nil
+	}}
+}
+
+func TestExecute_CachesResults(t *testing.T) {
+	calls := 0
+	tool := countingTool(&calls).WithCache(CacheConfig{Store: NewInMemoryResultCache()})
+
+	first, err := tool.Execute(nil, "golang")
+	if err != nil || first.CacheHit || first.Output != "results for golang" {
+		t.Fatalf("first = %+v, %v", first, err)
+	}
+	second, err := tool.Execute(nil, "  golang ")
+	if err != nil || !second.CacheHit || second.Output != first.Output || calls != 1 {
+		t.Fatalf("second = %+v, %v, calls = %d", second, err, calls)
+	}
+	if raw, ok := second.Raw[0].(map[string]interface{}); !ok || raw["hits"] != float64(3) {
+		t.Errorf("raw = %#v", second.Raw)
+	}
+	if _, err := tool.Execute(nil, "rust"); err != nil || calls != 2 {
+		t.Errorf("different input: calls = %d, %v", calls, err)
+	}
+}
+
+func TestExecute_Uncached(t *testing.T) {
+	calls := 0
+	tool := countingTool(&calls)
+	for i := 0; i < 2; i++ {
+		if res, err := tool.Execute(nil, "q"); err != nil || res.CacheHit {
+			t.Fatalf("res = %+v, %v", res, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d", calls)
+	}
+}
+
+func TestExecute_ErrorsNotCached(t *testing.T) {
+	calls := 0
+	tool := BaseTool{Name: "flaky", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		calls++
+		if calls == 1 {
+			return "", nil, errors.New("rate limited")
+		}
+		return "ok", nil, nil
+	}}.WithCache(CacheConfig{Store: NewInMemoryResultCache()})
+
+	if _, err := tool.Execute(nil, "q"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if res, err := tool.Execute(nil, "q"); err != nil || res.CacheHit || res.Output != "ok" {
+		t.Errorf("res = %+v, %v", res, err)
+	}
+}
+
+func TestExecute_TTL(t *testing.T) {
+	calls := 0
+	tool := countingTool(&calls).WithCache(CacheConfig{Store: NewInMemoryResultCache(), TTL: 20 * time.Millisecond})
+
+	tool.Execute(nil, "q")
+	if res, _ := tool.Execute(nil, "q"); !res.CacheHit {
+		t.Fatal("expected a hit before the TTL")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if res, _ := tool.Execute(nil, "q"); res.CacheHit || calls != 2 {
+		t.Errorf("expired entry used: %+v, calls = %d", res, calls)
+	}
+}
+
+func TestExecute_SessionScope(t *testing.T) {
+	calls := 0
+	tool := countingTool(&calls).WithCache(CacheConfig{Store: NewInMemoryResultCache(), Scope: CacheSession})
+
+	a := &ToolContext{Context: context.Background(), SessionID: "a"}
+	b := &ToolContext{Context: context.Background(), SessionID: "b"}
+	tool.Execute(a, "q")
+	if res, _ := tool.Execute(a, "q"); !res.CacheHit {
+		t.Error("expected a hit within the session")
+	}
+	if res, _ := tool.Execute(b, "q"); res.CacheHit {
+		t.Error("result shared across sessions")
+	}
+
+	// Runs without a session are not cached at all.
+	anonymous := &ToolContext{Context: context.Background()}
+	tool.Execute(anonymous, "q")
+	if res, _ := tool.Execute(anonymous, "q"); res.CacheHit {
+		t.Error("result shared between runs without a session")
+	}
+	userScoped := countingTool(&calls).WithCache(CacheConfig{Store: NewInMemoryResultCache(), Scope: CacheUser})
+	userScoped.Execute(a, "q")
+	if res, _ := userScoped.Execute(a, "q"); res.CacheHit {
+		t.Error("user-scoped result cached without a user")
+	}
+}
+
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("down")
+}
+
+func (failingCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+
+func TestExecute_CacheFailureRunsTool(t *testing.T) {
+	calls := 0
+	tool := countingTool(&calls).WithCache(CacheConfig{Store: failingCache{}})
+	if res, err := tool.Execute(nil, "q"); err != nil || res.Output != "results for q" || calls != 1 {
+		t.Errorf("res = %+v, %v, calls = %d", res, err, calls)
+	}
+}
+
+func TestCacheKey_Normalization(t *testing.T) {
+	same := [][2]string{
+		{`{"q": "go", "n": 5}`, `{"n":5,"q":"go"}`},
+		{"what  is\n go", "what is go"},
+	}
+	for _, p := range same {
+		if CacheKey("t", p[0], CacheGlobal, nil) != CacheKey("t", p[1], CacheGlobal, nil) {
+			t.Errorf("%q and %q hash differently", p[0], p[1])
+		}
+	}
+	if CacheKey("t", "go", CacheGlobal, nil) == CacheKey("u", "go", CacheGlobal, nil) {
+		t.Error("tool name not part of the key")
+	}
+	if CacheKey("t", `{"q":"go"}`, CacheGlobal, nil) == CacheKey("t", `{"q":"Go"}`, CacheGlobal, nil) {
+		t.Error("JSON values normalized")
+	}
+	u1 := &ToolContext{UserID: "1"}
+	u2 := &ToolContext{UserID: "2"}
+	if CacheKey("t", "go", CacheUser, u1) == CacheKey("t", "go", CacheUser, u2) {
+		t.Error("user scope not part of the key")
+	}
+}
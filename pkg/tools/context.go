@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/darksuit-ai/darksuitai/internal/observability"
+)
+
+// ToolContext is what a ToolHandler receives along with its input. It is a
+// context.Context, carrying the run's cancellation and deadline, and
+// identifies who the tool is running for.
+type ToolContext struct {
+	context.Context
+
+	// ToolName is the name of the running tool.
+	ToolName string
+	// SessionID and UserID identify the conversation and end user; RunID
+	// identifies the agent run (one Chat or Stream call).
+	SessionID string
+	UserID    string
+	RunID     string
+	// Meta is the agent's tool metadata (see Registry.SetMeta).
+	Meta map[string]interface{}
+	// Logger logs with the tool name, run ID and session ID attached. It
+	// defaults to slog.Default().
+	Logger *slog.Logger
+	// Span is the observer's handle for the run; nil outside an agent run.
+	Span observability.RunHandle
+}
+
+// ToolHandler is a context-aware tool implementation. Set it as a BaseTool's
+// Handler, or build the tool with NewContextTool.
+type ToolHandler func(tc *ToolContext, input string) (string, []interface{}, error)
+
+type toolContextKey struct{}
+
+// FromContext returns the ToolContext of the running tool, for code that
+// only receives a context.Context, such as a NewTypedTool handler.
+func FromContext(ctx context.Context) (*ToolContext, bool) {
+	tc, ok := ctx.Value(toolContextKey{}).(*ToolContext)
+	return tc, ok
+}
+
+// Progress reports progress of a long-running tool. It is recorded by the
+// run's observer when the observer supports progress events, and logged at
+// debug level.
+func (tc *ToolContext) Progress(message string, data map[string]any) {
+	if recorder, ok := tc.Span.(observability.ProgressRecorder); ok {
+		recorder.ToolProgress(observability.ToolProgress{Name: tc.ToolName, Message: message, Data: data})
+	}
+	if tc.Logger != nil {
+		tc.Logger.Debug(message, "progress", data)
+	}
+}
+
+// forTool returns a copy of tc prepared for running the named tool, with
+// defaults filled in. tc may be nil.
+func (tc *ToolContext) forTool(name string) *ToolContext {
+	var c ToolContext
+	if tc != nil {
+		c = *tc
+	}
+	if c.Context == nil {
+		c.Context = context.Background()
+	}
+	if c.Logger == nil {
+		c.Logger = slog.Default()
+	}
+	c.ToolName = name
+	c.Logger = c.Logger.With("tool", name)
+	if c.RunID != "" {
+		c.Logger = c.Logger.With("run_id", c.RunID)
+	}
+	if c.SessionID != "" {
+		c.Logger = c.Logger.With("session_id", c.SessionID)
+	}
+	c.Context = context.WithValue(c.Context, toolContextKey{}, &c)
+	return &c
+}
+
+// Run runs the tool with input, under its Policy when it has one. Tools with
+// a Handler receive a ToolContext built from tc; tools with only a ToolFunc
+// receive tc's Meta, and cannot be cancelled once started. tc may be nil, for
+// a background context.
+func (t BaseTool) Run(tc *ToolContext, input string) (string, []interface{}, error) {
+	if t.Policy != nil {
+		return t.Policy.run(t, tc, input)
+	}
+	return t.run(tc, input)
+}
+
+func (t BaseTool) run(tc *ToolContext, input string) (string, []interface{}, error) {
+	if t.Handler == nil {
+		var meta map[string]interface{}
+		if tc != nil {
+			meta = tc.Meta
+		}
+		return t.ToolFunc(input, t.Name, meta)
+	}
+	tc = tc.forTool(t.Name)
+	if err := tc.Err(); err != nil {
+		return "", nil, err
+	}
+	return t.Handler(tc, input)
+}
+
+/*
+NewContextTool creates a tool from a context-aware handler. The tool's
+ToolFunc is set too, running the handler with a background context, so code
+that calls ToolFunc directly keeps working.
+
+Example:
+
+	report := tools.NewContextTool("build_report", "Builds the monthly report. Input: the month.",
+		func(tc *tools.ToolContext, month string) (string, []interface{}, error) {
+			tc.Logger.Info("building report", "month", month, "user", tc.UserID)
+			rows, err := db.QueryContext(tc, reportQuery, month)
+			if err != nil {
+				return "", nil, err
+			}
+			tc.Progress("rows loaded", nil)
+			return summarize(rows), nil, nil
+		})
+*/
+func NewContextTool(name, description string, handler ToolHandler) BaseTool {
+	tool := BaseTool{Name: name, Description: description, Handler: handler}
+	tool.ToolFunc = func(input string, toolName string, meta map[string]interface{}) (string, []interface{}, error) {
+		return handler((&ToolContext{Meta: meta}).forTool(toolName), input)
+	}
+	return tool
+}
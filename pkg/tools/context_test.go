@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/darksuit-ai/darksuitai/internal/observability"
+)
+
+type progressSpan struct {
+	observability.RunHandle
+	events []observability.ToolProgress
+}
+
+func (s *progressSpan) ToolProgress(p observability.ToolProgress) { s.events = append(s.events, p) }
+
+func TestRun_Handler(t *testing.T) {
+	span := &progressSpan{RunHandle: observability.Noop{}.StartRun(observability.RunInfo{})}
+	var got *ToolContext
+	tool := NewContextTool("lookup", "Looks things up.", func(tc *ToolContext, input string) (string, []interface{}, error) {
+		got = tc
+		tc.Progress("half way", map[string]any{"done": 1})
+		return "found " + input, nil, nil
+	})
+
+	out, _, err := tool.Run(&ToolContext{
+		Context:   context.Background(),
+		SessionID: "s1",
+		UserID:    "u1",
+		RunID:     "r1",
+		Meta:      map[string]interface{}{"tenant": "acme"},
+		Span:      span,
+	}, "cats")
+	if err != nil || out != "found cats" {
+		t.Fatalf("Run = %q, %v", out, err)
+	}
+	if got.ToolName != "lookup" || got.SessionID != "s1" || got.UserID != "u1" || got.RunID != "r1" || got.Meta["tenant"] != "acme" || got.Logger == nil {
+		t.Errorf("tool context = %+v", got)
+	}
+	if fromCtx, ok := FromContext(got); !ok || fromCtx != got {
+		t.Error("FromContext did not return the tool context")
+	}
+	if len(span.events) != 1 || span.events[0].Name != "lookup" || span.events[0].Message != "half way" {
+		t.Errorf("progress events = %+v", span.events)
+	}
+
+	// Code calling ToolFunc directly still reaches the handler.
+	if out, _, err := tool.ToolFunc("dogs", tool.Name, nil); err != nil || out != "found dogs" || got.ToolName != "lookup" {
+		t.Errorf("ToolFunc = %q, %v", out, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := tool.Run(&ToolContext{Context: ctx}, "x"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled run error = %v", err)
+	}
+}
+
+func TestRun_LegacyToolFunc(t *testing.T) {
+	tool := BaseTool{Name: "echo", ToolFunc: func(input, name string, meta map[string]interface{}) (string, []interface{}, error) {
+		return name + ":" + input + ":" + meta["k"].(string), nil, nil
+	}}
+	out, _, err := tool.Run(&ToolContext{Meta: map[string]interface{}{"k": "v"}}, "hi")
+	if err != nil || out != "echo:hi:v" {
+		t.Errorf("Run = %q, %v", out, err)
+	}
+}
+
+func TestTypedTool_ToolContext(t *testing.T) {
+	type input struct {
+		Q string `json:"q" jsonschema:"required"`
+	}
+	tool := NewTypedTool("whoami", "Reports the caller.", func(ctx context.Context, in input) (string, error) {
+		tc, ok := FromContext(ctx)
+		if !ok {
+			return "", errors.New("no tool context")
+		}
+		return tc.UserID + " asked " + in.Q, nil
+	})
+	out, _, err := tool.Run(&ToolContext{UserID: "ada"}, `{"q":"why"}`)
+	if err != nil || out != "ada asked why" {
+		t.Errorf("Run = %q, %v", out, err)
+	}
+}
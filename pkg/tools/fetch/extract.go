@@ -0,0 +1,516 @@
+package fetch
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/*
+Extract returns the title and the main readable content of an HTML document,
+as Markdown. Links and images are resolved against base, which may be nil.
+
+The content is found the way reader modes do: scripts, navigation, footers,
+sidebars, hidden elements and elements whose class or id looks like
+boilerplate (menus, cookie banners, share buttons, comments) are dropped;
+then the page's <article> or <main> is used when it holds enough text, and
+otherwise the element containing the most paragraph text, discounted by its
+share of link text.
+*/
+func Extract(root *html.Node, base *url.URL) (title, markdown string) {
+	title = pageTitle(root)
+	body := findFirst(root, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+	if body == nil {
+		body = root
+	}
+	prune(body)
+	w := &mdWriter{base: base}
+	w.walk(mainContent(body))
+	return title, w.String()
+}
+
+// ---- finding the content ----
+
+// removed elements never hold readable content.
+var removed = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Canvas: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Nav: true, atom.Footer: true, atom.Aside: true, atom.Dialog: true,
+}
+
+var (
+	boilerplateClass = regexp.MustCompile(`(?i)\b(nav|navbar|menu|footer|sidebar|side-bar|comments?|cookies?|consent|banner|advert|ads?|promo|share|sharing|social|related|breadcrumbs?|popup|modal|newsletter|subscribe|masthead|skip-link)\b`)
+	contentClass     = regexp.MustCompile(`(?i)\b(article|content|main|post|entry|story|body|text)\b`)
+	boilerplateRoles = map[string]bool{"navigation": true, "banner": true, "contentinfo": true, "complementary": true, "dialog": true, "alert": true}
+)
+
+// prune removes the elements of n's subtree that are not readable content.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if boilerplate(c) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func boilerplate(n *html.Node) bool {
+	switch {
+	case n.Type == html.CommentNode:
+		return true
+	case n.Type != html.ElementNode:
+		return false
+	case removed[n.DataAtom]:
+		return true
+	case n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main:
+		return false
+	}
+	if _, hidden := attr(n, "hidden"); hidden || attrValue(n, "aria-hidden") == "true" ||
+		boilerplateRoles[attrValue(n, "role")] {
+		return true
+	}
+	if style := strings.ReplaceAll(attrValue(n, "style"), " ", ""); strings.Contains(style, "display:none") {
+		return true
+	}
+	names := attrValue(n, "class") + " " + attrValue(n, "id")
+	return boilerplateClass.MatchString(names) && !contentClass.MatchString(names)
+}
+
+// mainContent returns the element of body holding the page's main content.
+func mainContent(body *html.Node) *html.Node {
+	var explicit *html.Node
+	explicitLen := 0
+	walkElements(body, func(n *html.Node) {
+		if n.DataAtom == atom.Article || n.DataAtom == atom.Main || attrValue(n, "role") == "main" {
+			if l := textLen(n); l > explicitLen {
+				explicit, explicitLen = n, l
+			}
+		}
+	})
+	if explicitLen >= 250 {
+		return explicit
+	}
+
+	scores := map[*html.Node]float64{}
+	walkElements(body, func(n *html.Node) {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td {
+			return
+		}
+		text := collapse(textOf(n))
+		if utf8.RuneCountInString(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(utf8.RuneCountInString(text))/100, 3)
+		if p := n.Parent; p != nil {
+			scores[p] += score
+			if gp := p.Parent; gp != nil {
+				scores[gp] += score / 2
+			}
+		}
+	})
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if score > bestScore || (score == bestScore && best != nil && isAncestor(n, best)) {
+			best, bestScore = n, score
+		}
+	}
+	switch {
+	case best != nil:
+		return best
+	case explicit != nil:
+		return explicit
+	}
+	return body
+}
+
+func pageTitle(root *html.Node) string {
+	if meta := findFirst(root, func(n *html.Node) bool {
+		return n.DataAtom == atom.Meta && attrValue(n, "property") == "og:title"
+	}); meta != nil {
+		if t := collapse(attrValue(meta, "content")); t != "" {
+			return t
+		}
+	}
+	for _, a := range []atom.Atom{atom.Title, atom.H1} {
+		if n := findFirst(root, func(n *html.Node) bool { return n.DataAtom == a }); n != nil {
+			if t := collapse(textOf(n)); t != "" {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := textLen(n)
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walkElements(n, func(a *html.Node) {
+		if a.DataAtom == atom.A {
+			links += textLen(a)
+		}
+	})
+	return min(float64(links)/float64(total), 1)
+}
+
+func isAncestor(a, n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == a {
+			return true
+		}
+	}
+	return false
+}
+
+// ---- rendering Markdown ----
+
+type mdWriter struct {
+	b     strings.Builder
+	base  *url.URL
+	pre   int
+	lists []listState
+}
+
+type listState struct {
+	ordered bool
+	n       int
+}
+
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Address: true, atom.Details: true, atom.Summary: true,
+}
+
+var headings = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+func (w *mdWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	if level, ok := headings[n.DataAtom]; ok {
+		if text := collapse(textOf(n)); text != "" {
+			w.newlines(2)
+			w.b.WriteString(strings.Repeat("#", level) + " " + text)
+			w.newlines(2)
+		}
+		return
+	}
+	switch n.DataAtom {
+	case atom.Head, atom.Title:
+		return
+	case atom.Br:
+		w.newlines(1)
+	case atom.Hr:
+		w.newlines(2)
+		w.b.WriteString("---")
+		w.newlines(2)
+	case atom.A:
+		text := collapse(textOf(n))
+		href := w.resolve(attrValue(n, "href"))
+		if text == "" || href == "" || strings.HasPrefix(href, "#") {
+			w.children(n)
+			return
+		}
+		w.inline(n, "["+text+"]("+href+")")
+	case atom.Img:
+		if alt, src := collapse(attrValue(n, "alt")), w.resolve(attrValue(n, "src")); alt != "" && src != "" {
+			w.inline(n, "!["+alt+"]("+src+")")
+		}
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "_")
+	case atom.Code, atom.Kbd, atom.Samp:
+		if w.pre > 0 {
+			w.children(n)
+		} else if text := collapse(textOf(n)); text != "" {
+			w.inline(n, "`"+text+"`")
+		}
+	case atom.Pre:
+		w.newlines(2)
+		w.b.WriteString("```\n")
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.trimTrailing()
+		w.b.WriteString("\n```")
+		w.newlines(2)
+	case atom.Ul, atom.Ol:
+		// Nested lists continue their parent list.
+		gap := 2
+		if len(w.lists) > 0 {
+			gap = 1
+		}
+		w.newlines(gap)
+		w.lists = append(w.lists, listState{ordered: n.DataAtom == atom.Ol})
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		w.newlines(gap)
+	case atom.Li:
+		w.newlines(1)
+		marker := "- "
+		if depth := len(w.lists); depth > 0 {
+			w.b.WriteString(strings.Repeat("  ", depth-1))
+			if l := &w.lists[depth-1]; l.ordered {
+				l.n++
+				marker = strconv.Itoa(l.n) + ". "
+			}
+		}
+		w.b.WriteString(marker)
+		w.children(n)
+		w.newlines(1)
+	case atom.Blockquote:
+		inner := &mdWriter{base: w.base}
+		inner.children(n)
+		if text := strings.TrimSpace(inner.b.String()); text != "" {
+			w.newlines(2)
+			w.b.WriteString("> " + strings.ReplaceAll(text, "\n", "\n> "))
+			w.newlines(2)
+		}
+	case atom.Table:
+		w.table(n)
+	default:
+		if blocks[n.DataAtom] {
+			w.newlines(2)
+			w.children(n)
+			w.newlines(2)
+			return
+		}
+		w.children(n)
+	}
+}
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// wrap writes n's text between marker, e.g. **bold**.
+func (w *mdWriter) wrap(n *html.Node, marker string) {
+	if w.pre > 0 {
+		w.children(n)
+		return
+	}
+	if text := collapse(textOf(n)); text != "" {
+		w.inline(n, marker+text+marker)
+	}
+}
+
+// inline writes s in place of n, keeping the whitespace around n's text.
+func (w *mdWriter) inline(n *html.Node, s string) {
+	raw := textOf(n)
+	if raw != "" && isSpace(raw[0]) {
+		w.space()
+	}
+	w.b.WriteString(s)
+	if raw != "" && isSpace(raw[len(raw)-1]) {
+		w.space()
+	}
+}
+
+// table renders rows as a Markdown table, with the first row as its header.
+func (w *mdWriter) table(n *html.Node) {
+	var rows [][]string
+	walkElements(n, func(tr *html.Node) {
+		if tr.DataAtom != atom.Tr {
+			return
+		}
+		var cells []string
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Td || c.DataAtom == atom.Th {
+				cells = append(cells, strings.ReplaceAll(collapse(textOf(c)), "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+	if len(rows) == 0 {
+		return
+	}
+	width := 0
+	for _, r := range rows {
+		width = max(width, len(r))
+	}
+	w.newlines(2)
+	for i, r := range rows {
+		for len(r) < width {
+			r = append(r, "")
+		}
+		w.b.WriteString("| " + strings.Join(r, " | ") + " |\n")
+		if i == 0 {
+			w.b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	w.newlines(2)
+}
+
+// text appends inline text, collapsing whitespace outside <pre>.
+func (w *mdWriter) text(s string) {
+	if w.pre > 0 {
+		w.b.WriteString(s)
+		return
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			w.space()
+		}
+		return
+	}
+	if isSpace(s[0]) {
+		w.space()
+	}
+	w.b.WriteString(strings.Join(fields, " "))
+	if isSpace(s[len(s)-1]) {
+		w.space()
+	}
+}
+
+func (w *mdWriter) space() {
+	if tail := w.tail(); tail != "" && !strings.HasSuffix(tail, " ") && !strings.HasSuffix(tail, "\n") {
+		w.b.WriteByte(' ')
+	}
+}
+
+// tail returns the last line written.
+func (w *mdWriter) tail() string {
+	s := w.b.String()
+	return s[strings.LastIndexByte(s, '\n')+1:]
+}
+
+// trimTrailing removes trailing spaces and newlines.
+func (w *mdWriter) trimTrailing() {
+	s := w.b.String()
+	if trimmed := strings.TrimRight(s, " \n"); len(trimmed) != len(s) {
+		w.b.Reset()
+		w.b.WriteString(trimmed)
+	}
+}
+
+// newlines ends the current line and ensures n line breaks before what
+// follows. A line holding only a list marker is left open for the item's
+// content.
+func (w *mdWriter) newlines(n int) {
+	s := w.b.String()
+	if s == "" || listMarker.MatchString(w.tail()) {
+		return
+	}
+	trimmed := strings.TrimRight(s, " ")
+	have := len(trimmed) - len(strings.TrimRight(trimmed, "\n"))
+	if len(trimmed) != len(s) {
+		w.b.Reset()
+		w.b.WriteString(trimmed)
+	}
+	for ; have < n; have++ {
+		w.b.WriteByte('\n')
+	}
+}
+
+var listMarker = regexp.MustCompile(`^ *(-|\d+\.) $`)
+
+func (w *mdWriter) String() string {
+	return strings.TrimSpace(w.b.String())
+}
+
+// resolve makes ref absolute against the page URL, dropping javascript:
+// and data: URLs.
+func (w *mdWriter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	lower := strings.ToLower(ref)
+	if ref == "" || strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "data:") {
+		return ""
+	}
+	if strings.HasPrefix(ref, "#") || w.base == nil {
+		return ref
+	}
+	u, err := w.base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// ---- node helpers ----
+
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+			walkElements(c, fn)
+		}
+	}
+}
+
+func findFirst(n *html.Node, match func(*html.Node) bool) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && match(c) {
+			return c
+		}
+		if found := findFirst(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attrValue(n *html.Node, key string) string {
+	v, _ := attr(n, key)
+	return strings.TrimSpace(v)
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
+
+func textLen(n *html.Node) int {
+	return utf8.RuneCountInString(collapse(textOf(n)))
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,360 @@
+// Package fetch downloads web pages for agents: it enforces domain rules,
+// size limits, timeouts and robots.txt, extracts the readable content of HTML
+// pages as Markdown, and splits long documents into pages that fit a token
+// budget (see NewTool).
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// DefaultUserAgent identifies fetches to servers and to robots.txt rules.
+const DefaultUserAgent = "darksuitai-fetch/1.0 (+https://github.com/darksuit-ai/darksuitai)"
+
+var (
+	// ErrNotAllowed is returned, wrapped, for URLs the Config does not allow:
+	// other schemes than http and https, denied or unlisted domains, and
+	// private network addresses.
+	ErrNotAllowed = errors.New("fetching this URL is not allowed")
+	// ErrDisallowedByRobots is returned, wrapped, for URLs the site's
+	// robots.txt disallows.
+	ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+)
+
+// Config controls what a Fetcher may download and how.
+type Config struct {
+	// AllowDomains, when set, is the only domains (and their subdomains)
+	// that may be fetched, e.g. "go.dev".
+	AllowDomains []string
+	// DenyDomains are never fetched, nor are their subdomains. They take
+	// precedence over AllowDomains.
+	DenyDomains []string
+	// AllowPrivateNetworks lets URLs resolve to loopback, private,
+	// link-local, shared (CGNAT) and other non-public addresses, which are
+	// refused by default so a model cannot
+	// reach internal services. The check is made when connecting, so it
+	// holds for redirects and DNS tricks too; it is not applied when Client
+	// is set.
+	AllowPrivateNetworks bool
+	// MaxBytes caps the downloaded body; longer documents are cut. Defaults
+	// to 2 MiB.
+	MaxBytes int64
+	// Timeout limits each fetch, robots.txt included. Defaults to 20s.
+	Timeout time.Duration
+	// MaxTokens is the size of one page of content handed to the model,
+	// estimated at four characters per token. Defaults to 4000.
+	MaxTokens int
+	// UserAgent is sent with every request and matched against robots.txt
+	// groups by its first word. Defaults to DefaultUserAgent.
+	UserAgent string
+	// IgnoreRobots skips robots.txt checks.
+	IgnoreRobots bool
+	// Client sends the requests. By default a client is built that refuses
+	// private addresses (see AllowPrivateNetworks) and uses no proxy.
+	Client *http.Client
+}
+
+// Page is a fetched document.
+type Page struct {
+	// URL is the document's URL after redirects.
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	// Content is the readable content as Markdown for HTML pages, and the
+	// body as is for text, JSON and XML.
+	Content     string `json:"content"`
+	ContentType string `json:"content_type"`
+	// Truncated is set when the body was longer than MaxBytes and was cut.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Fetcher downloads pages under a Config. It is safe for concurrent use, and
+// caches robots.txt files for an hour.
+type Fetcher struct {
+	cfg    Config
+	client *http.Client
+
+	mu     sync.Mutex
+	robots map[string]robotsEntry // by scheme://host
+}
+
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+// New returns a Fetcher for cfg, applying its defaults.
+func New(cfg Config) *Fetcher {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 2 << 20
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 20 * time.Second
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 4000
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	f := &Fetcher{cfg: cfg, robots: make(map[string]robotsEntry)}
+	if cfg.Client != nil {
+		client := *cfg.Client
+		f.client = &client
+	} else {
+		f.client = &http.Client{Transport: guardedTransport(cfg.AllowPrivateNetworks)}
+	}
+	next := f.client.CheckRedirect
+	f.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if err := f.check(req.Context(), req.URL); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		return nil
+	}
+	return f
+}
+
+// guardedTransport returns a transport that, unless allowPrivate is set,
+// refuses to connect to non-public addresses.
+func guardedTransport(allowPrivate bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if !allowPrivate {
+		dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: refusePrivate}
+		transport.DialContext = dialer.DialContext
+	}
+	return transport
+}
+
+// blockedPrefixes are the address ranges refused unless
+// AllowPrivateNetworks is set: private, loopback, link-local (which holds the
+// 169.254.169.254 cloud metadata address), multicast and reserved ranges,
+// "this network", shared CGNAT space (which holds Alibaba Cloud's
+// 100.100.100.200 metadata address) and the NAT64 prefix, through which an
+// IPv4 address could be reached as IPv6.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not a public address", ErrNotAllowed, host)
+	}
+	ip = ip.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("%w: %s is not a public address", ErrNotAllowed, host)
+		}
+	}
+	return nil
+}
+
+// Fetch downloads rawURL and extracts its content.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("fetch: invalid URL: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+	if err := f.check(ctx, u); err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		err := fmt.Errorf("fetch: %s: HTTP %d %s", u, resp.StatusCode, http.StatusText(resp.StatusCode))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			err = tools.Retryable(err)
+		}
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch: reading %s: %w", u, err)
+	}
+	page := &Page{URL: resp.Request.URL.String()}
+	if int64(len(body)) > f.cfg.MaxBytes {
+		body, page.Truncated = body[:f.cfg.MaxBytes], true
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	page.ContentType = mediaType
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		r, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: decoding %s: %w", u, err)
+		}
+		root, err := html.Parse(r)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: parsing %s: %w", u, err)
+		}
+		page.Title, page.Content = Extract(root, resp.Request.URL)
+	case isText(mediaType):
+		page.Content = strings.ToValidUTF8(string(body), "�")
+	default:
+		return nil, fmt.Errorf("fetch: %s: unsupported content type %q", u, mediaType)
+	}
+	return page, nil
+}
+
+func isText(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" || mediaType == "application/xml" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// check reports whether u may be fetched.
+func (f *Fetcher) check(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https URLs can be fetched, got %q", ErrNotAllowed, u.String())
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: %q has no host", ErrNotAllowed, u.String())
+	}
+	if matchDomain(host, f.cfg.DenyDomains) {
+		return fmt.Errorf("%w: %s is a denied domain", ErrNotAllowed, host)
+	}
+	if len(f.cfg.AllowDomains) > 0 && !matchDomain(host, f.cfg.AllowDomains) {
+		return fmt.Errorf("%w: %s is not an allowed domain", ErrNotAllowed, host)
+	}
+	if f.cfg.IgnoreRobots || ctx.Value(robotsFetchKey{}) != nil {
+		return nil
+	}
+	rules, err := f.robotsFor(ctx, u)
+	if err != nil {
+		return err
+	}
+	if !rules.allowed(robotsPath(u)) {
+		return fmt.Errorf("%w: %s", ErrDisallowedByRobots, u.String())
+	}
+	return nil
+}
+
+// matchDomain reports whether host is one of domains or a subdomain of one.
+func matchDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "*"), "."), ".")
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsFetchKey marks the context of a robots.txt request, whose redirects
+// are not themselves checked against robots.txt.
+type robotsFetchKey struct{}
+
+// robotsFor returns the robots.txt rules for u's site that apply to the
+// fetcher's user agent. Following RFC 9309, a missing robots.txt (4xx)
+// allows everything and an unreachable one disallows everything: a server
+// error disallows the site, and a network error fails the fetch.
+func (f *Fetcher) robotsFor(ctx context.Context, u *url.URL) (robotsRules, error) {
+	site := u.Scheme + "://" + u.Host
+	f.mu.Lock()
+	entry, ok := f.robots[site]
+	f.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.rules, nil
+	}
+
+	ctx = context.WithValue(ctx, robotsFetchKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return robotsRules{}, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return robotsRules{}, fmt.Errorf("fetching robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var rules robotsRules
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll(), nil
+	case resp.StatusCode >= 400:
+		// No robots.txt: everything is allowed.
+	default:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+		if err != nil {
+			return disallowAll(), nil
+		}
+		rules = parseRobots(string(body), productToken(f.cfg.UserAgent))
+	}
+	f.mu.Lock()
+	f.robots[site] = robotsEntry{rules: rules, expires: time.Now().Add(time.Hour)}
+	f.mu.Unlock()
+	return rules, nil
+}
+
+// productToken returns the user agent's name for robots.txt matching:
+// "darksuitai-fetch" for "darksuitai-fetch/1.0 (+https://...)".
+func productToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	token, _, _ = strings.Cut(token, "/")
+	return strings.ToLower(token)
+}
@@ -0,0 +1,359 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+	"golang.org/x/net/html"
+)
+
+const articlePage = `<!doctype html>
+<html><head><title>Ignored title</title><meta property="og:title" content="Go 1.30 is released"></head>
+<body>
+<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+<div class="cookie-banner">We use cookies. <button>OK</button></div>
+<article>
+  <h1>Go 1.30 is released</h1>
+  <p>Today the Go team is <strong>very happy</strong> to announce the release of Go 1.30, which you can get from the
+  <a href="/dl/">download page</a>. It brings <em>faster</em> builds, a smaller runtime, and new packages.</p>
+  <h2>What's new</h2>
+  <ul><li>Generic methods</li><li>A new <code>iter</code> helper<ul><li>nested</li></ul></li></ul>
+  <ol><li>First</li><li>Second</li></ol>
+  <pre><code>go install golang.org/dl/go1.30@latest
+go1.30 download</code></pre>
+  <blockquote><p>Upgrade today.</p></blockquote>
+  <table><tr><th>OS</th><th>Arch</th></tr><tr><td>linux</td><td>amd64</td></tr></table>
+  <div class="share-buttons"><a href="https://twitter.com/share">Share</a></div>
+  <p style="display: none">hidden text</p>
+</article>
+<aside>Related posts</aside>
+<footer>Copyright</footer>
+<script>var x = 1;</script>
+</body></html>`
+
+func TestExtract_Article(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(articlePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://go.dev/blog/go1.30")
+	title, md := Extract(root, base)
+	if title != "Go 1.30 is released" {
+		t.Errorf("title = %q", title)
+	}
+	for _, want := range []string{
+		"# Go 1.30 is released",
+		"Today the Go team is **very happy** to announce",
+		"[download page](https://go.dev/dl/). It brings _faster_ builds",
+		"## What's new",
+		"- Generic methods\n- A new `iter` helper\n  - nested",
+		"1. First\n2. Second",
+		"```\ngo install golang.org/dl/go1.30@latest\ngo1.30 download\n```",
+		"> Upgrade today.",
+		"| OS | Arch |\n| --- | --- |\n| linux | amd64 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("missing %q in:\n%s", want, md)
+		}
+	}
+	for _, unwanted := range []string{"Home", "cookies", "Share", "hidden text", "Related", "Copyright", "var x"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("boilerplate %q kept in:\n%s", unwanted, md)
+		}
+	}
+}
+
+func TestExtract_ScoresParagraphs(t *testing.T) {
+	page := `<html><body>
+	<div id="menu"><a href="/a">A long list of links to other sections of the site</a></div>
+	<div class="links"><p><a href="/x">Link one, link two, link three, link four and more</a></p></div>
+	<div class="story-wrapper">
+	  <p>The first paragraph of the story, long enough to count, with commas, and detail.</p>
+	  <p>The second paragraph continues the story, and it is also long enough to count.</p>
+	</div>
+	</body></html>`
+	root, _ := html.Parse(strings.NewReader(page))
+	_, md := Extract(root, nil)
+	if !strings.HasPrefix(md, "The first paragraph") || strings.Contains(md, "Link one") || strings.Contains(md, "long list") {
+		t.Errorf("md = %q", md)
+	}
+}
+
+// site serves the given paths; other paths are 404s.
+func site(t *testing.T, pages map[string]func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := pages[r.URL.Path]; ok {
+			h(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serve(contentType, body string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		io.WriteString(w, body)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	var userAgent string
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){
+		"/post": func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.Header.Get("User-Agent")
+			serve("text/html; charset=utf-8", articlePage)(w, r)
+		},
+		"/moved":     func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/post", http.StatusFound) },
+		"/notes.txt": serve("text/plain", "plain notes"),
+		"/file.zip":  serve("application/zip", "PK"),
+		"/busy":      func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+	})
+	f := New(Config{AllowPrivateNetworks: true})
+
+	page, err := f.Fetch(context.Background(), srv.URL+"/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.URL != srv.URL+"/post" || page.Title != "Go 1.30 is released" || page.ContentType != "text/html" ||
+		!strings.Contains(page.Content, "[download page]("+srv.URL+"/dl/)") {
+		t.Errorf("page = %+v", page)
+	}
+	if userAgent != DefaultUserAgent {
+		t.Errorf("User-Agent = %q", userAgent)
+	}
+
+	if page, err := f.Fetch(context.Background(), srv.URL+"/notes.txt"); err != nil || page.Content != "plain notes" {
+		t.Errorf("text: %+v, %v", page, err)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/file.zip"); err == nil || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Errorf("zip: %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/busy"); err == nil || !tools.IsRetryable(err) {
+		t.Errorf("503: %v", err)
+	}
+}
+
+func TestFetch_RefusesPrivateAddresses(t *testing.T) {
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){"/": serve("text/plain", "secret")})
+	_, err := New(Config{IgnoreRobots: true}).Fetch(context.Background(), srv.URL+"/")
+	if !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("error = %v", err)
+	}
+
+	for _, host := range []string{"127.0.0.1", "10.1.2.3", "169.254.169.254", "100.100.100.200", "0.1.2.3",
+		"::1", "[::ffff:192.168.0.1]", "[64:ff9b::a9fe:a9fe]", "fd00::1", "ff02::1"} {
+		if err := refusePrivate("tcp", net.JoinHostPort(strings.Trim(host, "[]"), "80"), nil); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("%s: error = %v", host, err)
+		}
+	}
+	for _, host := range []string{"93.184.216.34", "100.128.0.1", "2606:4700::1111"} {
+		if err := refusePrivate("tcp", net.JoinHostPort(host, "443"), nil); err != nil {
+			t.Errorf("%s refused: %v", host, err)
+		}
+	}
+}
+
+func TestFetch_Domains(t *testing.T) {
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){
+		"/away": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://blocked.example/", http.StatusFound)
+		},
+	})
+	ctx := context.Background()
+	f := New(Config{AllowPrivateNetworks: true, DenyDomains: []string{"blocked.example", "evil.com"}})
+	for _, u := range []string{"ftp://example.com/file", "https://evil.com/", "https://www.evil.com/x", srv.URL + "/away"} {
+		if _, err := f.Fetch(ctx, u); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("%s: error = %v", u, err)
+		}
+	}
+
+	allowed := New(Config{AllowPrivateNetworks: true, AllowDomains: []string{"go.dev"}})
+	if _, err := allowed.Fetch(ctx, "https://example.com/"); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("unlisted domain: %v", err)
+	}
+	if !matchDomain("pkg.go.dev", []string{"*.go.dev"}) || matchDomain("notgo.dev", []string{"go.dev"}) {
+		t.Error("matchDomain")
+	}
+}
+
+func TestFetch_Robots(t *testing.T) {
+	robotsCalls := 0
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){
+		"/robots.txt": func(w http.ResponseWriter, r *http.Request) {
+			robotsCalls++
+			serve("text/plain", "User-agent: *\nDisallow: /private\n\nUser-agent: other-bot\nDisallow: /\n")(w, r)
+		},
+		"/private/page": serve("text/plain", "private"),
+		"/public":       serve("text/plain", "public"),
+	})
+	f := New(Config{AllowPrivateNetworks: true})
+	if _, err := f.Fetch(context.Background(), srv.URL+"/private/page"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("disallowed: %v", err)
+	}
+	if page, err := f.Fetch(context.Background(), srv.URL+"/public"); err != nil || page.Content != "public" {
+		t.Errorf("allowed: %+v, %v", page, err)
+	}
+	if robotsCalls != 1 {
+		t.Errorf("robots.txt fetched %d times", robotsCalls)
+	}
+
+	ignoring := New(Config{AllowPrivateNetworks: true, IgnoreRobots: true})
+	if _, err := ignoring.Fetch(context.Background(), srv.URL+"/private/page"); err != nil {
+		t.Errorf("IgnoreRobots: %v", err)
+	}
+	mine := New(Config{AllowPrivateNetworks: true, UserAgent: "other-bot/2.0"})
+	if _, err := mine.Fetch(context.Background(), srv.URL+"/public"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("agent group: %v", err)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(`
+User-agent: darksuitai-fetch
+User-agent: other
+Disallow: /docs/
+Allow: /docs/public/
+Disallow: /*.pdf$
+Disallow:
+
+User-agent: *
+Disallow: /
+`, "darksuitai-fetch")
+	for path, want := range map[string]bool{
+		"/":                  true,
+		"/docs/a":            false,
+		"/docs/public/a":     true,
+		"/files/report.pdf":  false,
+		"/files/report.pdfx": true,
+	} {
+		if got := rules.allowed(path); got != want {
+			t.Errorf("allowed(%q) = %v", path, got)
+		}
+	}
+	if parseRobots("User-agent: *\nDisallow: /\n", "darksuitai-fetch").allowed("/x") {
+		t.Error("wildcard group not applied")
+	}
+}
+
+func TestFetch_Limits(t *testing.T) {
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){
+		"/big": serve("text/plain", strings.Repeat("a", 100)),
+		"/slow": func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+		},
+	})
+	f := New(Config{AllowPrivateNetworks: true, IgnoreRobots: true, MaxBytes: 10, Timeout: 50 * time.Millisecond})
+	page, err := f.Fetch(context.Background(), srv.URL+"/big")
+	if err != nil || len(page.Content) != 10 || !page.Truncated {
+		t.Errorf("big: %+v, %v", page, err)
+	}
+	start := time.Now()
+	if _, err := f.Fetch(context.Background(), srv.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("slow: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("timeout not enforced")
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	if pages := Paginate("short", 10); len(pages) != 1 || pages[0] != "short" {
+		t.Errorf("short: %q", pages)
+	}
+	para := strings.Repeat("word ", 6) // 30 characters
+	content := strings.Join([]string{para, para, para, strings.Repeat("x ", 50)}, "\n\n")
+	pages := Paginate(content, 16) // 64 characters a page
+	if len(pages) < 3 {
+		t.Fatalf("pages = %q", pages)
+	}
+	for _, p := range pages {
+		if len(p) > 64 {
+			t.Errorf("page of %d characters: %q", len(p), p)
+		}
+	}
+	if !strings.HasPrefix(pages[0], strings.TrimSpace(para)) || !strings.Contains(pages[0], "\n\n") {
+		t.Errorf("first page = %q", pages[0])
+	}
+}
+
+func TestTool(t *testing.T) {
+	long := "<html><head><title>Long</title></head><body><article>" +
+		strings.Repeat("<p>"+strings.Repeat("lorem ipsum ", 30)+"</p>", 6) + "</article></body></html>"
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){
+		"/long": serve("text/html", long),
+	})
+	tool := NewTool(Config{AllowPrivateNetworks: true, IgnoreRobots: true, MaxTokens: 200})
+	if tool.Name != ToolName || tool.InputSchema["url"] == nil {
+		t.Fatalf("tool = %+v", tool)
+	}
+
+	out, raw, err := tool.Run(nil, srv.URL+"/long")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "# Long\nURL: "+srv.URL+"/long\n\nlorem ipsum") ||
+		!strings.Contains(out, `[Page 1 of 3. Call web_fetch with "page": 2 to read on.]`) {
+		t.Errorf("out = %q", out)
+	}
+	if page, ok := raw[0].(*Page); !ok || page.Title != "Long" {
+		t.Errorf("raw = %#v", raw)
+	}
+
+	out, _, err = tool.Run(nil, `{"url": "`+srv.URL+`/long", "page": 3}`)
+	if err != nil || !strings.HasSuffix(out, "[Page 3 of 3.]") {
+		t.Errorf("page 3: %q, %v", out, err)
+	}
+	if _, _, err := tool.Run(nil, `{"url": "`+srv.URL+`/long", "page": 9}`); err == nil || !strings.Contains(err.Error(), "has 3") {
+		t.Errorf("page 9: %v", err)
+	}
+}
+
+func TestTool_FetchesOncePerRun(t *testing.T) {
+	long := "<html><body><article>" + strings.Repeat("<p>"+strings.Repeat("lorem ipsum ", 30)+"</p>", 6) + "</article></body></html>"
+	fetches := 0
+	srv := site(t, map[string]func(http.ResponseWriter, *http.Request){
+		"/long": func(w http.ResponseWriter, r *http.Request) {
+			fetches++
+			serve("text/html", long)(w, r)
+		},
+	})
+	tool := NewTool(Config{AllowPrivateNetworks: true, IgnoreRobots: true, MaxTokens: 200})
+
+	run := &tools.ToolContext{Context: context.Background(), RunID: "run-1"}
+	for page := 1; page <= 3; page++ {
+		if _, err := tool.Execute(run, fmt.Sprintf(`{"url": %q, "page": %d}`, srv.URL+"/long", page)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times in one run", fetches)
+	}
+
+	other := &tools.ToolContext{Context: context.Background(), RunID: "run-2"}
+	if _, err := tool.Execute(other, srv.URL+"/long"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tool.Run(nil, srv.URL+"/long"); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 3 {
+		t.Errorf("want a fetch for the new run and one outside any run, got %d fetches", fetches)
+	}
+}
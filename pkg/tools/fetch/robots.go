@@ -0,0 +1,118 @@
+package fetch
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// robotsRules are the Allow and Disallow rules of one robots.txt group.
+type robotsRules struct {
+	rules []robotsRule
+}
+
+type robotsRule struct {
+	pattern *regexp.Regexp
+	length  int // of the rule's path, for precedence
+	allow   bool
+}
+
+func disallowAll() robotsRules {
+	return robotsRules{rules: []robotsRule{{pattern: regexp.MustCompile(`^/`), length: 1}}}
+}
+
+// allowed reports whether path may be fetched: the longest matching rule
+// wins, Allow winning ties, and paths no rule matches are allowed.
+func (r robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if rule.pattern.MatchString(path) && (rule.length > best || (rule.length == best && rule.allow)) {
+			best, allow = rule.length, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsPath returns the part of u robots.txt rules are matched against.
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// parseRobots returns the rules of the groups naming agent, or of the "*"
+// groups when none does.
+func parseRobots(body, agent string) robotsRules {
+	type group struct {
+		agents []string
+		rules  []robotsRule
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+	for _, line := range strings.Split(body, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{pattern: robotsPattern(value), length: len(value), allow: key == "allow"})
+		default:
+			inAgents = false
+		}
+	}
+
+	var matched, wildcard []robotsRule
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*":
+				wildcard = append(wildcard, g.rules...)
+			case a == agent:
+				matched = append(matched, g.rules...)
+			}
+		}
+	}
+	if matched != nil {
+		return robotsRules{rules: matched}
+	}
+	return robotsRules{rules: wildcard}
+}
+
+// robotsPattern compiles a rule path, where "*" matches any characters and
+// a trailing "$" anchors the end.
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	if !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "*") {
+		path = "/" + path
+	}
+	parts := strings.Split(path, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,204 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory/ingest"
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// ToolName is the name of the tool built by NewTool.
+const ToolName = "web_fetch"
+
+// pageTTL is how long the tool keeps a fetched page for later pages of the
+// same run.
+const pageTTL = 10 * time.Minute
+
+type toolInput struct {
+	URL  string `json:"url" jsonschema:"required,description=The http(s) URL of the page to read"`
+	Page int    `json:"page,omitempty" jsonschema:"minimum=1,description=Which page of a long document to read; 1 by default"`
+}
+
+/*
+NewTool returns a tool that reads a web page: it fetches the URL under cfg
+(see Fetcher), and hands the model the page's readable content as Markdown,
+one page of cfg.MaxTokens at a time. Long documents end with a note telling
+the model which page to ask for next; the document is fetched once per agent
+run, and later pages are read from that copy. In ReAct mode the plain-text
+input is the URL.
+
+The tool's single raw result is the *Page, with the full content.
+
+Example:
+
+	reader := fetch.NewTool(fetch.Config{
+		DenyDomains: []string{"facebook.com"},
+		MaxTokens:   3000,
+	})
+*/
+func NewTool(cfg Config) tools.BaseTool {
+	fetcher := New(cfg)
+	properties, required, err := tools.SchemaFor[toolInput]()
+	if err != nil {
+		panic(fmt.Sprintf("fetch: %v", err))
+	}
+	description := "Reads a web page and returns its main content as Markdown. Use it to read pages found with a search. Input: the page's URL."
+	schema := tools.BaseTool{InputSchema: properties, Required: required}
+	cache := &pageCache{pages: make(map[pageKey]cachedPage)}
+
+	tool := tools.NewContextTool(ToolName, description, func(tc *tools.ToolContext, input string) (string, []interface{}, error) {
+		if err := tools.ValidateInput(schema, input); err != nil {
+			return "", nil, fmt.Errorf("invalid input: %w", err)
+		}
+		args, err := tools.InputArguments(schema, input)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid input: %w", err)
+		}
+		var in toolInput
+		if err := json.Unmarshal(args, &in); err != nil {
+			return "", nil, fmt.Errorf("invalid input: %w", err)
+		}
+		if in.Page == 0 {
+			in.Page = 1
+		}
+
+		key := pageKey{runID: tc.RunID, url: in.URL}
+		page := cache.get(key)
+		if page == nil {
+			tc.Progress("fetching", map[string]any{"url": in.URL})
+			if page, err = fetcher.Fetch(tc, in.URL); err != nil {
+				return "", nil, err
+			}
+			cache.put(key, page)
+		}
+		pages := Paginate(page.Content, fetcher.cfg.MaxTokens)
+		if in.Page > len(pages) {
+			return "", nil, fmt.Errorf("invalid input: page %d requested, but the document has %d", in.Page, len(pages))
+		}
+		return render(page, pages, in.Page), []interface{}{page}, nil
+	})
+	tool.InputSchema = properties
+	tool.Required = required
+	return tool
+}
+
+// pageCache keeps the pages fetched in each agent run, so the model can read
+// a long document page by page without it being downloaded again.
+type pageCache struct {
+	mu    sync.Mutex
+	pages map[pageKey]cachedPage
+}
+
+type pageKey struct {
+	runID, url string
+}
+
+type cachedPage struct {
+	page    *Page
+	expires time.Time
+}
+
+// get returns the cached page for key, or nil. Calls outside a run are not
+// cached.
+func (c *pageCache) get(key pageKey) *Page {
+	if key.runID == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.pages[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.page
+}
+
+// put caches page under key, dropping expired pages.
+func (c *pageCache) put(key pageKey, page *Page) {
+	if key.runID == "" {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.pages {
+		if now.After(entry.expires) {
+			delete(c.pages, k)
+		}
+	}
+	c.pages[key] = cachedPage{page: page, expires: now.Add(pageTTL)}
+}
+
+// render formats page number n of pages for the model.
+func render(page *Page, pages []string, n int) string {
+	var b strings.Builder
+	if page.Title != "" {
+		b.WriteString("# " + page.Title + "\n")
+	}
+	b.WriteString("URL: " + page.URL + "\n\n")
+	if content := pages[n-1]; content != "" {
+		b.WriteString(content)
+	} else {
+		b.WriteString("(The page has no readable content.)")
+	}
+	if len(pages) > 1 {
+		fmt.Fprintf(&b, "\n\n[Page %d of %d.", n, len(pages))
+		if n < len(pages) {
+			fmt.Fprintf(&b, ` Call %s with "page": %d to read on.`, ToolName, n+1)
+		}
+		b.WriteString("]")
+	}
+	if page.Truncated {
+		b.WriteString("\n\n[The document was longer than the download limit and was cut.]")
+	}
+	return b.String()
+}
+
+// Paginate splits content into pages of about maxTokens tokens (four
+// characters per token), breaking between paragraphs where it can. It always
+// returns at least one page.
+func Paginate(content string, maxTokens int) []string {
+	size := maxTokens * 4
+	if size <= 0 || utf8.RuneCountInString(content) <= size {
+		return []string{content}
+	}
+	var pages []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			pages = append(pages, current.String())
+			current.Reset()
+		}
+	}
+	for _, para := range strings.Split(content, "\n\n") {
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		pieces := []string{para}
+		if utf8.RuneCountInString(para) > size {
+			pieces = pieces[:0]
+			for _, c := range (ingest.FixedChunker{Size: size, Overlap: -1}).Chunk(para) {
+				pieces = append(pieces, c.Text)
+			}
+		}
+		for _, piece := range pieces {
+			if current.Len() > 0 && utf8.RuneCountInString(current.String())+2+utf8.RuneCountInString(piece) > size {
+				flush()
+			}
+			if current.Len() > 0 {
+				current.WriteString("\n\n")
+			}
+			current.WriteString(piece)
+		}
+	}
+	flush()
+	if len(pages) == 0 {
+		return []string{""}
+	}
+	return pages
+}
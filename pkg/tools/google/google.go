@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
@@ -35,7 +36,11 @@ type GoogleSerperAPIWrapper struct {
 	wg               sync.WaitGroup
 }
 
-const API_KEY string = "1703d54836a59537c8256940b15809260dce88ad"
+// serperAPIKey returns the Serper API key from the SERPER_API_KEY environment
+// variable.
+func serperAPIKey() string {
+	return os.Getenv("SERPER_API_KEY")
+}
 
 func NewGoogleSerperAPIWrapper() *GoogleSerperAPIWrapper {
 	return &GoogleSerperAPIWrapper{
@@ -49,7 +54,7 @@ func NewGoogleSerperAPIWrapper() *GoogleSerperAPIWrapper {
 			"images": "images",
 			"search": "organic",
 		},
-		SerperAPIKey: API_KEY,
+		SerperAPIKey: serperAPIKey(),
 		AioSession: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -183,16 +188,18 @@ func (w *GoogleSerperAPIWrapper) googleSerperAPIResults(
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		exp.Loggers.System.Warn(err)
+		return nil
 	}
 
 	// Set the request headers
 	req.Header.Set("Content-Type", "application/json")
 	// Set the request headers
-	req.Header.Set("X-API-KEY", API_KEY)
+	req.Header.Set("X-API-KEY", w.SerperAPIKey)
 
 	resp, err := w.AioSession.Do(req)
 	if err != nil {
 		exp.Loggers.System.Warn(err)
+		return nil
 	}
 	defer resp.Body.Close()
 
@@ -210,6 +217,9 @@ func (w *GoogleSerperAPIWrapper) googleSerperAPIResults(
 }
 
 func GoogleSearchAndImages(query string, toolsMeta map[string]interface{}) (string, []interface{},error) {
+	if serperAPIKey() == "" {
+		return "", nil, fmt.Errorf("google search: no Serper API key (set SERPER_API_KEY)")
+	}
 	var wg sync.WaitGroup
 	var searchResult *string
 	var imgResult *[]string
@@ -231,7 +241,9 @@ func GoogleSearchAndImages(query string, toolsMeta map[string]interface{}) (stri
 	// Convert imgResult to []interface{}
 	var imgResultInterface = make([]interface{}, 1)
 
-	imgResultInterface[0] = append(imgResultInterface, *imgResult)
+	if imgResult != nil {
+		imgResultInterface[0] = append(imgResultInterface, *imgResult)
+	}
 
 	return *searchResult, imgResultInterface, nil
 }
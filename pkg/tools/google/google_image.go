@@ -53,11 +53,12 @@ func googleImages(query string) *[]string {
 	// Set the request headers
 	req.Header.Set("Content-Type", "application/json")
 	// Set the request headers
-	req.Header.Set("X-API-KEY", API_KEY)
+	req.Header.Set("X-API-KEY", serperAPIKey())
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		exp.Loggers.System.Warn(err)
+		return nil
 	}
 
 	defer resp.Body.Close()
@@ -0,0 +1,473 @@
+// Package openapi turns the operations of an OpenAPI 3 specification into
+// agent tools: one tools.BaseTool per operation, whose input schema is built
+// from the operation's parameters and request body and whose Handler calls
+// the API over HTTP.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// Config controls how tools are built from a spec and how they call the API.
+type Config struct {
+	// BaseURL is the API's base URL. It defaults to the spec's first server
+	// URL, which must then be absolute.
+	BaseURL string
+	// Operations is an allowlist of operations to expose, by operationId or
+	// as "METHOD /path" (e.g. "GET /pets/{petId}"). Empty exposes every
+	// operation that is not deprecated.
+	Operations []string
+	// Headers are sent with every request, e.g. an Authorization or API-key
+	// header. They take precedence over header parameters of the same name.
+	Headers http.Header
+	// Authorize, when set, is called on every request before it is sent, for
+	// credentials that change (e.g. short-lived OAuth tokens).
+	Authorize func(req *http.Request) error
+	// NamePrefix is prepended to every tool name, e.g. "billing_".
+	NamePrefix string
+	// Client sends the requests; a client with a 30 second timeout when nil.
+	Client *http.Client
+	// MaxResponseChars caps the response text shown to the model. Defaults
+	// to 20000.
+	MaxResponseChars int
+}
+
+// LoadFile reads a spec (JSON or YAML) from a file and builds its tools.
+func LoadFile(path string, cfg Config) ([]tools.BaseTool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return Load(data, cfg)
+}
+
+// LoadURL fetches a spec (JSON or YAML) and builds its tools. Relative
+// server URLs in the spec are resolved against specURL.
+func LoadURL(ctx context.Context, specURL string, cfg Config) ([]tools.BaseTool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.Headers {
+		req.Header[k] = v
+	}
+	resp, err := httpClient(cfg).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: fetching spec: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openapi: fetching spec: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("openapi: fetching spec: %w", err)
+	}
+	if cfg.BaseURL == "" {
+		doc, err := parseDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Servers) > 0 {
+			base, _ := url.Parse(specURL)
+			if server, err := base.Parse(doc.Servers[0].URL); err == nil {
+				cfg.BaseURL = server.String()
+			}
+		}
+	}
+	return Load(data, cfg)
+}
+
+/*
+Load builds one tool per operation of an OpenAPI 3 spec (JSON or YAML).
+
+Tools are named after the operationId (or the method and path when there is
+none) and described by the operation's summary. Their input schema has one
+property per parameter (path, query and header) and a "body" property for a
+JSON request body; local $refs are inlined. When the model calls a tool, path
+parameters are substituted into the URL, query parameters are added to it
+(arrays as repeated parameters), header parameters are sent as headers, and
+"body" is sent as JSON. The response body is returned to the model, and the
+decoded JSON (or text) as the tool's raw result; a response status of 400 or
+above is returned as the tool's error.
+
+Example:
+
+	tools, err := openapi.LoadFile("billing.yaml", openapi.Config{
+		Operations: []string{"getInvoice", "listInvoices"},
+		Headers:    http.Header{"Authorization": {"Bearer " + token}},
+	})
+*/
+func Load(spec []byte, cfg Config) ([]tools.BaseTool, error) {
+	doc, err := parseDocument(spec)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxResponseChars <= 0 {
+		cfg.MaxResponseChars = 20000
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" && len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+	}
+	if u, err := url.Parse(baseURL); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("openapi: an absolute base URL is required (set Config.BaseURL), got %q", baseURL)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	allowed := make(map[string]bool, len(cfg.Operations))
+	for _, op := range cfg.Operations {
+		allowed[normalizeOperationKey(op)] = false
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var out []tools.BaseTool
+	names := map[string]bool{}
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, mo := range item.operations() {
+			keys := []string{normalizeOperationKey(mo.method + " " + path)}
+			if mo.op.OperationID != "" {
+				keys = append(keys, normalizeOperationKey(mo.op.OperationID))
+			}
+			if len(allowed) > 0 {
+				match := false
+				for _, k := range keys {
+					if _, ok := allowed[k]; ok {
+						allowed[k] = true
+						match = true
+					}
+				}
+				if !match {
+					continue
+				}
+			} else if mo.op.Deprecated {
+				continue
+			}
+
+			op, err := newOperationTool(doc, cfg, baseURL, path, mo.method, mo.op, item.Parameters)
+			if err != nil {
+				return nil, err
+			}
+			if names[op.tool.Name] {
+				return nil, fmt.Errorf("openapi: two operations are named %q", op.tool.Name)
+			}
+			names[op.tool.Name] = true
+			out = append(out, op.tool)
+		}
+	}
+
+	var unknown []string
+	for _, op := range cfg.Operations {
+		if !allowed[normalizeOperationKey(op)] {
+			unknown = append(unknown, op)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("openapi: operations not found in the spec: %s", strings.Join(unknown, ", "))
+	}
+	return out, nil
+}
+
+func normalizeOperationKey(key string) string {
+	method, path, ok := strings.Cut(strings.TrimSpace(key), " ")
+	if !ok {
+		return key
+	}
+	return strings.ToUpper(method) + " " + strings.TrimSpace(path)
+}
+
+// operationTool holds what a tool needs to call one operation.
+type operationTool struct {
+	tool     tools.BaseTool
+	cfg      Config
+	baseURL  string
+	path     string
+	method   string
+	params   []parameter
+	bodyName string // "" when the operation takes no JSON body
+}
+
+func newOperationTool(doc *document, cfg Config, baseURL, path, method string, op *operation, shared []parameter) (*operationTool, error) {
+	ot := &operationTool{cfg: cfg, baseURL: baseURL, path: path, method: method}
+
+	// Operation parameters override path-level ones with the same name and
+	// location.
+	params := map[string]parameter{}
+	var order []string
+	for _, p := range append(append([]parameter(nil), shared...), op.Parameters...) {
+		if p.Ref != "" {
+			var resolved parameter
+			if err := doc.resolveInto(p.Ref, &resolved); err != nil {
+				return nil, err
+			}
+			p = resolved
+		}
+		if p.In == "cookie" {
+			continue
+		}
+		key := p.In + ":" + p.Name
+		if _, seen := params[key]; !seen {
+			order = append(order, key)
+		}
+		params[key] = p
+	}
+
+	properties := map[string]any{}
+	var required []string
+	for _, key := range order {
+		p := params[key]
+		schema, err := doc.inlineSchema(p.Schema, 0)
+		if err != nil {
+			return nil, err
+		}
+		if schema == nil {
+			schema = map[string]any{"type": "string"}
+		}
+		if p.Description != "" {
+			schema["description"] = p.Description
+		}
+		if _, clash := properties[p.Name]; clash {
+			return nil, fmt.Errorf("openapi: %s %s has two parameters named %q", method, path, p.Name)
+		}
+		properties[p.Name] = schema
+		if p.Required || p.In == "path" {
+			required = append(required, p.Name)
+		}
+		ot.params = append(ot.params, p)
+	}
+
+	if body := op.RequestBody; body != nil {
+		if body.Ref != "" {
+			var resolved requestBody
+			if err := doc.resolveInto(body.Ref, &resolved); err != nil {
+				return nil, err
+			}
+			body = &resolved
+		}
+		if media, ok := jsonMedia(body.Content); ok {
+			ot.bodyName = "body"
+			if _, clash := properties["body"]; clash {
+				ot.bodyName = "request_body"
+			}
+			schema, err := doc.inlineSchema(media.Schema, 0)
+			if err != nil {
+				return nil, err
+			}
+			if schema == nil {
+				schema = map[string]any{}
+			}
+			if body.Description != "" {
+				schema["description"] = body.Description
+			}
+			properties[ot.bodyName] = schema
+			if body.Required {
+				required = append(required, ot.bodyName)
+			}
+		}
+	}
+
+	description := strings.TrimSpace(op.Summary)
+	if description == "" {
+		description = strings.TrimSpace(op.Description)
+	}
+	if description == "" {
+		description = method + " " + path
+	}
+	name := op.OperationID
+	if name == "" {
+		name = strings.ToLower(method) + "_" + path
+	}
+	ot.tool = tools.NewContextTool(toolName(cfg.NamePrefix+name), description, ot.call)
+	ot.tool.InputSchema = properties
+	ot.tool.Required = required
+	return ot, nil
+}
+
+// jsonMedia returns the JSON media type of a request body, if it has one.
+func jsonMedia(content map[string]mediaType) (mediaType, bool) {
+	if m, ok := content["application/json"]; ok {
+		return m, true
+	}
+	for ct, m := range content {
+		if strings.HasSuffix(strings.SplitN(ct, ";", 2)[0], "+json") {
+			return m, true
+		}
+	}
+	return mediaType{}, false
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// toolName makes name valid for provider tool calling: letters, digits, _
+// and -, at most 64 characters. Runs of other characters become one _.
+func toolName(name string) string {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// call runs the operation with the model's arguments.
+func (ot *operationTool) call(tc *tools.ToolContext, input string) (string, []interface{}, error) {
+	raw, err := tools.InputArguments(ot.tool, input)
+	if err != nil {
+		return "", nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var args map[string]any
+	if err := dec.Decode(&args); err != nil {
+		return "", nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	req, err := ot.request(tc, args)
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := httpClient(ot.cfg).Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", ot.tool.Name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: reading response: %w", ot.tool.Name, err)
+	}
+
+	text := truncate(strings.TrimSpace(string(data)), ot.cfg.MaxResponseChars)
+	if resp.StatusCode >= 400 {
+		err := fmt.Errorf("%s: HTTP %d %s: %s", ot.tool.Name, resp.StatusCode, http.StatusText(resp.StatusCode), truncate(text, 2000))
+		// Rate limits and gateway failures are worth retrying under a Policy.
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			err = tools.Retryable(err)
+		}
+		return "", nil, err
+	}
+	if text == "" {
+		text = fmt.Sprintf("HTTP %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	var decoded any = string(data)
+	if json.Valid(data) {
+		_ = json.Unmarshal(data, &decoded)
+	}
+	return text, []interface{}{decoded}, nil
+}
+
+// request builds the HTTP request for args.
+func (ot *operationTool) request(ctx context.Context, args map[string]any) (*http.Request, error) {
+	path := ot.path
+	query := url.Values{}
+	header := http.Header{}
+	for _, p := range ot.params {
+		v, ok := args[p.Name]
+		if !ok || v == nil {
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(paramString(v)))
+		case "query":
+			if list, ok := v.([]any); ok {
+				for _, item := range list {
+					query.Add(p.Name, paramString(item))
+				}
+			} else {
+				query.Set(p.Name, paramString(v))
+			}
+		case "header":
+			header.Set(p.Name, paramString(v))
+		}
+	}
+	if strings.Contains(path, "{") {
+		return nil, fmt.Errorf("invalid input: missing path parameters for %s", path)
+	}
+
+	target := ot.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var body io.Reader
+	if ot.bodyName != "" {
+		if v, ok := args[ot.bodyName]; ok {
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input: %w", err)
+			}
+			body = bytes.NewReader(encoded)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, ot.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ot.tool.Name, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	// Configured headers go last, so a header parameter chosen by the model
+	// cannot replace a credential.
+	for k, v := range ot.cfg.Headers {
+		req.Header.Del(k)
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, */*;q=0.5")
+	if ot.cfg.Authorize != nil {
+		if err := ot.cfg.Authorize(req); err != nil {
+			return nil, fmt.Errorf("%s: authorizing request: %w", ot.tool.Name, err)
+		}
+	}
+	return req, nil
+}
+
+// paramString formats a parameter value for a URL or header.
+func paramString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool, nil:
+		return fmt.Sprint(v)
+	}
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
+
+func truncate(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+func httpClient(cfg Config) *http.Client {
+	if cfg.Client != nil {
+		return cfg.Client
+	}
+	return defaultClient
+}
+
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
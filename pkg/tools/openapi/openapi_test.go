@@ -0,0 +1,226 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+const petSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1"
+servers:
+  - url: https://pets.example.com/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets.
+      parameters:
+        - name: tag
+          in: query
+          schema: {type: array, items: {type: string}}
+        - $ref: '#/components/parameters/Limit'
+    post:
+      operationId: createPet
+      summary: Create a pet.
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/NewPet'}
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        schema: {type: integer}
+    get:
+      operationId: getPet
+      summary: Get one pet.
+      parameters:
+        - name: X-Trace
+          in: header
+          schema: {type: string}
+    delete:
+      summary: Delete a pet.
+      deprecated: true
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      description: Page size.
+      schema: {type: integer, maximum: 100}
+  schemas:
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        owner: {$ref: '#/components/schemas/Owner'}
+    Owner:
+      type: object
+      properties:
+        email: {type: string}
+`
+
+type recorded struct {
+	method, path, query, body, auth, trace string
+}
+
+func newPetServer(t *testing.T) (*httptest.Server, *[]recorded) {
+	t.Helper()
+	var calls []recorded
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, recorded{r.Method, r.URL.Path, r.URL.RawQuery, string(body), r.Header.Get("Authorization"), r.Header.Get("X-Trace")})
+		if r.URL.Path == "/v1/pets/404" {
+			http.Error(w, `{"error":"no such pet"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func byName(t *testing.T, list []tools.BaseTool, name string) tools.BaseTool {
+	t.Helper()
+	for _, tool := range list {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	t.Fatalf("no tool %s", name)
+	return tools.BaseTool{}
+}
+
+func TestLoad_Schemas(t *testing.T) {
+	list, err := Load([]byte(petSpec), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The deprecated delete operation is skipped.
+	if len(list) != 3 {
+		t.Fatalf("got %d tools", len(list))
+	}
+	get := byName(t, list, "getPet")
+	if get.Description != "Get one pet." || strings.Join(get.Required, ",") != "petId" {
+		t.Errorf("getPet = %q %v", get.Description, get.Required)
+	}
+	if get.InputSchema["X-Trace"] == nil {
+		t.Errorf("header parameter missing: %v", get.InputSchema)
+	}
+
+	list2 := byName(t, list, "listPets")
+	limit, _ := list2.InputSchema["limit"].(map[string]any)
+	if limit["description"] != "Page size." || limit["type"] != "integer" {
+		t.Errorf("referenced parameter = %v", limit)
+	}
+
+	create := byName(t, list, "createPet")
+	body, _ := create.InputSchema["body"].(map[string]any)
+	owner, _ := body["properties"].(map[string]any)["owner"].(map[string]any)
+	if owner["type"] != "object" || strings.Join(create.Required, ",") != "body" {
+		t.Errorf("request body schema not inlined: %v", body)
+	}
+}
+
+func TestLoad_Allowlist(t *testing.T) {
+	list, err := Load([]byte(petSpec), Config{Operations: []string{"getPet", "delete /pets/{petId}"}, NamePrefix: "pets_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "pets_getPet" || list[1].Name != "pets_delete_pets_petId" {
+		names := []string{}
+		for _, tool := range list {
+			names = append(names, tool.Name)
+		}
+		t.Errorf("tools = %v", names)
+	}
+	if _, err := Load([]byte(petSpec), Config{Operations: []string{"getPets"}}); err == nil || !strings.Contains(err.Error(), "getPets") {
+		t.Errorf("unknown operation error = %v", err)
+	}
+}
+
+func TestOperationTool_Call(t *testing.T) {
+	srv, calls := newPetServer(t)
+	list, err := Load([]byte(petSpec), Config{
+		BaseURL: srv.URL + "/v1/",
+		Headers: http.Header{"Authorization": {"Bearer secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := byName(t, list, "getPet")
+	out, raw, err := get.ToolFunc(`{"petId":7,"X-Trace":"abc"}`, get.Name, nil)
+	if err != nil || out != `{"ok":true}` {
+		t.Fatalf("getPet = %q, %v", out, err)
+	}
+	if m, ok := raw[0].(map[string]any); !ok || m["ok"] != true {
+		t.Errorf("raw = %v", raw)
+	}
+
+	lst := byName(t, list, "listPets")
+	if _, _, err := lst.ToolFunc(`{"tag":["cat","dog"],"limit":5}`, lst.Name, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	create := byName(t, list, "createPet")
+	if _, _, err := create.ToolFunc(`{"body":{"name":"Rex","owner":{"email":"a@b.c"}}}`, create.Name, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := get.ToolFunc(`{"petId":404}`, get.Name, nil); err == nil || !strings.Contains(err.Error(), "HTTP 404") || !strings.Contains(err.Error(), "no such pet") {
+		t.Errorf("404 error = %v", err)
+	}
+
+	want := []recorded{
+		{"GET", "/v1/pets/7", "", "", "Bearer secret", "abc"},
+		{"GET", "/v1/pets", "limit=5&tag=cat&tag=dog", "", "Bearer secret", ""},
+		{"POST", "/v1/pets", "", `{"name":"Rex","owner":{"email":"a@b.c"}}`, "Bearer secret", ""},
+	}
+	for i, w := range want {
+		if (*calls)[i] != w {
+			got, _ := json.Marshal((*calls)[i])
+			t.Errorf("call %d = %+v (%s), want %+v", i, (*calls)[i], got, w)
+		}
+	}
+}
+
+func TestOperationTool_ConfigHeadersWin(t *testing.T) {
+	srv, calls := newPetServer(t)
+	list, err := Load([]byte(petSpec), Config{
+		BaseURL: srv.URL + "/v1/",
+		Headers: http.Header{"x-trace": {"configured"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := byName(t, list, "getPet")
+	if _, _, err := get.ToolFunc(`{"petId":7,"X-Trace":"from-model"}`, get.Name, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*calls)[0].trace; got != "configured" {
+		t.Errorf("X-Trace = %q, want the configured header", got)
+	}
+}
+
+func TestLoad_RequiresBaseURL(t *testing.T) {
+	spec := strings.Replace(petSpec, "https://pets.example.com/v1", "/v1", 1)
+	if _, err := Load([]byte(spec), Config{}); err == nil {
+		t.Error("relative server URL accepted without BaseURL")
+	}
+	if _, err := Load([]byte(`{"swagger":"2.0"}`), Config{}); err == nil {
+		t.Error("Swagger 2 spec accepted")
+	}
+}
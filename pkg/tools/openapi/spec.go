@@ -0,0 +1,221 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// document is the part of an OpenAPI 3 document the loader uses.
+type document struct {
+	OpenAPI    string              `json:"openapi"`
+	Servers    []server            `json:"servers"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components map[string]any      `json:"components"`
+	// raw is the whole document, for resolving $refs.
+	raw map[string]any
+}
+
+type server struct {
+	URL string `json:"url"`
+}
+
+type pathItem struct {
+	Parameters []parameter `json:"parameters"`
+	Get        *operation  `json:"get"`
+	Put        *operation  `json:"put"`
+	Post       *operation  `json:"post"`
+	Delete     *operation  `json:"delete"`
+	Patch      *operation  `json:"patch"`
+	Head       *operation  `json:"head"`
+	Options    *operation  `json:"options"`
+}
+
+// operations returns the item's operations by HTTP method, in a fixed order.
+func (p pathItem) operations() []methodOperation {
+	var out []methodOperation
+	for _, mo := range []methodOperation{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options},
+	} {
+		if mo.op != nil {
+			out = append(out, mo)
+		}
+	}
+	return out
+}
+
+type methodOperation struct {
+	method string
+	op     *operation
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Parameters  []parameter  `json:"parameters"`
+	RequestBody *requestBody `json:"requestBody"`
+	Deprecated  bool         `json:"deprecated"`
+}
+
+type parameter struct {
+	Ref         string         `json:"$ref"`
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Required    bool           `json:"required"`
+	Description string         `json:"description"`
+	Schema      map[string]any `json:"schema"`
+}
+
+type requestBody struct {
+	Ref         string               `json:"$ref"`
+	Required    bool                 `json:"required"`
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema map[string]any `json:"schema"`
+}
+
+// parseDocument reads an OpenAPI 3 document in JSON or YAML.
+func parseDocument(data []byte) (*document, error) {
+	var raw map[string]any
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("openapi: parsing JSON spec: %w", err)
+		}
+	} else {
+		var y any
+		if err := yaml.Unmarshal(data, &y); err != nil {
+			return nil, fmt.Errorf("openapi: parsing YAML spec: %w", err)
+		}
+		m, ok := fromYAML(y).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("openapi: spec is not an object")
+		}
+		raw = m
+	}
+
+	// Round-trip through JSON to fill the typed view.
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	var doc document
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: reading spec: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported spec version %q (OpenAPI 3.x is required)", doc.OpenAPI)
+	}
+	doc.raw = raw
+	return &doc, nil
+}
+
+// fromYAML converts the map[interface{}]interface{} values produced by
+// yaml.v2 into JSON-compatible map[string]any values.
+func fromYAML(v any) any {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = fromYAML(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = fromYAML(val)
+		}
+		return v
+	}
+	return v
+}
+
+// lookup resolves a local reference such as "#/components/schemas/Pet".
+func (d *document) lookup(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("openapi: only local references are supported, got %q", ref)
+	}
+	var cur any = d.raw
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("openapi: unresolved reference %q", ref)
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("openapi: unresolved reference %q", ref)
+		}
+	}
+	return cur, nil
+}
+
+// resolveInto decodes the target of ref into v.
+func (d *document) resolveInto(ref string, v any) error {
+	target, err := d.lookup(ref)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
+
+// maxSchemaDepth bounds schema inlining; deeper (typically recursive)
+// references are left as unconstrained values.
+const maxSchemaDepth = 8
+
+// inlineSchema returns schema with every $ref replaced by its target, so the
+// model sees one self-contained schema.
+func (d *document) inlineSchema(schema map[string]any, depth int) (map[string]any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if depth >= maxSchemaDepth {
+			return map[string]any{}, nil
+		}
+		target, err := d.lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := target.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("openapi: reference %q is not a schema", ref)
+		}
+		return d.inlineSchema(m, depth+1)
+	}
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		resolved, err := d.inlineValue(v, depth)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = resolved
+	}
+	return out, nil
+}
+
+func (d *document) inlineValue(v any, depth int) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		return d.inlineSchema(v, depth)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := d.inlineValue(item, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	}
+	return v, nil
+}
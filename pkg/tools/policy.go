@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Policy controls how a tool is run: a time limit per attempt, retries of
+// retryable errors with exponential backoff, a circuit breaker that stops
+// calling a failing tool for a while, and whether errors are reported to the
+// model. Set it on BaseTool.Policy, or with WithPolicy.
+//
+// The circuit breaker's state belongs to the *Policy, so give each tool its
+// own Policy.
+type Policy struct {
+	// Timeout limits each attempt. A tool that does not return in time is
+	// abandoned (its goroutine finishes in the background) and the attempt
+	// fails with context.DeadlineExceeded. Zero means no limit.
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a retryable error.
+	Retries int
+	// Backoff is the wait before the first retry, doubling for each retry
+	// after it up to MaxBackoff. It defaults to 500ms, and MaxBackoff to 10s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable reports whether an error is worth retrying. It defaults to
+	// IsRetryable.
+	Retryable func(error) bool
+
+	// BreakerThreshold opens the circuit breaker after that many calls in a
+	// row have failed; while it is open, calls fail at once with
+	// ErrCircuitOpen. Zero disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before a call is
+	// let through again. It defaults to 30s.
+	BreakerCooldown time.Duration
+
+	// ReportErrors sends the tool's errors to the model as its observation
+	// instead of failing the run. Agents using native tool calling always do
+	// this; the ReAct/XML executor fails the run by default.
+	ReportErrors bool
+
+	// state is the circuit breaker, created on first use.
+	state *breaker
+}
+
+// ErrCircuitOpen is returned, wrapped, for calls to a tool whose circuit
+// breaker is open. Agents report it to the model whatever the Policy says,
+// so it can answer without the tool.
+var ErrCircuitOpen = errors.New("tool temporarily disabled after repeated failures")
+
+// WithPolicy returns a copy of the tool that runs under its own copy of p.
+func (t BaseTool) WithPolicy(p Policy) BaseTool {
+	p.state = nil
+	t.Policy = &p
+	return t
+}
+
+// ReportsError reports whether err from a tool run under p should be sent
+// to the model as an observation rather than fail the run. p may be nil.
+func (p *Policy) ReportsError(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || (p != nil && p.ReportErrors)
+}
+
+type retryableError struct{ err error }
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// Retryable marks err as retryable (see IsRetryable), e.g. for a rate limit
+// or a temporary upstream failure. It returns nil for a nil err.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retryableError{err}
+}
+
+// IsRetryable reports whether err is marked with Retryable, is a timeout of
+// the attempt (context.DeadlineExceeded), or is a network timeout.
+func IsRetryable(err error) bool {
+	var marked retryableError
+	if errors.As(err, &marked) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// breaker is the circuit breaker state of one Policy.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// breakerInit guards creating a Policy's breaker.
+var breakerInit sync.Mutex
+
+func (p *Policy) breaker() *breaker {
+	if p.BreakerThreshold <= 0 {
+		return nil
+	}
+	breakerInit.Lock()
+	defer breakerInit.Unlock()
+	if p.state == nil {
+		p.state = &breaker{}
+	}
+	return p.state
+}
+
+// allow reports whether a call may go ahead, and if not, how long the
+// breaker stays open.
+func (b *breaker) allow(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.openUntil) {
+		return false, b.openUntil.Sub(now)
+	}
+	return true, 0
+}
+
+// record counts a call's outcome, opening the breaker after threshold
+// failures in a row. A failed call let through after the cooldown opens it
+// again at once.
+func (b *breaker) record(failed bool, threshold int, cooldown time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= threshold {
+		b.openUntil = now.Add(cooldown)
+	}
+}
+
+// run runs the tool under the policy.
+func (p *Policy) run(t BaseTool, tc *ToolContext, input string) (string, []interface{}, error) {
+	b := p.breaker()
+	cooldown := p.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	if b != nil {
+		if ok, wait := b.allow(time.Now()); !ok {
+			return "", nil, fmt.Errorf("%s: %w; try again in %s", t.Name, ErrCircuitOpen, wait.Round(time.Second))
+		}
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	backoff, maxBackoff := p.Backoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	parent := context.Background()
+	if tc != nil && tc.Context != nil {
+		parent = tc.Context
+	}
+
+	var (
+		output string
+		raw    []interface{}
+		err    error
+	)
+	for attempt := 0; ; attempt++ {
+		output, raw, err = p.attempt(t, tc, parent, input)
+		if err == nil || attempt >= p.Retries || !retryable(err) || parent.Err() != nil {
+			break
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-parent.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if parent.Err() != nil {
+			break
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+	// A run cancelled by the caller says nothing about the tool's health.
+	if b != nil && parent.Err() == nil {
+		b.record(err != nil, p.BreakerThreshold, cooldown, time.Now())
+	}
+	return output, raw, err
+}
+
+// attempt runs the tool once, abandoning it when it outlives the timeout or
+// the run's context.
+func (p *Policy) attempt(t BaseTool, tc *ToolContext, parent context.Context, input string) (string, []interface{}, error) {
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if p.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, p.Timeout)
+	}
+	defer cancel()
+	attemptCtx := ToolContext{}
+	if tc != nil {
+		attemptCtx = *tc
+	}
+	attemptCtx.Context = ctx
+
+	type result struct {
+		output string
+		raw    []interface{}
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, raw, err := t.run(&attemptCtx, input)
+		done <- result{output, raw, err}
+	}()
+	select {
+	case r := <-done:
+		return r.output, r.raw, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+			return "", nil, fmt.Errorf("%s did not finish within %s: %w", t.Name, p.Timeout, ctx.Err())
+		}
+		return "", nil, ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hung := BaseTool{Name: "hung", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		<-release
+		return "late", nil, nil
+	}}.WithPolicy(Policy{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	_, _, err := hung.Run(nil, "")
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "did not finish within 20ms") {
+		t.Errorf("error = %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("timeout not enforced")
+	}
+}
+
+func TestPolicy_Retries(t *testing.T) {
+	calls := 0
+	flaky := BaseTool{Name: "flaky", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		calls++
+		if calls < 3 {
+			return "", nil, Retryable(errors.New("503"))
+		}
+		return "ok", nil, nil
+	}}.WithPolicy(Policy{Retries: 2, Backoff: time.Millisecond})
+
+	if out, _, err := flaky.Run(nil, ""); err != nil || out != "ok" || calls != 3 {
+		t.Errorf("Run = %q, %v after %d calls", out, err, calls)
+	}
+
+	calls = 0
+	broken := BaseTool{Name: "broken", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		calls++
+		return "", nil, errors.New("bad request")
+	}}.WithPolicy(Policy{Retries: 5, Backoff: time.Millisecond})
+	if _, _, err := broken.Run(nil, ""); err == nil || calls != 1 {
+		t.Errorf("non-retryable error retried: %d calls, %v", calls, err)
+	}
+
+	// Cancelling the run stops the retries.
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := BaseTool{Name: "slow", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		calls++
+		cancel()
+		return "", nil, Retryable(errors.New("try later"))
+	}}.WithPolicy(Policy{Retries: 5, Backoff: time.Hour})
+	if _, _, err := slow.Run(&ToolContext{Context: ctx}, ""); err == nil || calls != 1 {
+		t.Errorf("cancelled run retried: %d calls, %v", calls, err)
+	}
+}
+
+func TestPolicy_CircuitBreaker(t *testing.T) {
+	fail := true
+	calls := 0
+	tool := BaseTool{Name: "search", ToolFunc: func(string, string, map[string]interface{}) (string, []interface{}, error) {
+		calls++
+		if fail {
+			return "", nil, fmt.Errorf("upstream down")
+		}
+		return "ok", nil, nil
+	}}.WithPolicy(Policy{BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := tool.Run(nil, ""); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	_, _, err := tool.Run(nil, "")
+	if !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("open breaker: %v after %d calls", err, calls)
+	}
+	if !tool.Policy.ReportsError(err) || tool.Policy.ReportsError(errors.New("other")) {
+		t.Error("ReportsError is wrong")
+	}
+
+	// Copies of the tool share the breaker; a fresh policy does not.
+	copied := tool
+	if _, _, err := copied.Run(nil, ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("copy of the tool: %v", err)
+	}
+	if _, _, err := tool.WithPolicy(*tool.Policy).Run(nil, ""); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("new policy shares the breaker: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	fail = false
+	if out, _, err := tool.Run(nil, ""); err != nil || out != "ok" {
+		t.Errorf("after cooldown: %q, %v", out, err)
+	}
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry holds one agent's tools and tool metadata. Tools can be added,
+// removed, enabled and disabled while the agent runs; only enabled tools are
+// offered to the model. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	order []string
+	tools map[string]*registeredTool
+	meta  map[string]interface{}
+	// version counts changes, so an agent can tell its tools changed since
+	// it last prepared its prompt.
+	version uint64
+}
+
+type registeredTool struct {
+	tool     BaseTool
+	disabled bool
+}
+
+// NewRegistry returns a registry holding tools, all enabled.
+func NewRegistry(tools ...BaseTool) *Registry {
+	r := &Registry{
+		tools: make(map[string]*registeredTool),
+		meta:  make(map[string]interface{}),
+	}
+	r.Add(tools...)
+	return r
+}
+
+// Add registers tools, enabled. A tool with the name of a registered tool
+// replaces it in place and keeps its enabled state.
+func (r *Registry) Add(tools ...BaseTool) {
+	if len(tools) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range tools {
+		if existing, ok := r.tools[t.Name]; ok {
+			existing.tool = t
+			continue
+		}
+		r.order = append(r.order, t.Name)
+		r.tools[t.Name] = &registeredTool{tool: t}
+	}
+	r.version++
+}
+
+// Remove unregisters a tool. It reports whether the tool was registered.
+func (r *Registry) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[name]; !ok {
+		return false
+	}
+	delete(r.tools, name)
+	for i, n := range r.order {
+		if n == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	r.version++
+	return true
+}
+
+// Enable offers a registered tool to the model again.
+func (r *Registry) Enable(name string) error { return r.setDisabled(name, false) }
+
+// Disable keeps a tool registered but stops offering it to the model.
+func (r *Registry) Disable(name string) error { return r.setDisabled(name, true) }
+
+func (r *Registry) setDisabled(name string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tools[name]
+	if !ok {
+		return fmt.Errorf("tools: no tool named %q is registered", name)
+	}
+	if t.disabled != disabled {
+		t.disabled = disabled
+		r.version++
+	}
+	return nil
+}
+
+// Enabled reports whether a tool is registered and enabled.
+func (r *Registry) Enabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return ok && !t.disabled
+}
+
+// Get returns a registered tool, enabled or not.
+func (r *Registry) Get(name string) (BaseTool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	if !ok {
+		return BaseTool{}, false
+	}
+	return t.tool, true
+}
+
+// List returns every registered tool, enabled or not, in registration order.
+func (r *Registry) List() []BaseTool { return r.list(false) }
+
+// Active returns the enabled tools in registration order.
+func (r *Registry) Active() []BaseTool { return r.list(true) }
+
+func (r *Registry) list(enabledOnly bool) []BaseTool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]BaseTool, 0, len(r.order))
+	for _, name := range r.order {
+		if t := r.tools[name]; !enabledOnly || !t.disabled {
+			out = append(out, t.tool)
+		}
+	}
+	return out
+}
+
+// SetMeta sets a metadata value passed to every tool's ToolFunc. A nil value
+// deletes the key.
+func (r *Registry) SetMeta(key string, value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value == nil {
+		delete(r.meta, key)
+	} else {
+		r.meta[key] = value
+	}
+	r.version++
+}
+
+// Meta returns a copy of the tool metadata.
+func (r *Registry) Meta() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]interface{}, len(r.meta))
+	for k, v := range r.meta {
+		out[k] = v
+	}
+	return out
+}
+
+// Version changes whenever tools or metadata change.
+func (r *Registry) Version() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// Clone returns an independent copy of the registry. Cloning a nil registry
+// returns an empty one.
+func (r *Registry) Clone() *Registry {
+	c := NewRegistry()
+	if r == nil {
+		return c
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, name := range r.order {
+		t := *r.tools[name]
+		c.order = append(c.order, name)
+		c.tools[name] = &t
+	}
+	for k, v := range r.meta {
+		c.meta[k] = v
+	}
+	return c
+}
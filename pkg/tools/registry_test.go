@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func names(list []BaseTool) string {
+	out := make([]string, len(list))
+	for i, t := range list {
+		out[i] = t.Name
+	}
+	return strings.Join(out, ",")
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(BaseTool{Name: "a"}, BaseTool{Name: "b"}, BaseTool{Name: "c"})
+	v := r.Version()
+
+	if err := r.Disable("b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(r.Active()); got != "a,c" {
+		t.Errorf("active = %s", got)
+	}
+	if got := names(r.List()); got != "a,b,c" {
+		t.Errorf("list = %s", got)
+	}
+	if r.Enabled("b") || !r.Enabled("a") || r.Enabled("missing") {
+		t.Error("Enabled reports the wrong state")
+	}
+	if r.Version() == v {
+		t.Error("version not bumped by Disable")
+	}
+
+	// Replacing a tool keeps its position and disabled state.
+	r.Add(BaseTool{Name: "b", Description: "new"})
+	if got, _ := r.Get("b"); got.Description != "new" || r.Enabled("b") {
+		t.Errorf("replaced tool = %+v, enabled %v", got, r.Enabled("b"))
+	}
+	if err := r.Enable("b"); err != nil || names(r.Active()) != "a,b,c" {
+		t.Errorf("after Enable: %s, %v", names(r.Active()), err)
+	}
+
+	if !r.Remove("a") || r.Remove("a") {
+		t.Error("Remove reports the wrong result")
+	}
+	if err := r.Disable("a"); err == nil {
+		t.Error("disabling an unregistered tool succeeded")
+	}
+	if got := names(r.List()); got != "b,c" {
+		t.Errorf("after Remove: %s", got)
+	}
+}
+
+func TestRegistry_CloneIsIndependent(t *testing.T) {
+	r := NewRegistry(BaseTool{Name: "a"})
+	r.SetMeta("tenant", "acme")
+	c := r.Clone()
+
+	c.Add(BaseTool{Name: "b"})
+	_ = c.Disable("a")
+	c.SetMeta("tenant", "globex")
+	c.SetMeta("region", "eu")
+
+	if names(r.Active()) != "a" || r.Meta()["tenant"] != "acme" || len(r.Meta()) != 1 {
+		t.Errorf("original changed: %s %v", names(r.Active()), r.Meta())
+	}
+	if names(c.Active()) != "b" || c.Meta()["tenant"] != "globex" {
+		t.Errorf("clone = %s %v", names(c.Active()), c.Meta())
+	}
+
+	c.SetMeta("region", nil)
+	if _, ok := c.Meta()["region"]; ok {
+		t.Error("SetMeta(nil) kept the key")
+	}
+	if (*Registry)(nil).Clone().Version() != 0 {
+		t.Error("clone of nil registry is not empty")
+	}
+}
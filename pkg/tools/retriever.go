@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// RetrieverConfig tunes a tool built by NewRetrieverTool.
+type RetrieverConfig struct {
+	// Name defaults to "knowledge_base_search".
+	Name string
+	// Description tells the model what the knowledge base contains; a
+	// generic description is used when empty.
+	Description string
+	// TopK is the number of passages returned when the model does not ask
+	// for a number. Defaults to 5.
+	TopK int
+	// MaxTopK caps the top_k the model may request. Defaults to 20.
+	MaxTopK int
+	// MinScore drops hits whose similarity is below it. Zero keeps every hit.
+	MinScore float64
+	// Filter is always applied, on top of any filters the model passes, e.g.
+	// to scope the search to one tenant.
+	Filter memory.Filter
+	// FilterFields restricts the metadata fields the model may filter on and
+	// lists them in the input schema. When empty, any field is accepted;
+	// Filter still applies either way.
+	FilterFields []string
+}
+
+// retrieverInput is the structured input of a retriever tool.
+type retrieverInput struct {
+	Query   string         `json:"query"`
+	TopK    int            `json:"top_k"`
+	Filters map[string]any `json:"filters"`
+}
+
+/*
+NewRetrieverTool turns a vector store into a tool that any agent can call to
+search it. The model passes a query, an optional top_k and optional metadata
+filters; it receives numbered passages labelled with their source, which it can
+cite as [1], [2], and so on. The raw memory.Hit values are returned as the
+tool's []interface{} result, so they reach the caller alongside the answer
+(e.g. for a UI that shows sources).
+
+In ReAct mode, where tools receive plain text, the whole input is used as the
+query unless it is a JSON object.
+
+Example:
+
+	kb := tools.NewRetrieverTool(store, embedder, tools.RetrieverConfig{
+		Description:  "Searches the product manuals and support FAQ.",
+		FilterFields: []string{"product"},
+	})
+*/
+func NewRetrieverTool(store memory.VectorStore, embedder memory.Embedder, cfg RetrieverConfig) BaseTool {
+	if cfg.Name == "" {
+		cfg.Name = "knowledge_base_search"
+	}
+	if cfg.Description == "" {
+		cfg.Description = "Searches the knowledge base for passages relevant to a query."
+	}
+	if cfg.TopK <= 0 {
+		cfg.TopK = 5
+	}
+	if cfg.MaxTopK <= 0 {
+		cfg.MaxTopK = 20
+	}
+	cfg.TopK = min(cfg.TopK, cfg.MaxTopK)
+
+	properties := map[string]any{
+		"query": map[string]any{
+			"type":        "string",
+			"description": "What to search for, phrased as a question or keywords.",
+		},
+		"top_k": map[string]any{
+			"type":        "integer",
+			"description": fmt.Sprintf("Number of passages to return (default %d, at most %d).", cfg.TopK, cfg.MaxTopK),
+		},
+	}
+	filters := map[string]any{
+		"type":        "object",
+		"description": "Optional metadata filters: only passages whose field equals the value (or any value of a list) are returned.",
+	}
+	if len(cfg.FilterFields) > 0 {
+		fields := make(map[string]any, len(cfg.FilterFields))
+		for _, f := range cfg.FilterFields {
+			fields[f] = map[string]any{"description": "Filter on " + f + "."}
+		}
+		filters["properties"] = fields
+		filters["additionalProperties"] = false
+	}
+	properties["filters"] = filters
+
+	search := func(tc *ToolContext, input string) (string, []interface{}, error) {
+		in, err := parseRetrieverInput(input)
+		if err != nil {
+			return "", nil, err
+		}
+		k := in.TopK
+		if k <= 0 {
+			k = cfg.TopK
+		}
+		k = min(k, cfg.MaxTopK)
+		filter, err := retrieverFilter(cfg, in.Filters)
+		if err != nil {
+			return "", nil, err
+		}
+
+		vector, err := embedder.Embed(tc, in.Query)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: embedding query: %w", cfg.Name, err)
+		}
+		hits, err := store.SearchFiltered(tc, vector, k, filter)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", cfg.Name, err)
+		}
+		var raw []interface{}
+		kept := hits[:0]
+		for _, h := range hits {
+			if cfg.MinScore > 0 && h.Score < cfg.MinScore {
+				continue
+			}
+			kept = append(kept, h)
+			raw = append(raw, h)
+		}
+		return FormatPassages(kept), raw, nil
+	}
+
+	tool := NewContextTool(cfg.Name, cfg.Description, search)
+	tool.InputSchema = properties
+	tool.Required = []string{"query"}
+	return tool
+}
+
+func parseRetrieverInput(input string) (retrieverInput, error) {
+	var in retrieverInput
+	trimmed := strings.TrimSpace(input)
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &in); err != nil {
+			return in, fmt.Errorf("invalid search input: %w", err)
+		}
+	} else {
+		in.Query = trimmed
+	}
+	in.Query = strings.TrimSpace(in.Query)
+	if in.Query == "" {
+		return in, fmt.Errorf("a search query is required")
+	}
+	return in, nil
+}
+
+// retrieverFilter combines the configured filter with the model's equality
+// filters, rejecting fields the tool does not expose.
+func retrieverFilter(cfg RetrieverConfig, filters map[string]any) (memory.Filter, error) {
+	filter := append(memory.Filter(nil), cfg.Filter...)
+	fields := make([]string, 0, len(filters))
+	for f := range filters {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		allowed := len(cfg.FilterFields) == 0
+		for _, a := range cfg.FilterFields {
+			allowed = allowed || a == f
+		}
+		if !allowed {
+			return nil, fmt.Errorf("cannot filter on %q; filterable fields: [%s]", f, strings.Join(cfg.FilterFields, ", "))
+		}
+		if values, ok := filters[f].([]any); ok {
+			filter = append(filter, memory.In(f, values...))
+		} else {
+			filter = append(filter, memory.Eq(f, filters[f]))
+		}
+	}
+	return filter, nil
+}
+
+// FormatPassages renders hits as numbered passages for a model to read and
+// cite. Each passage is labelled with its source: the "source" metadata (plus
+// "heading", as set by document ingestion), else "title", else the hit ID.
+func FormatPassages(hits []memory.Hit) string {
+	if len(hits) == 0 {
+		return "No relevant passages found."
+	}
+	var b strings.Builder
+	for i, h := range hits {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%d] (source: %s, relevance %.2f)\n%s", i+1, passageSource(h), h.Score, strings.TrimSpace(h.Text))
+	}
+	b.WriteString("\n\nCite the passages you use by their [number].")
+	return b.String()
+}
+
+func passageSource(h memory.Hit) string {
+	source, _ := h.Meta["source"].(string)
+	if source == "" {
+		source, _ = h.Meta["title"].(string)
+	}
+	if source == "" {
+		source = h.ID
+	}
+	if heading, _ := h.Meta["heading"].(string); heading != "" {
+		source += " > " + heading
+	}
+	return source
+}
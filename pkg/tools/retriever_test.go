@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/darksuit-ai/darksuitai/internal/memory"
+)
+
+// wordEmbedder embeds text as counts of a fixed vocabulary.
+type wordEmbedder []string
+
+func (e wordEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	v := make([]float32, len(e)+1)
+	for i, w := range e {
+		v[i] = float32(strings.Count(strings.ToLower(text), w))
+	}
+	v[len(e)] = 0.1
+	return v, nil
+}
+
+func newTestRetriever(t *testing.T, cfg RetrieverConfig) BaseTool {
+	t.Helper()
+	ctx := context.Background()
+	emb := wordEmbedder{"refund", "shipping", "warranty"}
+	store := memory.NewInMemoryVectorStore()
+	docs := []struct {
+		id, text string
+		meta     map[string]any
+	}{
+		{"a", "Refunds are issued within 14 days.", map[string]any{"source": "kb/faq.md", "heading": "Refunds", "tenant": "acme", "product": "drill"}},
+		{"b", "Refund requests need an order number.", map[string]any{"title": "Refund policy", "tenant": "acme", "product": "saw"}},
+		{"c", "Shipping is free over 50 euros.", map[string]any{"tenant": "acme", "product": "drill"}},
+		{"d", "Refund rules for another customer.", map[string]any{"tenant": "globex", "product": "drill"}},
+	}
+	for _, d := range docs {
+		v, _ := emb.Embed(ctx, d.text)
+		_ = store.Add(ctx, d.id, d.text, v, d.meta)
+	}
+	return NewRetrieverTool(store, emb, cfg)
+}
+
+func TestRetrieverTool_PassagesAndRawHits(t *testing.T) {
+	tool := newTestRetriever(t, RetrieverConfig{TopK: 2, Filter: memory.Filter{memory.Eq("tenant", "acme")}})
+	if tool.Name != "knowledge_base_search" || tool.Required[0] != "query" {
+		t.Fatalf("unexpected tool: %+v", tool)
+	}
+	for _, p := range []string{"query", "top_k", "filters"} {
+		if _, ok := tool.InputSchema[p]; !ok {
+			t.Errorf("schema missing %q", p)
+		}
+	}
+
+	text, raw, err := tool.ToolFunc(`{"query": "refund"}`, tool.Name, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(raw) != 2 {
+		t.Fatalf("want 2 raw hits, got %d", len(raw))
+	}
+	for _, r := range raw {
+		h, ok := r.(memory.Hit)
+		if !ok || h.Meta["tenant"] != "acme" {
+			t.Errorf("raw result is not an acme memory.Hit: %#v", r)
+		}
+	}
+	for _, want := range []string{"[1] (source: kb/faq.md > Refunds", "[2] (source: Refund policy", "Cite the passages"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("passages missing %q:\n%s", want, text)
+		}
+	}
+
+	// Plain-text input (ReAct mode) is the query.
+	if _, raw, _ := tool.ToolFunc("shipping", tool.Name, nil); len(raw) == 0 || raw[0].(memory.Hit).ID != "c" {
+		t.Errorf("plain-text query returned %+v", raw)
+	}
+}
+
+func TestRetrieverTool_FiltersAndLimits(t *testing.T) {
+	tool := newTestRetriever(t, RetrieverConfig{
+		MaxTopK:      3,
+		Filter:       memory.Filter{memory.Eq("tenant", "acme")},
+		FilterFields: []string{"product"},
+	})
+
+	_, raw, err := tool.ToolFunc(`{"query": "refund", "top_k": 10, "filters": {"product": ["drill"]}}`, tool.Name, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(raw) != 2 {
+		t.Fatalf("want the 2 acme drill passages, got %+v", raw)
+	}
+	for _, r := range raw {
+		if h := r.(memory.Hit); h.Meta["product"] != "drill" || h.Meta["tenant"] != "acme" {
+			t.Errorf("filter not applied: %+v", h)
+		}
+	}
+
+	if _, _, err := tool.ToolFunc(`{"query": "refund", "filters": {"tenant": "globex"}}`, tool.Name, nil); err == nil {
+		t.Error("filtering on a field outside FilterFields should fail")
+	}
+	if _, _, err := tool.ToolFunc(`{"top_k": 2}`, tool.Name, nil); err == nil {
+		t.Error("a missing query should fail")
+	}
+	if text, raw, _ := newTestRetriever(t, RetrieverConfig{MinScore: 0.99}).ToolFunc("warranty", "", nil); raw != nil || text != "No relevant passages found." {
+		t.Errorf("MinScore not applied: %q %v", text, raw)
+	}
+}
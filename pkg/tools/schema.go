@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+SchemaFor reflects the struct type T into a JSON-schema "properties" object and
+its required property names, ready for BaseTool.InputSchema and
+BaseTool.Required.
+
+Property names follow the json tags (fields tagged "-" and unexported fields
+are skipped; embedded structs are flattened). A jsonschema tag adds
+comma-separated constraints; a literal comma inside a value is escaped with a
+backslash (written \\, inside the tag's quotes):
+
+	required            the property must be present
+	description=...     shown to the model
+	enum=a|b|c          allowed values, converted to the field's type
+	minimum=, maximum=  numeric bounds
+	minLength=, maxLength=, pattern=, format=   string constraints
+	minItems=, maxItems=                        array length
+	default=...         documented default
+
+Example:
+
+	type WeatherInput struct {
+		City  string `json:"city" jsonschema:"required,description=City name\\, e.g. Paris"`
+		Units string `json:"units,omitempty" jsonschema:"enum=celsius|fahrenheit,default=celsius"`
+		Days  int    `json:"days,omitempty" jsonschema:"minimum=1,maximum=14"`
+	}
+*/
+func SchemaFor[T any]() (properties map[string]any, required []string, err error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("tools: schema input must be a struct, got %s", t)
+	}
+	obj, err := schemaOf(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, nil, err
+	}
+	properties, _ = obj["properties"].(map[string]any)
+	required, _ = obj["required"].([]string)
+	return properties, required, nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf returns the JSON schema of t. visiting guards against recursive
+// struct types, which have no finite inline schema.
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("tools: map keys must be strings, got %s", t)
+		}
+		values, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, fmt.Errorf("tools: recursive type %s has no inline schema", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := map[string]any{}
+		var required []string
+		if err := addFields(t, properties, &required, visiting); err != nil {
+			return nil, err
+		}
+		obj := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+		if len(required) > 0 {
+			obj["required"] = required
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("tools: unsupported type %s", t)
+}
+
+func addFields(t reflect.Type, properties map[string]any, required *[]string, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := addFields(ft, properties, required, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema, err := schemaOf(f.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		isRequired, err := applyTag(schema, f.Tag.Get("jsonschema"), f.Type)
+		if err != nil {
+			return fmt.Errorf("tools: field %s: %w", f.Name, err)
+		}
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// applyTag adds the constraints of a jsonschema tag to schema and reports
+// whether the property is required.
+func applyTag(schema map[string]any, tag string, t reflect.Type) (required bool, err error) {
+	for _, part := range splitTag(tag) {
+		key, value, _ := strings.Cut(part, "=")
+		switch key = strings.TrimSpace(key); key {
+		case "":
+		case "required":
+			required = true
+		case "description", "pattern", "format":
+			schema[key] = value
+		case "enum":
+			var values []any
+			for _, v := range strings.Split(value, "|") {
+				typed, err := tagValue(v, t)
+				if err != nil {
+					return false, fmt.Errorf("enum: %w", err)
+				}
+				values = append(values, typed)
+			}
+			schema["enum"] = values
+		case "default":
+			typed, err := tagValue(value, t)
+			if err != nil {
+				return false, fmt.Errorf("default: %w", err)
+			}
+			schema["default"] = typed
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", key, err)
+			}
+			schema[key] = n
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", key, err)
+			}
+			schema[key] = n
+		default:
+			return false, fmt.Errorf("unknown jsonschema key %q", key)
+		}
+	}
+	return required, nil
+}
+
+// splitTag splits a jsonschema tag on commas, honouring \, escapes.
+func splitTag(tag string) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	return append(parts, b.String())
+}
+
+// tagValue converts a tag value to the JSON type of t.
+func tagValue(v string, t reflect.Type) (any, error) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(v, 64)
+	}
+	return v, nil
+}
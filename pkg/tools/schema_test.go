@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type weatherInput struct {
+	City   string   `json:"city" jsonschema:"required,description=City name\\, e.g. Paris"`
+	Units  string   `json:"units,omitempty" jsonschema:"enum=celsius|fahrenheit,default=celsius"`
+	Days   int      `json:"days,omitempty" jsonschema:"minimum=1,maximum=14"`
+	Tags   []string `json:"tags,omitempty" jsonschema:"maxItems=2"`
+	Since  *time.Time
+	Hidden string `json:"-"`
+	secret string
+	paging
+}
+
+type paging struct {
+	Page int `json:"page,omitempty" jsonschema:"enum=1|2|3"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	props, required, err := SchemaFor[weatherInput]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(required, []string{"city"}) {
+		t.Errorf("required = %v", required)
+	}
+	want := map[string]any{
+		"city":  map[string]any{"type": "string", "description": "City name, e.g. Paris"},
+		"units": map[string]any{"type": "string", "enum": []any{"celsius", "fahrenheit"}, "default": "celsius"},
+		"days":  map[string]any{"type": "integer", "minimum": 1.0, "maximum": 14.0},
+		"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "maxItems": 2},
+		"Since": map[string]any{"type": "string", "format": "date-time"},
+		"page":  map[string]any{"type": "integer", "enum": []any{1.0, 2.0, 3.0}},
+	}
+	if !reflect.DeepEqual(props, want) {
+		got, _ := json.MarshalIndent(props, "", "  ")
+		t.Errorf("properties =\n%s", got)
+	}
+}
+
+func TestSchemaFor_Errors(t *testing.T) {
+	type recursive struct {
+		Next *recursive `json:"next"`
+	}
+	type badTag struct {
+		N int `json:"n" jsonschema:"minimum=low"`
+	}
+	if _, _, err := SchemaFor[string](); err == nil {
+		t.Error("non-struct input accepted")
+	}
+	if _, _, err := SchemaFor[recursive](); err == nil {
+		t.Error("recursive type accepted")
+	}
+	if _, _, err := SchemaFor[badTag](); err == nil {
+		t.Error("malformed tag accepted")
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	props, required, _ := SchemaFor[weatherInput]()
+	schema := objectSchema(props, required)
+	schema["additionalProperties"] = false
+	cases := []struct {
+		args    string
+		wantErr string
+	}{
+		{`{"city":"Paris","units":"celsius","days":3,"tags":["a"]}`, ""},
+		{`{"units":"celsius"}`, `missing required field "city"`},
+		{`{"city":42}`, "input.city: must be string, got number"},
+		{`{"city":"Paris","units":"kelvin"}`, `input.units: must be one of ["celsius", "fahrenheit"], got "kelvin"`},
+		{`{"city":"Paris","days":30}`, "input.days: must be <= 14, got 30"},
+		{`{"city":"Paris","days":1.5}`, "input.days: must be integer, got number"},
+		{`{"city":"Paris","page":4}`, "input.page: must be one of [1, 2, 3], got 4"},
+		{`{"city":"Paris","tags":["a","b","c"]}`, "input.tags: must have at most 2 items"},
+		{`{"city":"Paris","tags":[1]}`, "input.tags[0]: must be string"},
+		{`{"city":"Paris","country":"FR"}`, `unknown field "country"`},
+	}
+	for _, c := range cases {
+		args, err := decodeArgs(c.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = validateValue(schema, args, "input")
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", c.args, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Errorf("%s: error = %v, want %q", c.args, err, c.wantErr)
+		}
+	}
+}
+
+func TestNewTypedTool(t *testing.T) {
+	type forecast struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	tool := NewTypedTool("weather", "Weather forecast.", func(_ context.Context, in weatherInput) (forecast, error) {
+		if in.City == "Atlantis" {
+			return forecast{}, errors.New("unknown city")
+		}
+		return forecast{City: in.City, Days: in.Days}, nil
+	})
+	if tool.InputSchema["city"] == nil || !reflect.DeepEqual(tool.Required, []string{"city"}) {
+		t.Fatalf("schema not reflected: %v %v", tool.InputSchema, tool.Required)
+	}
+
+	out, raw, err := tool.ToolFunc(`{"city":"Paris","days":2}`, tool.Name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `{"city":"Paris","days":2}` {
+		t.Errorf("output = %s", out)
+	}
+	if len(raw) != 1 || raw[0] != (forecast{City: "Paris", Days: 2}) {
+		t.Errorf("raw = %v", raw)
+	}
+
+	if _, _, err := tool.ToolFunc(`{"city":"Paris","days":99}`, tool.Name, nil); err == nil || !strings.Contains(err.Error(), "must be <= 14") {
+		t.Errorf("range error = %v", err)
+	}
+	if _, _, err := tool.ToolFunc(`{"city":"Atlantis"}`, tool.Name, nil); err == nil || err.Error() != "unknown city" {
+		t.Errorf("handler error = %v", err)
+	}
+	// Plain text fills the only required string field, as ValidateInput
+	// assumes.
+	if err := ValidateInput(tool, "Paris"); err != nil {
+		t.Errorf("ValidateInput rejected plain text: %v", err)
+	}
+	out, _, err = tool.ToolFunc("Paris", tool.Name, nil)
+	if err != nil || out != `{"city":"Paris","days":0}` {
+		t.Errorf("plain text for the required field: %q, %v", out, err)
+	}
+}
+
+func TestNewTypedTool_PlainTextInput(t *testing.T) {
+	type query struct {
+		Q string `json:"q" jsonschema:"required"`
+	}
+	tool := NewTypedTool("echo", "Echoes the query.", func(_ context.Context, in query) (string, error) {
+		return "you said " + in.Q, nil
+	})
+	out, _, err := tool.ToolFunc("  hello there ", tool.Name, nil)
+	if err != nil || out != "you said hello there" {
+		t.Errorf("got %q, %v", out, err)
+	}
+	if _, _, err := tool.ToolFunc("", tool.Name, nil); err == nil || !strings.Contains(err.Error(), `missing required field "q"`) {
+		t.Errorf("empty input error = %v", err)
+	}
+
+	type pair struct {
+		A string `json:"a" jsonschema:"required"`
+		B string `json:"b" jsonschema:"required"`
+	}
+	two := NewTypedTool("pair", "Two required fields.", func(_ context.Context, in pair) (string, error) {
+		return in.A + in.B, nil
+	})
+	if _, _, err := two.ToolFunc("hello", two.Name, nil); err == nil || !strings.Contains(err.Error(), "expected a JSON object") {
+		t.Errorf("plain text accepted with two required fields: %v", err)
+	}
+}
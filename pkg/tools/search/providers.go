@@ -0,0 +1,257 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Serper searches Google through serper.dev.
+type Serper struct {
+	// APIKey defaults to the SERPER_API_KEY environment variable.
+	APIKey string
+	// BaseURL defaults to https://google.serper.dev.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewSerper returns a Serper provider; an empty apiKey reads SERPER_API_KEY.
+func NewSerper(apiKey string) *Serper { return &Serper{APIKey: apiKey} }
+
+func (s *Serper) Name() string { return "serper" }
+
+var serperTimeRanges = map[TimeRange]string{PastDay: "qdr:d", PastWeek: "qdr:w", PastMonth: "qdr:m", PastYear: "qdr:y"}
+
+// Search runs a Google web search. An answer box, when Google shows one, is
+// the first result.
+func (s *Serper) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key, err := apiKey(s.Name(), s.APIKey, "SERPER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]any{"q": query, "num": opts.count()}
+	if opts.Region != "" {
+		payload["gl"] = strings.ToLower(opts.Region)
+	}
+	if opts.Language != "" {
+		payload["hl"] = opts.Language
+	}
+	if tbs, ok := serperTimeRanges[opts.TimeRange]; ok {
+		payload["tbs"] = tbs
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL(s.BaseURL, "https://google.serper.dev")+"/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("search: serper: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", key)
+
+	var resp struct {
+		AnswerBox *struct {
+			Title   string `json:"title"`
+			Answer  string `json:"answer"`
+			Snippet string `json:"snippet"`
+			Link    string `json:"link"`
+		} `json:"answerBox"`
+		Organic []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+			Date    string `json:"date"`
+		} `json:"organic"`
+	}
+	if err := do(s.Name(), s.Client, req, &resp); err != nil {
+		return nil, err
+	}
+	var results []Result
+	if box := resp.AnswerBox; box != nil && (box.Answer != "" || box.Snippet != "") {
+		snippet := box.Answer
+		if snippet == "" {
+			snippet = box.Snippet
+		}
+		results = append(results, Result{Title: box.Title, URL: box.Link, Snippet: snippet})
+	}
+	for _, r := range resp.Organic {
+		results = append(results, Result{Title: r.Title, URL: r.Link, Snippet: r.Snippet, Published: r.Date})
+	}
+	return truncate(results, opts.count()), nil
+}
+
+// Brave searches with the Brave Search API.
+type Brave struct {
+	// APIKey defaults to the BRAVE_SEARCH_API_KEY environment variable.
+	APIKey string
+	// BaseURL defaults to https://api.search.brave.com.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewBrave returns a Brave Search provider; an empty apiKey reads
+// BRAVE_SEARCH_API_KEY.
+func NewBrave(apiKey string) *Brave { return &Brave{APIKey: apiKey} }
+
+func (b *Brave) Name() string { return "brave" }
+
+var braveTimeRanges = map[TimeRange]string{PastDay: "pd", PastWeek: "pw", PastMonth: "pm", PastYear: "py"}
+
+// Search runs a Brave web search. Brave returns at most 20 results.
+func (b *Brave) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key, err := apiKey(b.Name(), b.APIKey, "BRAVE_SEARCH_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{"q": {query}, "count": {strconv.Itoa(min(opts.count(), 20))}}
+	if opts.Region != "" {
+		params.Set("country", strings.ToUpper(opts.Region))
+	}
+	if opts.Language != "" {
+		params.Set("search_lang", opts.Language)
+	}
+	if freshness, ok := braveTimeRanges[opts.TimeRange]; ok {
+		params.Set("freshness", freshness)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL(b.BaseURL, "https://api.search.brave.com")+"/res/v1/web/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("search: brave: %w", err)
+	}
+	req.Header.Set("X-Subscription-Token", key)
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+				Age         string `json:"age"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := do(b.Name(), b.Client, req, &resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Web.Results))
+	for _, r := range resp.Web.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Description, Published: r.Age})
+	}
+	return truncate(results, opts.count()), nil
+}
+
+// Tavily searches with the Tavily API. It ignores Options.Region and
+// Options.Language.
+type Tavily struct {
+	// APIKey defaults to the TAVILY_API_KEY environment variable.
+	APIKey string
+	// BaseURL defaults to https://api.tavily.com.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewTavily returns a Tavily provider; an empty apiKey reads TAVILY_API_KEY.
+func NewTavily(apiKey string) *Tavily { return &Tavily{APIKey: apiKey} }
+
+func (t *Tavily) Name() string { return "tavily" }
+
+// Search runs a Tavily search.
+func (t *Tavily) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	key, err := apiKey(t.Name(), t.APIKey, "TAVILY_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]any{"query": query, "max_results": opts.count()}
+	if opts.TimeRange != AnyTime {
+		payload["time_range"] = string(opts.TimeRange)
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL(t.BaseURL, "https://api.tavily.com")+"/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("search: tavily: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	var resp struct {
+		Results []struct {
+			Title         string `json:"title"`
+			URL           string `json:"url"`
+			Content       string `json:"content"`
+			PublishedDate string `json:"published_date"`
+		} `json:"results"`
+	}
+	if err := do(t.Name(), t.Client, req, &resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content, Published: r.PublishedDate})
+	}
+	return truncate(results, opts.count()), nil
+}
+
+// SearXNG searches a self-hosted SearXNG instance. The instance must have the
+// JSON output format enabled (search.formats in its settings.yml).
+type SearXNG struct {
+	// BaseURL is the instance's URL, e.g. http://localhost:8080.
+	BaseURL string
+	// Header is sent with every request, e.g. for an authenticating proxy.
+	Header http.Header
+	Client *http.Client
+}
+
+// NewSearXNG returns a provider for the SearXNG instance at baseURL.
+func NewSearXNG(baseURL string) *SearXNG { return &SearXNG{BaseURL: baseURL} }
+
+func (s *SearXNG) Name() string { return "searxng" }
+
+// Search runs a SearXNG search, reading its first page of results.
+func (s *SearXNG) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
+	if s.BaseURL == "" {
+		return nil, fmt.Errorf("search: searxng: no BaseURL")
+	}
+	params := url.Values{"q": {query}, "format": {"json"}}
+	if lang := opts.Language; lang != "" {
+		if opts.Region != "" {
+			lang += "-" + strings.ToUpper(opts.Region)
+		}
+		params.Set("language", lang)
+	}
+	if opts.TimeRange != AnyTime {
+		params.Set("time_range", string(opts.TimeRange))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(s.BaseURL, "/")+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("search: searxng: %w", err)
+	}
+	for name, values := range s.Header {
+		req.Header[name] = values
+	}
+
+	var resp struct {
+		Results []struct {
+			Title         string `json:"title"`
+			URL           string `json:"url"`
+			Content       string `json:"content"`
+			PublishedDate string `json:"publishedDate"`
+		} `json:"results"`
+	}
+	if err := do(s.Name(), s.Client, req, &resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content, Published: r.PublishedDate})
+	}
+	return truncate(results, opts.count()), nil
+}
+
+func baseURL(configured, fallback string) string {
+	if configured == "" {
+		return fallback
+	}
+	return strings.TrimRight(configured, "/")
+}
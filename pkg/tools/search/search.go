@@ -0,0 +1,126 @@
+// Package search runs web searches through interchangeable providers (Serper,
+// Brave Search, Tavily and self-hosted SearXNG) and exposes them to agents as
+// a tool (see NewTool).
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// Provider runs web searches.
+type Provider interface {
+	// Name identifies the provider in errors, e.g. "brave".
+	Name() string
+	// Search returns up to opts.Count results for query.
+	Search(ctx context.Context, query string, opts Options) ([]Result, error)
+}
+
+// TimeRange limits results to pages published recently.
+type TimeRange string
+
+const (
+	AnyTime   TimeRange = ""
+	PastDay   TimeRange = "day"
+	PastWeek  TimeRange = "week"
+	PastMonth TimeRange = "month"
+	PastYear  TimeRange = "year"
+)
+
+// DefaultCount is the number of results returned when Options.Count is zero.
+const DefaultCount = 5
+
+// Options tune a search. Providers ignore options they do not support.
+type Options struct {
+	// Count is the number of results wanted; DefaultCount when zero.
+	Count int
+	// Region is a two-letter country code, e.g. "us" or "gb".
+	Region string
+	// Language is a two-letter language code, e.g. "en".
+	Language string
+	// TimeRange limits results by age.
+	TimeRange TimeRange
+}
+
+// count returns the wanted number of results.
+func (o Options) count() int {
+	if o.Count <= 0 {
+		return DefaultCount
+	}
+	return o.Count
+}
+
+// Result is one search hit.
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+	// Published is the page's date as the provider reports it, if it does.
+	Published string `json:"published,omitempty"`
+}
+
+var defaultClient = &http.Client{Timeout: 15 * time.Second}
+
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return defaultClient
+}
+
+// apiKey returns key, or the environment variable env when key is empty.
+func apiKey(provider, key, env string) (string, error) {
+	if key == "" {
+		key = os.Getenv(env)
+	}
+	if key == "" {
+		return "", fmt.Errorf("search: %s: no API key (set %s)", provider, env)
+	}
+	return key, nil
+}
+
+// do sends req and decodes its JSON response into out. Rate limits and
+// server errors are marked retryable (see tools.Retryable).
+func do(provider string, client *http.Client, req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient(client).Do(req)
+	if err != nil {
+		return fmt.Errorf("search: %s: %w", provider, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("search: %s: reading response: %w", provider, err)
+	}
+	if resp.StatusCode >= 400 {
+		text := strings.TrimSpace(string(body))
+		if len(text) > 500 {
+			text = text[:500] + "…"
+		}
+		err := fmt.Errorf("search: %s: HTTP %d %s: %s", provider, resp.StatusCode, http.StatusText(resp.StatusCode), text)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			err = tools.Retryable(err)
+		}
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("search: %s: decoding response: %w", provider, err)
+	}
+	return nil
+}
+
+// truncate keeps the first n results.
+func truncate(results []Result, n int) []Result {
+	if len(results) > n {
+		return results[:n]
+	}
+	return results
+}
@@ -0,0 +1,200 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// stub serves body as JSON and records the last request and its body.
+func stub(t *testing.T, status int, body string) (*httptest.Server, *http.Request, *string) {
+	t.Helper()
+	var (
+		last     http.Request
+		lastBody string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r.Clone(context.Background())
+		b, _ := io.ReadAll(r.Body)
+		lastBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last, &lastBody
+}
+
+func TestSerper(t *testing.T) {
+	srv, req, body := stub(t, 200, `{
+		"answerBox": {"title": "Go", "answer": "A programming language", "link": "https://go.dev"},
+		"organic": [
+			{"title": "The Go Programming Language", "link": "https://go.dev", "snippet": "Build simple, secure software.", "date": "Jan 1, 2026"},
+			{"title": "Go on Wikipedia", "link": "https://en.wikipedia.org/wiki/Go", "snippet": "Go is..."}
+		]}`)
+	s := &Serper{APIKey: "k", BaseURL: srv.URL}
+	results, err := s.Search(context.Background(), "golang", Options{Count: 2, Region: "GB", TimeRange: PastWeek})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Snippet != "A programming language" || results[1].Published != "Jan 1, 2026" {
+		t.Errorf("results = %+v", results)
+	}
+	if req.URL.Path != "/search" || req.Header.Get("X-API-KEY") != "k" {
+		t.Errorf("request = %s %v", req.URL.Path, req.Header)
+	}
+	var payload map[string]any
+	json.Unmarshal([]byte(*body), &payload)
+	if payload["q"] != "golang" || payload["num"] != float64(2) || payload["gl"] != "gb" || payload["tbs"] != "qdr:w" {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestBrave(t *testing.T) {
+	srv, req, _ := stub(t, 200, `{"web": {"results": [{"title": "T", "url": "https://a.example", "description": "D", "age": "2 days ago"}]}}`)
+	b := &Brave{APIKey: "k", BaseURL: srv.URL}
+	results, err := b.Search(context.Background(), "news", Options{Count: 50, Region: "us", Language: "en", TimeRange: PastDay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0] != (Result{Title: "T", URL: "https://a.example", Snippet: "D", Published: "2 days ago"}) {
+		t.Errorf("results = %+v", results)
+	}
+	q := req.URL.Query()
+	if req.URL.Path != "/res/v1/web/search" || q.Get("q") != "news" || q.Get("count") != "20" ||
+		q.Get("country") != "US" || q.Get("search_lang") != "en" || q.Get("freshness") != "pd" {
+		t.Errorf("request = %s", req.URL)
+	}
+	if req.Header.Get("X-Subscription-Token") != "k" {
+		t.Errorf("headers = %v", req.Header)
+	}
+}
+
+func TestTavily(t *testing.T) {
+	srv, req, body := stub(t, 200, `{"results": [{"title": "T", "url": "https://a.example", "content": "C"}, {"title": "U", "url": "https://b.example", "content": "E"}]}`)
+	tv := &Tavily{APIKey: "k", BaseURL: srv.URL}
+	results, err := tv.Search(context.Background(), "q", Options{Count: 1, TimeRange: PastMonth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Snippet != "C" {
+		t.Errorf("results = %+v", results)
+	}
+	if req.Header.Get("Authorization") != "Bearer k" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	var payload map[string]any
+	json.Unmarshal([]byte(*body), &payload)
+	if payload["query"] != "q" || payload["max_results"] != float64(1) || payload["time_range"] != "month" {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestSearXNG(t *testing.T) {
+	srv, req, _ := stub(t, 200, `{"results": [{"title": "T", "url": "https://a.example", "content": "C", "publishedDate": "2026-01-02"}]}`)
+	s := &SearXNG{BaseURL: srv.URL + "/", Header: http.Header{"X-Token": {"t"}}}
+	results, err := s.Search(context.Background(), "q", Options{Language: "en", Region: "gb", TimeRange: PastYear})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Published != "2026-01-02" {
+		t.Errorf("results = %+v", results)
+	}
+	q := req.URL.Query()
+	if req.URL.Path != "/search" || q.Get("format") != "json" || q.Get("language") != "en-GB" || q.Get("time_range") != "year" {
+		t.Errorf("request = %s", req.URL)
+	}
+	if req.Header.Get("X-Token") != "t" {
+		t.Errorf("headers = %v", req.Header)
+	}
+}
+
+func TestAPIKeyFromEnv(t *testing.T) {
+	srv, req, _ := stub(t, 200, `{"results": []}`)
+	t.Setenv("TAVILY_API_KEY", "from-env")
+	if _, err := (&Tavily{BaseURL: srv.URL}).Search(context.Background(), "q", Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("Authorization") != "Bearer from-env" {
+		t.Errorf("headers = %v", req.Header)
+	}
+
+	t.Setenv("BRAVE_SEARCH_API_KEY", "")
+	_, err := NewBrave("").Search(context.Background(), "q", Options{})
+	if err == nil || !strings.Contains(err.Error(), "BRAVE_SEARCH_API_KEY") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	for status, retryable := range map[int]bool{429: true, 503: true, 401: false} {
+		srv, _, _ := stub(t, status, `{"message": "nope"}`)
+		_, err := (&Serper{APIKey: "k", BaseURL: srv.URL}).Search(context.Background(), "q", Options{})
+		if err == nil || !strings.Contains(err.Error(), "nope") {
+			t.Errorf("%d: error = %v", status, err)
+		}
+		if tools.IsRetryable(err) != retryable {
+			t.Errorf("%d: retryable = %v", status, !retryable)
+		}
+	}
+}
+
+type fakeProvider struct {
+	query string
+	opts  Options
+	err   error
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) Search(_ context.Context, query string, opts Options) ([]Result, error) {
+	f.query, f.opts = query, opts
+	return []Result{{Title: "Go", URL: "https://go.dev", Snippet: "The Go site."}}, f.err
+}
+
+func TestTool(t *testing.T) {
+	p := &fakeProvider{}
+	tool := NewTool(p, Options{Count: 3, Region: "us", Language: "en"})
+	if tool.Name != ToolName || tool.InputSchema["query"] == nil || len(tool.Required) != 1 {
+		t.Fatalf("tool = %+v", tool)
+	}
+
+	out, raw, err := tool.Run(nil, "golang release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.query != "golang release" || p.opts.Count != 3 || p.opts.Region != "us" {
+		t.Errorf("text input: %q %+v", p.query, p.opts)
+	}
+	if out != "1. Go\n   https://go.dev\n   The Go site." || len(raw) != 1 {
+		t.Errorf("out = %q, raw = %v", out, raw)
+	}
+
+	if _, _, err := tool.Run(nil, `{"query": "go", "count": 7, "region": "de", "time_range": "week"}`); err != nil {
+		t.Fatal(err)
+	}
+	if p.opts != (Options{Count: 7, Region: "de", Language: "en", TimeRange: PastWeek}) {
+		t.Errorf("JSON input: %+v", p.opts)
+	}
+
+	if _, _, err := tool.Run(nil, `{"query": "go", "time_range": "decade"}`); err == nil || !strings.Contains(err.Error(), "time_range") {
+		t.Errorf("bad time range: %v", err)
+	}
+	p.err = errors.New("down")
+	if _, _, err := tool.Run(nil, "go"); err == nil {
+		t.Error("provider error not returned")
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	if got := Format("zzz", nil); got != `No results found for "zzz".` {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/darksuit-ai/darksuitai/pkg/tools"
+)
+
+// ToolName is the name of the tool built by NewTool.
+const ToolName = "web_search"
+
+type toolInput struct {
+	Query     string `json:"query" jsonschema:"required,description=The search query"`
+	Count     int    `json:"count,omitempty" jsonschema:"minimum=1,maximum=20,description=Number of results"`
+	Region    string `json:"region,omitempty" jsonschema:"minLength=2,maxLength=2,description=Two-letter country code to localize results\\, e.g. us"`
+	TimeRange string `json:"time_range,omitempty" jsonschema:"enum=day|week|month|year,description=Only return pages published in the past day\\, week\\, month or year"`
+}
+
+/*
+NewTool returns a web search tool backed by provider. The model passes a
+query and may override the result count, region and time range; the other
+options come from defaults. In ReAct mode the plain-text input is the query.
+
+Results are shown to the model as a numbered list of titles, URLs and
+snippets, and returned as the tool's single raw result ([]Result).
+
+Example:
+
+	search := search.NewTool(search.NewBrave(""), search.Options{Count: 8, Region: "gb"})
+*/
+func NewTool(provider Provider, defaults Options) tools.BaseTool {
+	properties, required, err := tools.SchemaFor[toolInput]()
+	if err != nil {
+		panic(fmt.Sprintf("search: %v", err))
+	}
+	description := "Searches the web and returns result titles, URLs and snippets. Use it for recent events or facts you are unsure of. Input: the search query."
+
+	schema := tools.BaseTool{InputSchema: properties, Required: required}
+
+	tool := tools.NewContextTool(ToolName, description, func(tc *tools.ToolContext, input string) (string, []interface{}, error) {
+		if err := tools.ValidateInput(schema, input); err != nil {
+			return "", nil, fmt.Errorf("invalid input: %w", err)
+		}
+		args, err := tools.InputArguments(schema, input)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid input: %w", err)
+		}
+		var in toolInput
+		if err := json.Unmarshal(args, &in); err != nil {
+			return "", nil, fmt.Errorf("invalid input: %w", err)
+		}
+		query := strings.TrimSpace(in.Query)
+		if query == "" {
+			return "", nil, fmt.Errorf("invalid input: the query is empty")
+		}
+
+		opts := defaults
+		if in.Count > 0 {
+			opts.Count = in.Count
+		}
+		if in.Region != "" {
+			opts.Region = in.Region
+		}
+		if in.TimeRange != "" {
+			opts.TimeRange = TimeRange(in.TimeRange)
+		}
+		results, err := provider.Search(tc, query, opts)
+		if err != nil {
+			return "", nil, err
+		}
+		return Format(query, results), []interface{}{results}, nil
+	})
+	tool.InputSchema = properties
+	tool.Required = required
+	return tool
+}
+
+// Format renders results as a numbered list for a model to read.
+func Format(query string, results []Result) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("No results found for %q.", query)
+	}
+	var b strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d. %s\n   %s\n", i+1, r.Title, r.URL)
+		if r.Published != "" {
+			fmt.Fprintf(&b, "   Published: %s\n", r.Published)
+		}
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", r.Snippet)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+NewTypedTool builds a tool from a typed handler. The input schema is reflected
+from the struct type In (see SchemaFor for the supported tags), so the model
+sees the same fields and constraints the handler receives.
+
+When the model calls the tool, its arguments are validated against that schema
+(types, required fields, enums, ranges and lengths) and decoded into In; a
+validation failure is returned as the tool's error, worded so the model can
+fix its call. The handler's ctx is the run's ToolContext (see FromContext).
+The handler's Out is sent back to the model as is when it is a
+string and as JSON otherwise, and is also returned as the tool's single raw
+[]interface{} result.
+
+In ReAct mode, where tools receive plain text, non-JSON input is accepted when
+In has exactly one required field and it is a string (or, with no required
+fields, exactly one field, a string): the text becomes that field's value, as
+ValidateInput assumes.
+
+NewTypedTool panics if In cannot be reflected into a schema (it is not a
+struct, or it has unsupported field types or malformed tags); that is a
+programming error, caught when the tool is declared.
+
+Example:
+
+	type WeatherInput struct {
+		City  string `json:"city" jsonschema:"required,description=City name"`
+		Units string `json:"units,omitempty" jsonschema:"enum=celsius|fahrenheit"`
+	}
+	type Weather struct {
+		TempC   float64 `json:"temp_c"`
+		Summary string  `json:"summary"`
+	}
+
+	weather := tools.NewTypedTool("get_weather", "Current weather for a city.",
+		func(ctx context.Context, in WeatherInput) (Weather, error) {
+			return lookupWeather(ctx, in.City, in.Units)
+		})
+*/
+func NewTypedTool[In, Out any](name, description string, handler func(ctx context.Context, in In) (Out, error)) BaseTool {
+	properties, required, err := SchemaFor[In]()
+	if err != nil {
+		panic(fmt.Sprintf("tools: NewTypedTool %q: %v", name, err))
+	}
+	schema := objectSchema(properties, required)
+	schema["additionalProperties"] = false
+	textField := textProperty(properties, required)
+
+	run := func(tc *ToolContext, input string) (string, []interface{}, error) {
+		in, err := decodeTypedInput[In](input, schema, textField)
+		if err != nil {
+			return "", nil, err
+		}
+		out, err := handler(tc, in)
+		if err != nil {
+			return "", nil, err
+		}
+		text, err := formatTypedOutput(out)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: encoding result: %w", name, err)
+		}
+		return text, []interface{}{out}, nil
+	}
+
+	tool := NewContextTool(name, description, run)
+	tool.InputSchema = properties
+	tool.Required = required
+	return tool
+}
+
+// decodeTypedInput validates the model's arguments against schema and
+// decodes them into In.
+func decodeTypedInput[In any](input string, schema map[string]any, textField string) (In, error) {
+	var in In
+	trimmed := strings.TrimSpace(input)
+	switch {
+	case trimmed == "":
+		trimmed = "{}"
+	case !strings.HasPrefix(trimmed, "{"):
+		if textField == "" {
+			return in, fmt.Errorf("invalid input: expected a JSON object with the fields %s", strings.Join(sortedKeys(schema["properties"].(map[string]any)), ", "))
+		}
+		encoded, _ := json.Marshal(map[string]string{textField: trimmed})
+		trimmed = string(encoded)
+	}
+
+	args, err := decodeArgs(trimmed)
+	if err != nil {
+		return in, fmt.Errorf("invalid input: not valid JSON: %w", err)
+	}
+	if err := validateValue(schema, args, "input"); err != nil {
+		return in, fmt.Errorf("invalid input: %w", err)
+	}
+	if err := json.Unmarshal([]byte(trimmed), &in); err != nil {
+		return in, fmt.Errorf("invalid input: %w", err)
+	}
+	return in, nil
+}
+
+// singleStringField returns the name of the only property when it is a
+// string, and "" otherwise.
+func singleStringField(properties map[string]any) string {
+	if len(properties) != 1 {
+		return ""
+	}
+	for name, p := range properties {
+		if prop, ok := p.(map[string]any); ok && prop["type"] == "string" {
+			return name
+		}
+	}
+	return ""
+}
+
+func formatTypedOutput(out any) (string, error) {
+	if s, ok := out.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,351 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+ValidateInput checks a tool's input against its InputSchema before the tool
+runs, so a malformed call can be reported back to the model instead of
+reaching ToolFunc. Tools without an InputSchema take free text and always
+pass.
+
+The input must be a JSON object matching the schema: property types,
+required properties, enums, numeric ranges, string and array lengths, and
+nested objects and arrays are checked. Plain (non-JSON) text, as written in
+ReAct mode, is accepted when the schema has a single string property the text
+can stand for: the only required property, or the only property when none is
+required. It is then checked against that property's schema.
+
+The error names the offending field, e.g. `input.days: must be <= 14, got 30`.
+*/
+func ValidateInput(tool BaseTool, input string) error {
+	if tool.InputSchema == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" {
+		trimmed = "{}"
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		name := textProperty(tool.InputSchema, tool.Required)
+		if name == "" {
+			return fmt.Errorf("expected a JSON object with the fields %s", strings.Join(sortedKeys(tool.InputSchema), ", "))
+		}
+		prop, _ := tool.InputSchema[name].(map[string]any)
+		return validateValue(prop, trimmed, "input."+name)
+	}
+	args, err := decodeArgs(trimmed)
+	if err != nil {
+		return fmt.Errorf("input is not valid JSON: %w", err)
+	}
+	return validateValue(objectSchema(tool.InputSchema, tool.Required), args, "input")
+}
+
+// InputArguments returns a structured tool's input as a JSON object: JSON
+// input is returned as is, empty input becomes {}, and plain text is wrapped
+// as the string property it stands for (see ValidateInput).
+func InputArguments(tool BaseTool, input string) (json.RawMessage, error) {
+	trimmed := strings.TrimSpace(input)
+	switch {
+	case trimmed == "":
+		return json.RawMessage("{}"), nil
+	case strings.HasPrefix(trimmed, "{"):
+		if !json.Valid([]byte(trimmed)) {
+			return nil, fmt.Errorf("input is not valid JSON")
+		}
+		return json.RawMessage(trimmed), nil
+	}
+	name := textProperty(tool.InputSchema, tool.Required)
+	if name == "" {
+		return nil, fmt.Errorf("expected a JSON object with the fields %s", strings.Join(sortedKeys(tool.InputSchema), ", "))
+	}
+	return json.Marshal(map[string]string{name: trimmed})
+}
+
+// textProperty returns the string property that plain-text input stands for,
+// or "" if there is none.
+func textProperty(properties map[string]any, required []string) string {
+	if len(required) == 1 {
+		if prop, ok := properties[required[0]].(map[string]any); ok && prop["type"] == "string" {
+			return required[0]
+		}
+		return ""
+	}
+	if len(required) == 0 {
+		return singleStringField(properties)
+	}
+	return ""
+}
+
+// objectSchema wraps a tool's properties and required names into a full
+// object schema.
+func objectSchema(properties map[string]any, required []string) map[string]any {
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+// decodeArgs decodes a JSON value keeping numbers as json.Number, so integers
+// and floats can be told apart during validation.
+func decodeArgs(data string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+// validateValue checks v, as decoded by decodeArgs, against a JSON schema.
+// It understands the subset of JSON schema produced by SchemaFor (type, enum,
+// numeric bounds, string and array lengths, pattern, items, properties,
+// required and additionalProperties); other keywords are ignored. Schemas
+// may hold numbers as any numeric type, as they do after a JSON round trip.
+// path names v in error messages.
+func validateValue(schema map[string]any, v any, path string) error {
+	if t, ok := schema["type"]; ok {
+		if err := checkType(t, v, path); err != nil {
+			return err
+		}
+	}
+	if enum := schemaEnum(schema["enum"]); enum != nil && !inEnum(enum, v) {
+		return fmt.Errorf("%s: must be one of %s, got %s", path, formatEnum(enum), formatValue(v))
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		n, _ := v.Float64()
+		if min, ok := schemaNumber(schema["minimum"]); ok && n < min {
+			return fmt.Errorf("%s: must be >= %s, got %s", path, formatNumber(min), v)
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && n > max {
+			return fmt.Errorf("%s: must be <= %s, got %s", path, formatNumber(max), v)
+		}
+	case string:
+		length := len([]rune(v))
+		if min, ok := schemaNumber(schema["minLength"]); ok && float64(length) < min {
+			return fmt.Errorf("%s: must be at least %s characters long", path, formatNumber(min))
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && float64(length) > max {
+			return fmt.Errorf("%s: must be at most %s characters long", path, formatNumber(max))
+		}
+		if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(v) {
+				return fmt.Errorf("%s: must match the pattern %s", path, pattern)
+			}
+		}
+	case []any:
+		if min, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < min {
+			return fmt.Errorf("%s: must have at least %s items", path, formatNumber(min))
+		}
+		if max, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > max {
+			return fmt.Errorf("%s: must have at most %s items", path, formatNumber(max))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		return validateObject(schema, v, path)
+	}
+	return nil
+}
+
+func validateObject(schema map[string]any, v map[string]any, path string) error {
+	for _, name := range schemaStrings(schema["required"]) {
+		if _, ok := v[name]; !ok {
+			return fmt.Errorf("%s: missing required field %q", path, name)
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field := path + "." + k
+		if prop, ok := properties[k].(map[string]any); ok {
+			if err := validateValue(prop, v[k], field); err != nil {
+				return err
+			}
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: unknown field %q; expected fields: %s", path, k, strings.Join(sortedKeys(properties), ", "))
+			}
+		case map[string]any:
+			if err := validateValue(extra, v[k], field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkType reports whether v has one of the JSON types named by t (a string
+// or a list of strings).
+func checkType(t any, v any, path string) error {
+	types := schemaStrings(t)
+	if s, ok := t.(string); ok {
+		types = []string{s}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	for _, want := range types {
+		if hasType(want, v) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: must be %s, got %s", path, strings.Join(types, " or "), jsonType(v))
+}
+
+func hasType(want string, v any) bool {
+	switch want {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return jsonType(v) == want
+	}
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if n, ok := v.(json.Number); ok {
+			f, _ := n.Float64()
+			if en, ok := schemaNumber(e); ok && en == f {
+				return true
+			}
+			continue
+		}
+		if formatValue(e) == formatValue(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = formatValue(e)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func formatValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return formatNumber(f)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func formatNumber(f float64) string {
+	return fmt.Sprintf("%g", f)
+}
+
+// schemaNumber reads a numeric schema keyword, whatever Go type holds it.
+func schemaNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// schemaEnum reads an enum held as []any or []string.
+func schemaEnum(v any) []any {
+	switch e := v.(type) {
+	case []any:
+		return e
+	case []string:
+		out := make([]any, len(e))
+		for i, s := range e {
+			out[i] = s
+		}
+		return out
+	}
+	return nil
+}
+
+// schemaStrings reads a list of strings held as []string or []any.
+func schemaStrings(v any) []string {
+	switch s := v.(type) {
+	case []string:
+		return s
+	case []any:
+		out := make([]string, 0, len(s))
+		for _, e := range s {
+			if str, ok := e.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateInput(t *testing.T) {
+	search := BaseTool{
+		Name: "search",
+		InputSchema: map[string]any{
+			"query": map[string]any{"type": "string", "maxLength": 20},
+			"top_k": map[string]any{"type": "integer", "minimum": 1, "maximum": 10},
+			"sort":  map[string]any{"type": "string", "enum": []string{"relevance", "date"}},
+			"filters": map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"product": map[string]any{"type": "string"}},
+				"additionalProperties": false,
+			},
+		},
+		Required: []string{"query"},
+	}
+	pair := BaseTool{
+		Name: "convert",
+		InputSchema: map[string]any{
+			"amount":   map[string]any{"type": "number"},
+			"currency": map[string]any{"type": "string"},
+		},
+		Required: []string{"amount", "currency"},
+	}
+	cases := []struct {
+		tool    BaseTool
+		input   string
+		wantErr string
+	}{
+		{BaseTool{Name: "free"}, "anything at all", ""},
+		{search, `{"query":"refunds","top_k":3,"sort":"date","filters":{"product":"drill"}}`, ""},
+		{search, "refund policy", ""},
+		{search, "a plain text query that is far too long", "input.query: must be at most 20 characters long"},
+		{search, `{"top_k":3}`, `input: missing required field "query"`},
+		{search, `{"query":"x","top_k":"3"}`, "input.top_k: must be integer, got string"},
+		{search, `{"query":"x","top_k":0}`, "input.top_k: must be >= 1, got 0"},
+		{search, `{"query":"x","sort":"price"}`, `input.sort: must be one of ["relevance", "date"], got "price"`},
+		{search, `{"query":"x","filters":{"colour":"red"}}`, `input.filters: unknown field "colour"; expected fields: product`},
+		{search, `{"query":"x"`, "input is not valid JSON"},
+		{search, `{"query":"x"} trailing`, "input is not valid JSON"},
+		{pair, `{"amount":12.5,"currency":"EUR"}`, ""},
+		{pair, "12.5 EUR", "expected a JSON object with the fields amount, currency"},
+		{pair, "", `input: missing required field "amount"`},
+	}
+	for _, c := range cases {
+		err := ValidateInput(c.tool, c.input)
+		switch {
+		case c.wantErr == "" && err != nil:
+			t.Errorf("%s %s: unexpected error %v", c.tool.Name, c.input, err)
+		case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+			t.Errorf("%s %s: error = %v, want %q", c.tool.Name, c.input, err, c.wantErr)
+		}
+	}
+}
+
+func TestValidateInput_RetrieverTool(t *testing.T) {
+	tool := NewRetrieverTool(nil, nil, RetrieverConfig{FilterFields: []string{"product"}})
+	if err := ValidateInput(tool, "what is the refund window?"); err != nil {
+		t.Errorf("plain-text query rejected: %v", err)
+	}
+	if err := ValidateInput(tool, `{"query":"refunds","filters":{"tenant":"globex"}}`); err == nil {
+		t.Error("filter on an unexposed field accepted")
+	}
+}
@@ -29,4 +29,20 @@ type LLMArgs struct {
 	// Compactor, when set, enables conversation compaction (rolling summary +
 	// recent turns) in place of raw last-K memory retrieval.
 	Compactor *memory.Compactor
+	// ChatMemory, when set, stores the agent's conversation transcript in place
+	// of the MongoDB collection (e.g. a Redis-backed memory).
+	ChatMemory memory.ChatMemory
+	// Recaller, when set, embeds each completed turn into a vector store and
+	// injects the most relevant older turns into the prompt.
+	Recaller *memory.Recaller
+	// UserMemory, when set, extracts durable facts about the user after each
+	// turn and injects them into the system prompt as a profile block.
+	UserMemory *memory.UserMemory
+	// Sessions, when set, records each saved turn on its session (user ID,
+	// auto-generated title, timestamps) for listing and managing
+	// conversations.
+	Sessions *memory.SessionManager
+	// ToolCallHistory, when true, lists earlier turns' tool calls in the chat
+	// history injected into the prompt.
+	ToolCallHistory bool
 }